@@ -0,0 +1,508 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// batchGetSize is the maximum number of keys of BatchGetItem request
+	batchGetSize = 100
+	// batchWriteSize is the maximum number of requests of BatchWriteItem request
+	batchWriteSize = 25
+)
+
+var (
+	// ErrBatchIncomplete is wrapped by the error returned from BatchGet and BatchWrite when any item failed
+	ErrBatchIncomplete = errors.New("batch incomplete")
+	// ErrUnprocessed is set on BatchItemError of the item still unprocessed after retries
+	ErrUnprocessed = errors.New("unprocessed after retries")
+)
+
+// BatchItemError is error of single item of batch
+type BatchItemError struct {
+	// Index is the position of the item in the keys or requests passed to the batch
+	Index int
+	Err   error
+}
+
+// Error returns the error message with index
+func (e *BatchItemError) Error() string {
+	return fmt.Sprintf("item %d: %v", e.Index, e.Err)
+}
+
+// Unwrap returns the cause
+func (e *BatchItemError) Unwrap() error {
+	return e.Err
+}
+
+// BatchGetResult is result of BatchGet
+type BatchGetResult struct {
+	// Items is found items. The order is not the same as keys
+	Items  []map[string]types.AttributeValue
+	Errors []*BatchItemError
+}
+
+// Unmarshal unmarshals items into out. out must be pointer to slice
+func (r *BatchGetResult) Unmarshal(out any) error {
+	return attributevalue.UnmarshalListOfMaps(r.Items, out)
+}
+
+// BatchWriteResult is result of BatchWrite
+type BatchWriteResult struct {
+	Errors []*BatchItemError
+}
+
+// WriteRequest is put or delete request of BatchWrite
+type WriteRequest struct {
+	put    any
+	delete Key
+}
+
+// PutRequest returns WriteRequest putting item marshaled by attributevalue.MarshalMap
+func PutRequest(item any) *WriteRequest {
+	return &WriteRequest{put: item}
+}
+
+// DeleteRequest returns WriteRequest deleting the item of key
+func DeleteRequest(key Key) *WriteRequest {
+	return &WriteRequest{delete: key}
+}
+
+func (r *WriteRequest) writeRequest() (types.WriteRequest, error) {
+	if r.delete != nil {
+		key, err := r.delete.attributeValues()
+		if err != nil {
+			return types.WriteRequest{}, err
+		}
+		return types.WriteRequest{DeleteRequest: &types.DeleteRequest{Key: key}}, nil
+	}
+
+	item, err := attributevalue.MarshalMap(r.put)
+	if err != nil {
+		return types.WriteRequest{}, err
+	}
+	return types.WriteRequest{PutRequest: &types.PutRequest{Item: item}}, nil
+}
+
+type batchConfig struct {
+	tableName      string
+	concurrency    int
+	maxRetries     int
+	baseDelay      time.Duration
+	maxDelay       time.Duration
+	consistentRead bool
+	keyNames       []string
+	clientOpts     []func(*dynamodb.Options)
+}
+
+func newBatchConfig() *batchConfig {
+	return &batchConfig{
+		tableName:      "",
+		concurrency:    4,
+		maxRetries:     5,
+		baseDelay:      50 * time.Millisecond,
+		maxDelay:       5 * time.Second,
+		consistentRead: false,
+		keyNames:       nil,
+		clientOpts:     nil,
+	}
+}
+
+func createBatchConfig(batchOpts ...BatchOption) (*batchConfig, error) {
+	bc := newBatchConfig()
+
+	for _, opt := range batchOpts {
+		err := opt(bc)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return bc, nil
+}
+
+// BatchOption is functional option pattern option for BatchGet and BatchWrite
+type BatchOption func(*batchConfig) error
+
+// BatchOptionTable returns BatchOption instance using table instead of DefaultTableName
+func BatchOptionTable(name string) func(c *batchConfig) error {
+	return func(c *batchConfig) error {
+		if name == "" {
+			return errors.New("table name is empty")
+		}
+		c.tableName = name
+		return nil
+	}
+}
+
+// BatchOptionConcurrency returns BatchOption instance with the maximum number of requests running at once. default is 4
+func BatchOptionConcurrency(n int) func(c *batchConfig) error {
+	return func(c *batchConfig) error {
+		if n < 1 {
+			return errors.New("concurrency must be positive")
+		}
+		c.concurrency = n
+		return nil
+	}
+}
+
+// BatchOptionRetry returns BatchOption instance retrying unprocessed items up to maxRetries times.
+// The delay starts from baseDelay and doubles up to maxDelay. default is 5 retries from 50ms up to 5s
+func BatchOptionRetry(maxRetries int, baseDelay, maxDelay time.Duration) func(c *batchConfig) error {
+	return func(c *batchConfig) error {
+		if maxRetries < 0 {
+			return errors.New("max retries must not be negative")
+		}
+		if baseDelay <= 0 || maxDelay < baseDelay {
+			return errors.New("delay must be positive and max delay must not be less than base delay")
+		}
+		c.maxRetries = maxRetries
+		c.baseDelay = baseDelay
+		c.maxDelay = maxDelay
+		return nil
+	}
+}
+
+// BatchOptionConsistentRead returns BatchOption instance with strongly consistent read of BatchGet
+func BatchOptionConsistentRead() func(c *batchConfig) error {
+	return func(c *batchConfig) error {
+		c.consistentRead = true
+		return nil
+	}
+}
+
+// BatchOptionKeyNames returns BatchOption instance with key attribute names of the table, which BatchWrite otherwise reads by DescribeTable
+func BatchOptionKeyNames(names ...string) func(c *batchConfig) error {
+	return func(c *batchConfig) error {
+		if len(names) == 0 || len(names) > 2 || slices.Contains(names, "") {
+			return errors.New("key names must be one or two non-empty names")
+		}
+		c.keyNames = names
+		return nil
+	}
+}
+
+// BatchOptionClientOptions returns BatchOption instance with request options
+func BatchOptionClientOptions(optFns ...func(*dynamodb.Options)) func(c *batchConfig) error {
+	return func(c *batchConfig) error {
+		c.clientOpts = append(c.clientOpts, optFns...)
+		return nil
+	}
+}
+
+func (bc *batchConfig) table(d *DynamoDB) string {
+	if bc.tableName != "" {
+		return bc.tableName
+	}
+	return d.DefaultTableName
+}
+
+// delay returns backoff delay before retry of attempt starting from 0
+func (bc *batchConfig) delay(attempt int) time.Duration {
+	delay := bc.baseDelay << attempt
+	if delay <= 0 || delay > bc.maxDelay {
+		return bc.maxDelay
+	}
+	return delay
+}
+
+// BatchGet gets items of keys by BatchGetItem in chunks of 100 keys run concurrently.
+// Duplicated keys are read once. Missing items are not reported as error.
+// The result is returned even when error wrapping ErrBatchIncomplete is returned
+func (d *DynamoDB) BatchGet(ctx context.Context, keys []Key, batchOpts ...BatchOption) (*BatchGetResult, error) {
+	bc, err := createBatchConfig(batchOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BatchGetResult{}
+
+	// index of keys by signature to map unprocessed keys back
+	indexes := make(map[string][]int, len(keys))
+	unique := make([]map[string]types.AttributeValue, 0, len(keys))
+	for i, key := range keys {
+		av, err := key.attributeValues()
+		if err != nil {
+			result.Errors = append(result.Errors, &BatchItemError{Index: i, Err: err})
+			continue
+		}
+
+		sig := keySignature(av)
+		if _, ok := indexes[sig]; !ok {
+			unique = append(unique, av)
+		}
+		indexes[sig] = append(indexes[sig], i)
+	}
+
+	var mu sync.Mutex
+	runChunks(ctx, bc, len(unique), batchGetSize, func(ctx context.Context, start, end int) {
+		items, unprocessed, err := d.batchGetChunk(ctx, bc, unique[start:end])
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		result.Items = append(result.Items, items...)
+		for _, key := range unprocessed {
+			for _, i := range indexes[keySignature(key)] {
+				result.Errors = append(result.Errors, &BatchItemError{Index: i, Err: err})
+			}
+		}
+	})
+
+	if len(result.Errors) > 0 {
+		sortBatchItemErrors(result.Errors)
+		return result, fmt.Errorf("%w: %d of %d keys failed", ErrBatchIncomplete, len(result.Errors), len(keys))
+	}
+
+	return result, nil
+}
+
+// batchGetChunk gets keys with retries. unprocessed keys are returned with the cause
+func (d *DynamoDB) batchGetChunk(ctx context.Context, bc *batchConfig, keys []map[string]types.AttributeValue) ([]map[string]types.AttributeValue, []map[string]types.AttributeValue, error) {
+	table := bc.table(d)
+	var items []map[string]types.AttributeValue
+
+	for attempt := 0; ; attempt++ {
+		in := &dynamodb.BatchGetItemInput{
+			RequestItems: map[string]types.KeysAndAttributes{
+				table: {Keys: keys},
+			},
+		}
+		if bc.consistentRead {
+			in.RequestItems[table] = types.KeysAndAttributes{Keys: keys, ConsistentRead: aws.Bool(true)}
+		}
+
+		res, err := d.DynamoDB.BatchGetItem(ctx, in, bc.clientOpts...)
+		if err != nil {
+			return items, keys, err
+		}
+
+		items = append(items, res.Responses[table]...)
+
+		keys = res.UnprocessedKeys[table].Keys
+		if len(keys) == 0 {
+			return items, nil, nil
+		}
+		if attempt >= bc.maxRetries {
+			return items, keys, ErrUnprocessed
+		}
+
+		err = sleep(ctx, bc.delay(attempt))
+		if err != nil {
+			return items, keys, err
+		}
+	}
+}
+
+// BatchWrite runs put and delete requests by BatchWriteItem in chunks of 25 requests run concurrently.
+// Requests of the same key are collapsed into the last one, because DynamoDB rejects a chunk with duplicated keys, and failure is reported by the index of the last one.
+// The keys of put requests are found by BatchOptionKeyNames, or by the key schema read by DescribeTable once per table.
+// The result is returned even when error wrapping ErrBatchIncomplete is returned
+func (d *DynamoDB) BatchWrite(ctx context.Context, requests []*WriteRequest, batchOpts ...BatchOption) (*BatchWriteResult, error) {
+	bc, err := createBatchConfig(batchOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	keyNames := bc.keyNames
+	if keyNames == nil && len(requests) > 1 && slices.ContainsFunc(requests, func(r *WriteRequest) bool { return r.delete == nil }) {
+		keyNames, err = d.keyNames(ctx, bc.table(d), bc.clientOpts...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result := &BatchWriteResult{}
+
+	// index of requests by key signature to map unprocessed requests back. The last request of the key wins
+	indexes := make(map[string]int, len(requests))
+	positions := make(map[string]int, len(requests))
+	writes := make([]types.WriteRequest, 0, len(requests))
+	for i, r := range requests {
+		w, err := r.writeRequest()
+		if err != nil {
+			result.Errors = append(result.Errors, &BatchItemError{Index: i, Err: err})
+			continue
+		}
+
+		sig := writeRequestSignature(w, keyNames)
+		indexes[sig] = i
+		if pos, ok := positions[sig]; ok {
+			writes[pos] = w
+			continue
+		}
+		positions[sig] = len(writes)
+		writes = append(writes, w)
+	}
+
+	var mu sync.Mutex
+	runChunks(ctx, bc, len(writes), batchWriteSize, func(ctx context.Context, start, end int) {
+		unprocessed, err := d.batchWriteChunk(ctx, bc, writes[start:end])
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		for _, w := range unprocessed {
+			result.Errors = append(result.Errors, &BatchItemError{Index: indexes[writeRequestSignature(w, keyNames)], Err: err})
+		}
+	})
+
+	if len(result.Errors) > 0 {
+		sortBatchItemErrors(result.Errors)
+		return result, fmt.Errorf("%w: %d of %d requests failed", ErrBatchIncomplete, len(result.Errors), len(requests))
+	}
+
+	return result, nil
+}
+
+// batchWriteChunk writes requests with retries. unprocessed requests are returned with the cause
+func (d *DynamoDB) batchWriteChunk(ctx context.Context, bc *batchConfig, writes []types.WriteRequest) ([]types.WriteRequest, error) {
+	table := bc.table(d)
+
+	for attempt := 0; ; attempt++ {
+		res, err := d.DynamoDB.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+			RequestItems: map[string][]types.WriteRequest{
+				table: writes,
+			},
+		}, bc.clientOpts...)
+		if err != nil {
+			return writes, err
+		}
+
+		writes = res.UnprocessedItems[table]
+		if len(writes) == 0 {
+			return nil, nil
+		}
+		if attempt >= bc.maxRetries {
+			return writes, ErrUnprocessed
+		}
+
+		err = sleep(ctx, bc.delay(attempt))
+		if err != nil {
+			return writes, err
+		}
+	}
+}
+
+// runChunks calls fn for every chunk of n items by size concurrently
+func runChunks(ctx context.Context, bc *batchConfig, n, size int, fn func(ctx context.Context, start, end int)) {
+	sem := make(chan struct{}, bc.concurrency)
+
+	var wg sync.WaitGroup
+	for start := 0; start < n; start += size {
+		end := min(start+size, n)
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(ctx, start, end)
+		}()
+	}
+	wg.Wait()
+}
+
+func sortBatchItemErrors(errs []*BatchItemError) {
+	slices.SortFunc(errs, func(a, b *BatchItemError) int {
+		return a.Index - b.Index
+	})
+}
+
+// keySignature returns string identifying key
+func keySignature(key map[string]types.AttributeValue) string {
+	var b strings.Builder
+	writeSignature(&b, &types.AttributeValueMemberM{Value: key})
+	return b.String()
+}
+
+// writeRequestSignature returns string identifying the key of write request.
+// Only attributes of keyNames are used, and the whole key of delete request is used when keyNames is empty
+func writeRequestSignature(w types.WriteRequest, keyNames []string) string {
+	var item map[string]types.AttributeValue
+	if w.DeleteRequest != nil {
+		item = w.DeleteRequest.Key
+	} else {
+		item = w.PutRequest.Item
+	}
+	if len(keyNames) == 0 {
+		return keySignature(item)
+	}
+
+	key := make(map[string]types.AttributeValue, len(keyNames))
+	for _, name := range keyNames {
+		if av, ok := item[name]; ok {
+			key[name] = av
+		}
+	}
+	return keySignature(key)
+}
+
+// writeSignature writes canonical form of av. map keys and sets are sorted
+func writeSignature(b *strings.Builder, av types.AttributeValue) {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		fmt.Fprintf(b, "S%q", v.Value)
+	case *types.AttributeValueMemberN:
+		fmt.Fprintf(b, "N%q", v.Value)
+	case *types.AttributeValueMemberB:
+		fmt.Fprintf(b, "B%x", v.Value)
+	case *types.AttributeValueMemberBOOL:
+		fmt.Fprintf(b, "T%t", v.Value)
+	case *types.AttributeValueMemberNULL:
+		b.WriteString("0")
+	case *types.AttributeValueMemberSS:
+		fmt.Fprintf(b, "SS%q", slices.Sorted(slices.Values(v.Value)))
+	case *types.AttributeValueMemberNS:
+		fmt.Fprintf(b, "NS%q", slices.Sorted(slices.Values(v.Value)))
+	case *types.AttributeValueMemberBS:
+		values := make([]string, 0, len(v.Value))
+		for _, x := range v.Value {
+			values = append(values, fmt.Sprintf("%x", x))
+		}
+		slices.Sort(values)
+		fmt.Fprintf(b, "BS%q", values)
+	case *types.AttributeValueMemberL:
+		b.WriteString("L[")
+		for _, x := range v.Value {
+			writeSignature(b, x)
+			b.WriteString(",")
+		}
+		b.WriteString("]")
+	case *types.AttributeValueMemberM:
+		b.WriteString("M{")
+		for _, k := range slices.Sorted(maps.Keys(v.Value)) {
+			fmt.Fprintf(b, "%q:", k)
+			writeSignature(b, v.Value[k])
+			b.WriteString(",")
+		}
+		b.WriteString("}")
+	default:
+		fmt.Fprintf(b, "?%T", av)
+	}
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,224 @@
+package dynamodb_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rssh-jp/data-access-library/aws/dynamodb"
+	"github.com/rssh-jp/data-access-library/aws/dynamodb/memory"
+)
+
+type batchItem struct {
+	Key   string `dynamodbav:"key"`
+	Value string `dynamodbav:"value"`
+}
+
+func TestBatch(t *testing.T) {
+	const items = 230
+
+	retry := dynamodb.BatchOptionRetry(3, time.Millisecond, 10*time.Millisecond)
+
+	write := func(t *testing.T, d *dynamodb.DynamoDB) {
+		t.Helper()
+
+		requests := make([]*dynamodb.WriteRequest, 0, items)
+		for i := 0; i < items; i++ {
+			requests = append(requests, dynamodb.PutRequest(batchItem{Key: fmt.Sprintf("key%03d", i), Value: "value"}))
+		}
+
+		_, err := d.BatchWrite(context.Background(), requests, retry)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("BatchWrite and BatchGet", func(t *testing.T) {
+		c := newTestClient(t)
+		d := c.newDynamoDB()
+		if err := d.CreateDefaultTable(); err != nil {
+			t.Fatal(err)
+		}
+
+		write(t, d)
+
+		keys := make([]dynamodb.Key, 0, items+1)
+		for i := 0; i < items; i++ {
+			keys = append(keys, d.Key(fmt.Sprintf("key%03d", i)))
+		}
+		// duplicated and missing keys
+		keys = append(keys, d.Key("key000"), d.Key("unknown"))
+
+		res, err := d.BatchGet(context.Background(), keys, retry)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var actual []batchItem
+		err = res.Unmarshal(&actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(actual) != items {
+			t.Errorf("Could not match items.\nexpect: %d\nactual: %d", items, len(actual))
+		}
+	})
+	t.Run("Retry unprocessed", func(t *testing.T) {
+		c := newTestClient(t)
+		d := c.newDynamoDB()
+		if err := d.CreateDefaultTable(); err != nil {
+			t.Fatal(err)
+		}
+
+		c.unprocessedBatches = 3
+		write(t, d)
+
+		c.unprocessedBatches = 2
+		res, err := d.BatchGet(context.Background(), []dynamodb.Key{d.Key("key000"), d.Key("key001")}, retry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Items) != 2 {
+			t.Errorf("Could not match items.\nexpect: %d\nactual: %d", 2, len(res.Items))
+		}
+	})
+	t.Run("Retries exhausted", func(t *testing.T) {
+		c := newTestClient(t)
+		d := c.newDynamoDB()
+		if err := d.CreateDefaultTable(); err != nil {
+			t.Fatal(err)
+		}
+
+		c.unprocessedBatches = 100
+		requests := []*dynamodb.WriteRequest{
+			dynamodb.PutRequest(batchItem{Key: "a", Value: "value"}),
+			dynamodb.DeleteRequest(d.Key("b")),
+		}
+		res, err := d.BatchWrite(context.Background(), requests, retry)
+		if !errors.Is(err, dynamodb.ErrBatchIncomplete) {
+			t.Fatalf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrBatchIncomplete, err)
+		}
+		if len(res.Errors) != 1 || res.Errors[0].Index != 1 || !errors.Is(res.Errors[0], dynamodb.ErrUnprocessed) {
+			t.Errorf("Could not match item errors. actual: %v", res.Errors)
+		}
+
+		getRes, err := d.BatchGet(context.Background(), []dynamodb.Key{d.Key("a"), d.Key("b"), d.Key("a")}, retry)
+		if !errors.Is(err, dynamodb.ErrBatchIncomplete) {
+			t.Fatalf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrBatchIncomplete, err)
+		}
+		// the last unique key is unprocessed
+		if len(getRes.Errors) != 1 || getRes.Errors[0].Index != 1 {
+			t.Errorf("Could not match item errors. actual: %v", getRes.Errors)
+		}
+		if len(getRes.Items) != 1 {
+			t.Errorf("Could not match items.\nexpect: %d\nactual: %d", 1, len(getRes.Items))
+		}
+	})
+	t.Run("Duplicated keys", func(t *testing.T) {
+		c := newTestClient(t)
+		d := c.newDynamoDB()
+		if err := d.CreateDefaultTable(); err != nil {
+			t.Fatal(err)
+		}
+
+		// the last request of the key wins
+		_, err := d.BatchWrite(context.Background(), []*dynamodb.WriteRequest{
+			dynamodb.PutRequest(batchItem{Key: "a", Value: "first"}),
+			dynamodb.PutRequest(batchItem{Key: "b", Value: "first"}),
+			dynamodb.PutRequest(batchItem{Key: "a", Value: "second"}),
+			dynamodb.DeleteRequest(d.Key("b")),
+		}, retry)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual, err := d.Get("a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != "second" {
+			t.Errorf("Could not match value.\nexpect: %s\nactual: %s", "second", actual)
+		}
+		_, err = d.Get("b")
+		if !errors.Is(err, dynamodb.ErrNotFound) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrNotFound, err)
+		}
+
+		// failure of the key is reported once by the index of the last request
+		c.unprocessedBatches = 100
+		res, err := d.BatchWrite(context.Background(), []*dynamodb.WriteRequest{
+			dynamodb.PutRequest(batchItem{Key: "b", Value: "third"}),
+			dynamodb.PutRequest(batchItem{Key: "a", Value: "third"}),
+			dynamodb.PutRequest(batchItem{Key: "a", Value: "third"}),
+		}, retry)
+		if !errors.Is(err, dynamodb.ErrBatchIncomplete) {
+			t.Fatalf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrBatchIncomplete, err)
+		}
+		if len(res.Errors) != 1 || res.Errors[0].Index != 2 {
+			t.Errorf("Could not match item errors. actual: %v", res.Errors)
+		}
+	})
+	t.Run("Key schema", func(t *testing.T) {
+		c, err := memory.New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		client := &describeFailingClient{Client: c}
+		d := dynamodb.NewFromClient(client)
+		if err := d.CreateDefaultTable(); err != nil {
+			t.Fatal(err)
+		}
+
+		requests := []*dynamodb.WriteRequest{
+			dynamodb.PutRequest(batchItem{Key: "a", Value: "value"}),
+			dynamodb.PutRequest(batchItem{Key: "b", Value: "value"}),
+		}
+
+		// the key schema is read once per table
+		for range 3 {
+			_, err := d.BatchWrite(context.Background(), requests, retry)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if actual := client.describes.Load(); actual != 1 {
+			t.Errorf("Bug. the key schema should be cached. But %d calls of DescribeTable", actual)
+		}
+
+		// the key schema is not read when the key names are given
+		err = d.CreateTableFromDefinition(&dynamodb.TableDefinition{
+			Name:         "other",
+			PartitionKey: dynamodb.KeyAttribute{Name: "key"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		client.describes.Store(0)
+		_, err = d.BatchWrite(context.Background(), requests, retry, dynamodb.BatchOptionTable("other"), dynamodb.BatchOptionKeyNames("key"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := client.describes.Load(); actual != 0 {
+			t.Errorf("Bug. DescribeTable should not be called with the key names. But %d calls", actual)
+		}
+
+		_, err = d.BatchWrite(context.Background(), requests, dynamodb.BatchOptionKeyNames("key", "sort", "extra"))
+		if err == nil {
+			t.Error("Bug. more than two key names should be rejected. But no error")
+		}
+	})
+	t.Run("Request error", func(t *testing.T) {
+		c := newTestClient(t)
+		d := c.newDynamoDB()
+
+		res, err := d.BatchGet(context.Background(), []dynamodb.Key{d.Key("a"), d.Key("b")}, dynamodb.BatchOptionTable("unknown"))
+		if !errors.Is(err, dynamodb.ErrBatchIncomplete) {
+			t.Fatalf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrBatchIncomplete, err)
+		}
+		if len(res.Errors) != 2 {
+			t.Errorf("Could not match item errors. actual: %v", res.Errors)
+		}
+	})
+}
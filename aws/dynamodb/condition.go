@@ -0,0 +1,90 @@
+package dynamodb
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+var (
+	// ErrConditionFailed is matched by errors.Is when condition of write is not satisfied
+	ErrConditionFailed = errors.New("condition failed")
+)
+
+// ConditionFailedError is returned when condition of write is not satisfied
+type ConditionFailedError struct {
+	// Item is the stored item at the time of the check. nil when the item does not exist
+	Item map[string]types.AttributeValue
+	Err  error
+}
+
+// Error returns the error message
+func (e *ConditionFailedError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrConditionFailed, e.Err)
+}
+
+// Is reports whether target is ErrConditionFailed
+func (e *ConditionFailedError) Is(target error) bool {
+	return target == ErrConditionFailed
+}
+
+// Unwrap returns the cause
+func (e *ConditionFailedError) Unwrap() error {
+	return e.Err
+}
+
+// conditionError returns ConditionFailedError when err is ConditionalCheckFailedException
+func conditionError(err error) error {
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return &ConditionFailedError{Item: ccf.Item, Err: err}
+	}
+	return err
+}
+
+// versionOf returns the number of version attribute. 0 is returned when the attribute is missing
+func versionOf(item map[string]types.AttributeValue, name string) (int64, error) {
+	av, ok := item[name]
+	if !ok {
+		return 0, nil
+	}
+
+	n, ok := av.(*types.AttributeValueMemberN)
+	if !ok {
+		return 0, fmt.Errorf("version attribute %s must be number: %T", name, av)
+	}
+
+	return strconv.ParseInt(n.Value, 10, 64)
+}
+
+// versionCondition returns condition that the stored version equals version
+func versionCondition(name string, version int64) expression.ConditionBuilder {
+	if version == 0 {
+		return expression.AttributeNotExists(expression.Name(name))
+	}
+	return expression.Name(name).Equal(expression.Value(version))
+}
+
+// conditionBuilder returns the condition of write combined with the version check. nil is returned without condition.
+// current is the item or the key holding the version of the caller
+func (wc *writeConfig) conditionBuilder(current map[string]types.AttributeValue) (*expression.ConditionBuilder, int64, error) {
+	cond := wc.condition
+	if wc.versionName == "" {
+		return cond, 0, nil
+	}
+
+	version, err := versionOf(current, wc.versionName)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	vc := versionCondition(wc.versionName, version)
+	if cond != nil {
+		vc = cond.And(vc)
+	}
+
+	return &vc, version, nil
+}
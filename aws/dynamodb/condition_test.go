@@ -0,0 +1,164 @@
+package dynamodb_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/rssh-jp/data-access-library/aws/dynamodb"
+)
+
+type versionedItem struct {
+	Key     string `dynamodbav:"key"`
+	Value   string `dynamodbav:"value"`
+	Version int64  `dynamodbav:"version"`
+}
+
+func TestCondition(t *testing.T) {
+	newDynamoDB := func(t *testing.T) *dynamodb.DynamoDB {
+		c := newTestClient(t)
+		d := c.newDynamoDB()
+		if err := d.CreateDefaultTable(); err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	t.Run("Put if not exists", func(t *testing.T) {
+		d := newDynamoDB(t)
+		notExists := dynamodb.WriteOptionCondition(expression.AttributeNotExists(expression.Name("key")))
+
+		err := dynamodb.PutItem(context.Background(), d, batchItem{Key: "k", Value: "first"}, notExists)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = dynamodb.PutItem(context.Background(), d, batchItem{Key: "k", Value: "second"}, notExists)
+		if !errors.Is(err, dynamodb.ErrConditionFailed) {
+			t.Fatalf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrConditionFailed, err)
+		}
+
+		var cfe *dynamodb.ConditionFailedError
+		if !errors.As(err, &cfe) {
+			t.Fatalf("Could not match error type. actual: %T", err)
+		}
+		if v, ok := cfe.Item["value"].(*types.AttributeValueMemberS); !ok || v.Value != "first" {
+			t.Errorf("Could not match stored item. actual: %v", cfe.Item)
+		}
+
+		var ccf *types.ConditionalCheckFailedException
+		if !errors.As(err, &ccf) {
+			t.Errorf("Bug. Cause is wrapped. But not found: %v", err)
+		}
+	})
+	t.Run("Delete with condition", func(t *testing.T) {
+		d := newDynamoDB(t)
+
+		err := dynamodb.PutItem(context.Background(), d, batchItem{Key: "k", Value: "v"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = dynamodb.DeleteItem(context.Background(), d, d.Key("k"), dynamodb.WriteOptionCondition(expression.Name("value").Equal(expression.Value("other"))))
+		if !errors.Is(err, dynamodb.ErrConditionFailed) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrConditionFailed, err)
+		}
+
+		err = dynamodb.DeleteItem(context.Background(), d, d.Key("k"), dynamodb.WriteOptionCondition(expression.Name("value").Equal(expression.Value("v"))))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = d.Get("k")
+		if !errors.Is(err, dynamodb.ErrNotFound) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrNotFound, err)
+		}
+	})
+	t.Run("Reused option", func(t *testing.T) {
+		d := newDynamoDB(t)
+		exists := dynamodb.WriteOptionCondition(expression.AttributeExists(expression.Name("key")))
+
+		err := dynamodb.PutItem(context.Background(), d, batchItem{Key: "k", Value: "first"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = dynamodb.PutItem(context.Background(), d, batchItem{Key: "k", Value: "second"},
+			dynamodb.WriteOptionCondition(expression.Name("value").Equal(expression.Value("first"))), exists)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the condition of the previous write is not carried over
+		err = dynamodb.PutItem(context.Background(), d, batchItem{Key: "k", Value: "third"}, exists)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual, err := d.Get("k")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != "third" {
+			t.Errorf("Could not match value.\nexpect: %s\nactual: %s", "third", actual)
+		}
+	})
+	t.Run("Optimistic locking", func(t *testing.T) {
+		d := newDynamoDB(t)
+		version := dynamodb.WriteOptionVersion("version")
+
+		item := &versionedItem{Key: "k", Value: "v1"}
+		err := dynamodb.PutItem(context.Background(), d, item, version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if item.Version != 1 {
+			t.Errorf("Could not match version.\nexpect: %d\nactual: %d", 1, item.Version)
+		}
+
+		// another writer read version 1
+		stale := *item
+
+		item.Value = "v2"
+		err = dynamodb.PutItem(context.Background(), d, item, version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if item.Version != 2 {
+			t.Errorf("Could not match version.\nexpect: %d\nactual: %d", 2, item.Version)
+		}
+
+		stale.Value = "stale"
+		err = dynamodb.PutItem(context.Background(), d, &stale, version)
+		if !errors.Is(err, dynamodb.ErrConditionFailed) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrConditionFailed, err)
+		}
+
+		// new item with the same key
+		err = dynamodb.PutItem(context.Background(), d, versionedItem{Key: "k", Value: "new"}, version)
+		if !errors.Is(err, dynamodb.ErrConditionFailed) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrConditionFailed, err)
+		}
+
+		actual, err := dynamodb.GetItem[versionedItem](context.Background(), d, d.Key("k"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *actual != *item {
+			t.Errorf("Could not match item.\nexpect: %+v\nactual: %+v", *item, *actual)
+		}
+
+		err = dynamodb.DeleteItem(context.Background(), d, dynamodb.Key{"key": "k", "version": 1}, version)
+		if !errors.Is(err, dynamodb.ErrConditionFailed) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrConditionFailed, err)
+		}
+
+		err = dynamodb.DeleteItem(context.Background(), d, dynamodb.Key{"key": "k", "version": 2}, version)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+}
@@ -1,120 +1,272 @@
 package dynamodb
 
 import (
+	"context"
 	"errors"
+	"strconv"
+	"sync"
+	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams"
 )
 
 var (
 	ErrNotFound = errors.New("Not found")
 )
 
+// Client is DynamoDB operations used by DynamoDB. It is satisfied by *dynamodb.Client and by *memory.Client of the in-memory implementation
+type Client interface {
+	CreateTable(ctx context.Context, params *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error)
+	DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error)
+	UpdateTable(ctx context.Context, params *dynamodb.UpdateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateTableOutput, error)
+	DeleteTable(ctx context.Context, params *dynamodb.DeleteTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteTableOutput, error)
+	ListTables(ctx context.Context, params *dynamodb.ListTablesInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ListTablesOutput, error)
+	DescribeTimeToLive(ctx context.Context, params *dynamodb.DescribeTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTimeToLiveOutput, error)
+	UpdateTimeToLive(ctx context.Context, params *dynamodb.UpdateTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateTimeToLiveOutput, error)
+
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+	DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+	BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error)
+	BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error)
+	Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+	Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+	TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error)
+	TransactGetItems(ctx context.Context, params *dynamodb.TransactGetItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactGetItemsOutput, error)
+}
+
+// StreamsClient is DynamoDB Streams operations used by ConsumeStream. It is satisfied by *dynamodbstreams.Client and by *memory.Client
+type StreamsClient interface {
+	DescribeStream(ctx context.Context, params *dynamodbstreams.DescribeStreamInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.DescribeStreamOutput, error)
+	GetShardIterator(ctx context.Context, params *dynamodbstreams.GetShardIteratorInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetShardIteratorOutput, error)
+	GetRecords(ctx context.Context, params *dynamodbstreams.GetRecordsInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetRecordsOutput, error)
+}
+
 type DynamoDB struct {
-	DynamoDB         *dynamodb.DynamoDB
+	DynamoDB Client
+	// Streams is client of DynamoDB Streams used by ConsumeStream. It is nil for DynamoDB created by NewFromClient
+	Streams          StreamsClient
 	DefaultTableName string
 	DefaultKeyName   string
-	DefaultValueName string
+	// DefaultSortKeyName is sort key of the default table. empty means the table has no sort key
+	DefaultSortKeyName string
+	DefaultValueName   string
+	// DefaultTTLName is attribute of expiration time in epoch seconds written by SetWithTTL.
+	// TTL of the default table is enabled on it by CreateDefaultTable. It is empty by default, which disables TTL
+	DefaultTTLName string
+
+	// keySchemas caches key attribute names read by DescribeTable by table name
+	keySchemas sync.Map
 }
 
-func New(cfgs ...*aws.Config) (*DynamoDB, error) {
-	sess, err := session.NewSession(cfgs...)
+// New returns DynamoDB instance with configuration loaded by config.LoadDefaultConfig
+func New(optFns ...func(*config.LoadOptions) error) (*DynamoDB, error) {
+	cfg, err := config.LoadDefaultConfig(context.Background(), optFns...)
 	if err != nil {
 		return nil, err
 	}
+
+	return NewFromConfig(cfg), nil
+}
+
+// NewFromConfig returns DynamoDB instance with configuration already loaded
+func NewFromConfig(cfg aws.Config, optFns ...func(*dynamodb.Options)) *DynamoDB {
+	d := NewFromClient(dynamodb.NewFromConfig(cfg, optFns...))
+	d.Streams = dynamodbstreams.NewFromConfig(cfg)
+
+	return d
+}
+
+// NewFromClient returns DynamoDB instance sending requests to client. e.g. the in-memory implementation of package memory
+func NewFromClient(client Client) *DynamoDB {
 	d := &DynamoDB{
-		DynamoDB:         dynamodb.New(sess),
+		DynamoDB:         client,
 		DefaultTableName: "default_table",
 		DefaultKeyName:   "key",
 		DefaultValueName: "value",
+		DefaultTTLName:   "",
 	}
 
-	return d, nil
+	return d
+}
+
+// WithEndpoint returns load option resolving every request to endpoint url
+func WithEndpoint(url string) func(*config.LoadOptions) error {
+	return config.WithBaseEndpoint(url)
+}
+
+// WithLocalEndpoint returns load option for dynamodb-local running on url.
+// Dummy credentials and region are set when they are not configured
+func WithLocalEndpoint(url string) func(*config.LoadOptions) error {
+	return func(o *config.LoadOptions) error {
+		o.BaseEndpoint = url
+		if o.Credentials == nil {
+			o.Credentials = credentials.NewStaticCredentialsProvider("dummy", "dummy", "dummy")
+		}
+		if o.Region == "" {
+			o.Region = "ap-northeast-1"
+		}
+		return nil
+	}
 }
 
 func (d *DynamoDB) CreateDefaultTable() error {
-	return d.CreateTable(d.DefaultTableName, d.DefaultKeyName)
+	return d.CreateDefaultTableWithContext(context.Background())
 }
 
-func (d *DynamoDB) CreateTable(tableName, keyName string) error {
-	_, err := d.DynamoDB.CreateTable(&dynamodb.CreateTableInput{
-		AttributeDefinitions: []*dynamodb.AttributeDefinition{
-			{
-				AttributeName: aws.String(keyName),
-				AttributeType: aws.String("S"),
-			},
-		},
-		KeySchema: []*dynamodb.KeySchemaElement{
-			{
-				AttributeName: aws.String(keyName),
-				KeyType:       aws.String("HASH"),
-			},
-		},
-		ProvisionedThroughput: &dynamodb.ProvisionedThroughput{
-			ReadCapacityUnits:  aws.Int64(5),
-			WriteCapacityUnits: aws.Int64(5),
-		},
-		TableName: aws.String(tableName),
-	})
-	if err != nil {
-		return err
+// CreateDefaultTableWithContext is CreateDefaultTable with context and request options.
+// The table has string sort key when DefaultSortKeyName is set, and TTL is enabled when DefaultTTLName is set
+func (d *DynamoDB) CreateDefaultTableWithContext(ctx context.Context, optFns ...func(*dynamodb.Options)) error {
+	def := &TableDefinition{
+		Name:         d.DefaultTableName,
+		PartitionKey: KeyAttribute{Name: d.DefaultKeyName, Type: AttributeTypeString},
+		TTLAttribute: d.DefaultTTLName,
+	}
+	if d.DefaultSortKeyName != "" {
+		def.SortKey = &KeyAttribute{Name: d.DefaultSortKeyName, Type: AttributeTypeString}
 	}
 
-	return nil
+	return d.CreateTableFromDefinitionWithContext(ctx, def, optFns...)
+}
+
+func (d *DynamoDB) CreateTable(tableName, keyName string) error {
+	return d.CreateTableWithContext(context.Background(), tableName, keyName)
+}
+
+// CreateTableWithContext is CreateTable with context and request options
+func (d *DynamoDB) CreateTableWithContext(ctx context.Context, tableName, keyName string, optFns ...func(*dynamodb.Options)) error {
+	return d.CreateTableFromDefinitionWithContext(ctx, &TableDefinition{
+		Name:         tableName,
+		PartitionKey: KeyAttribute{Name: keyName, Type: AttributeTypeString},
+	}, optFns...)
 }
 
 func (d *DynamoDB) TableNames() ([]string, error) {
-	res, err := d.DynamoDB.ListTables(&dynamodb.ListTablesInput{})
-	if err != nil {
-		return nil, err
-	}
+	return d.TableNamesWithContext(context.Background())
+}
 
-	ret := make([]string, 0, len(res.TableNames))
+// TableNamesWithContext is TableNames with context and request options. all pages are read
+func (d *DynamoDB) TableNamesWithContext(ctx context.Context, optFns ...func(*dynamodb.Options)) ([]string, error) {
+	ret := make([]string, 0)
 
-	for _, tableName := range res.TableNames {
-		ret = append(ret, *tableName)
+	p := dynamodb.NewListTablesPaginator(d.DynamoDB, &dynamodb.ListTablesInput{})
+	for p.HasMorePages() {
+		res, err := p.NextPage(ctx, optFns...)
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, res.TableNames...)
 	}
 
 	return ret, nil
 }
 
 func (d *DynamoDB) Get(key string) (string, error) {
-	res, err := d.DynamoDB.GetItem(&dynamodb.GetItemInput{
-		Key: map[string]*dynamodb.AttributeValue{
-			d.DefaultKeyName: {
-				S: aws.String(key),
-			},
-		},
+	return d.GetWithContext(context.Background(), key)
+}
+
+// GetWithContext is Get with context and request options
+func (d *DynamoDB) GetWithContext(ctx context.Context, key string, optFns ...func(*dynamodb.Options)) (string, error) {
+	return d.GetByKeyWithContext(ctx, Key{d.DefaultKeyName: key}, optFns...)
+}
+
+// GetByKey returns the value of item with composite key
+func (d *DynamoDB) GetByKey(key Key) (string, error) {
+	return d.GetByKeyWithContext(context.Background(), key)
+}
+
+// GetByKeyWithContext is GetByKey with context and request options
+func (d *DynamoDB) GetByKeyWithContext(ctx context.Context, key Key, optFns ...func(*dynamodb.Options)) (string, error) {
+	av, err := key.attributeValues()
+	if err != nil {
+		return "", err
+	}
+
+	res, err := d.DynamoDB.GetItem(ctx, &dynamodb.GetItemInput{
+		Key:       av,
 		TableName: aws.String(d.DefaultTableName),
-	})
+	}, optFns...)
 	if err != nil {
 		return "", err
 	}
 
-	if item, ok := res.Item[d.DefaultValueName]; !ok {
+	if d.expired(res.Item) {
+		return "", ErrNotFound
+	}
+
+	if item, ok := res.Item[d.DefaultValueName].(*types.AttributeValueMemberS); !ok {
 		return "", ErrNotFound
 	} else {
-		return *item.S, nil
+		return item.Value, nil
 	}
 }
 
 func (d *DynamoDB) Set(key, value string) error {
-	_, err := d.DynamoDB.PutItem(&dynamodb.PutItemInput{
-		Item: map[string]*dynamodb.AttributeValue{
-			d.DefaultKeyName: {
-				S: aws.String(key),
-			},
-			d.DefaultValueName: {
-				S: aws.String(value),
-			},
-		},
+	return d.SetWithContext(context.Background(), key, value)
+}
+
+// SetWithContext is Set with context and request options
+func (d *DynamoDB) SetWithContext(ctx context.Context, key, value string, optFns ...func(*dynamodb.Options)) error {
+	return d.SetByKeyWithContext(ctx, Key{d.DefaultKeyName: key}, value, optFns...)
+}
+
+// SetByKey sets the value of item with composite key
+func (d *DynamoDB) SetByKey(key Key, value string) error {
+	return d.SetByKeyWithContext(context.Background(), key, value)
+}
+
+// SetByKeyWithContext is SetByKey with context and request options
+func (d *DynamoDB) SetByKeyWithContext(ctx context.Context, key Key, value string, optFns ...func(*dynamodb.Options)) error {
+	return d.setByKey(ctx, key, value, nil, optFns...)
+}
+
+// setByKey puts the value. The expiration attribute of DefaultTTLName is written only when expiresAt is not nil,
+// and callers with expiresAt check that DefaultTTLName is not empty
+func (d *DynamoDB) setByKey(ctx context.Context, key Key, value string, expiresAt *time.Time, optFns ...func(*dynamodb.Options)) error {
+	item, err := key.attributeValues()
+	if err != nil {
+		return err
+	}
+	item[d.DefaultValueName] = &types.AttributeValueMemberS{Value: value}
+	if expiresAt != nil {
+		item[d.DefaultTTLName] = &types.AttributeValueMemberN{Value: strconv.FormatInt(expiresAt.Unix(), 10)}
+	}
+
+	_, err = d.DynamoDB.PutItem(ctx, &dynamodb.PutItemInput{
+		Item:      item,
 		TableName: aws.String(d.DefaultTableName),
-	})
+	}, optFns...)
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// Delete deletes the item with key. It succeeds even when the item does not exist
+func (d *DynamoDB) Delete(key string) error {
+	return d.DeleteWithContext(context.Background(), key)
+}
+
+// DeleteWithContext is Delete with context and request options
+func (d *DynamoDB) DeleteWithContext(ctx context.Context, key string, optFns ...func(*dynamodb.Options)) error {
+	return d.DeleteByKeyWithContext(ctx, Key{d.DefaultKeyName: key}, optFns...)
+}
+
+// DeleteByKey deletes the item with composite key
+func (d *DynamoDB) DeleteByKey(key Key) error {
+	return d.DeleteByKeyWithContext(context.Background(), key)
+}
+
+// DeleteByKeyWithContext is DeleteByKey with context and request options.
+// Use DeleteItem for conditional delete
+func (d *DynamoDB) DeleteByKeyWithContext(ctx context.Context, key Key, optFns ...func(*dynamodb.Options)) error {
+	return DeleteItem(ctx, d, key, WriteOptionClientOptions(optFns...))
+}
@@ -0,0 +1,73 @@
+package dynamodb_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/rssh-jp/data-access-library/aws/dynamodb"
+)
+
+func TestDynamoDB(t *testing.T) {
+	c := newTestClient(t)
+	d := c.newDynamoDB()
+
+	err := d.CreateDefaultTable()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("TableNames", func(t *testing.T) {
+		actual, err := d.TableNames()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expect := []string{d.DefaultTableName}
+		if !reflect.DeepEqual(expect, actual) {
+			t.Errorf("Could not match table names.\nexpect: %v\nactual: %v", expect, actual)
+		}
+	})
+	t.Run("Set and Get", func(t *testing.T) {
+		err := d.Set("test_key", "test_value")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual, err := d.Get("test_key")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if actual != "test_value" {
+			t.Errorf("Could not match value.\nexpect: %s\nactual: %s", "test_value", actual)
+		}
+	})
+	t.Run("Not found", func(t *testing.T) {
+		_, err := d.Get("unknown")
+		if !errors.Is(err, dynamodb.ErrNotFound) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrNotFound, err)
+		}
+	})
+	t.Run("Delete", func(t *testing.T) {
+		err := d.Set("delete_key", "value")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = d.Delete("delete_key")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = d.Get("delete_key")
+		if !errors.Is(err, dynamodb.ErrNotFound) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrNotFound, err)
+		}
+
+		err = d.Delete("delete_key")
+		if err != nil {
+			t.Errorf("Bug. Delete of absent item must succeed: %v", err)
+		}
+	})
+}
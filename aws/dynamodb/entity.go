@@ -0,0 +1,645 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+var (
+	// ErrUnknownEntity is returned when the type attribute of item is not registered
+	ErrUnknownEntity = errors.New("unknown entity type")
+)
+
+// EntityKeys is key templates of entity. "{name}" in template is replaced with attribute name of the entity. e.g. "USER#{id}"
+type EntityKeys struct {
+	PartitionKey string
+	SortKey      string
+}
+
+// EntityDefinition declares how Go type is stored in the single table
+type EntityDefinition struct {
+	// Type is the value of the type attribute. The name of Go type by default
+	Type string
+	// Keys is templates of the primary key
+	Keys EntityKeys
+	// Indexes is templates of keys of overloaded global secondary indexes by index name.
+	// The index keys are not written when attribute of the template is missing, so the index is sparse
+	Indexes map[string]EntityKeys
+}
+
+type registryIndex struct {
+	partitionKeyName string
+	sortKeyName      string
+}
+
+type registryConfig struct {
+	partitionKeyName string
+	sortKeyName      string
+	typeName         string
+	indexes          map[string]registryIndex
+}
+
+func newRegistryConfig() *registryConfig {
+	return &registryConfig{
+		partitionKeyName: "PK",
+		sortKeyName:      "SK",
+		typeName:         "_type",
+		indexes:          make(map[string]registryIndex),
+	}
+}
+
+func createRegistryConfig(registryOpts ...RegistryOption) (*registryConfig, error) {
+	rc := newRegistryConfig()
+
+	for _, opt := range registryOpts {
+		err := opt(rc)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return rc, nil
+}
+
+// RegistryOption is functional option pattern option for Registry
+type RegistryOption func(*registryConfig) error
+
+// RegistryOptionKeyNames returns RegistryOption instance with key attribute names of the table. "PK" and "SK" by default.
+// sortKeyName is empty when the table has no sort key
+func RegistryOptionKeyNames(partitionKeyName, sortKeyName string) func(c *registryConfig) error {
+	return func(c *registryConfig) error {
+		if partitionKeyName == "" {
+			return errors.New("partition key name is empty")
+		}
+		c.partitionKeyName = partitionKeyName
+		c.sortKeyName = sortKeyName
+		return nil
+	}
+}
+
+// RegistryOptionTypeName returns RegistryOption instance with attribute name of the entity type. "_type" by default
+func RegistryOptionTypeName(name string) func(c *registryConfig) error {
+	return func(c *registryConfig) error {
+		if name == "" {
+			return errors.New("type attribute name is empty")
+		}
+		c.typeName = name
+		return nil
+	}
+}
+
+// RegistryOptionIndex returns RegistryOption instance with overloaded global secondary index shared by entities.
+// sortKeyName is empty when the index has no sort key
+func RegistryOptionIndex(indexName, partitionKeyName, sortKeyName string) func(c *registryConfig) error {
+	return func(c *registryConfig) error {
+		if indexName == "" {
+			return errors.New("index name is empty")
+		}
+		if partitionKeyName == "" {
+			return errors.New("partition key name is empty")
+		}
+		c.indexes[indexName] = registryIndex{partitionKeyName: partitionKeyName, sortKeyName: sortKeyName}
+		return nil
+	}
+}
+
+// Registry maps Go types to items of single table design.
+// Key attributes are built from key templates of EntityDefinition and the type attribute discriminates entities
+type Registry struct {
+	d         *DynamoDB
+	tableName string
+	rc        *registryConfig
+
+	mu       sync.RWMutex
+	byGoType map[reflect.Type]*entity
+	byType   map[string]*entity
+}
+
+type entity struct {
+	goType  reflect.Type
+	name    string
+	keys    *entityKeyTemplates
+	indexes map[string]*entityKeyTemplates
+}
+
+type entityKeyTemplates struct {
+	partitionKey *keyTemplate
+	// sortKey is nil when the table or index has no sort key
+	sortKey *keyTemplate
+}
+
+// NewRegistry returns Registry instance of tableName
+func (d *DynamoDB) NewRegistry(tableName string, registryOpts ...RegistryOption) (*Registry, error) {
+	if tableName == "" {
+		return nil, errors.New("table name is empty")
+	}
+
+	rc, err := createRegistryConfig(registryOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Registry{
+		d:         d,
+		tableName: tableName,
+		rc:        rc,
+		byGoType:  make(map[reflect.Type]*entity),
+		byType:    make(map[string]*entity),
+	}, nil
+}
+
+// Register registers struct type T as entity of def
+func Register[T any](r *Registry, def *EntityDefinition) error {
+	if def == nil {
+		return errors.New("entity definition is nil")
+	}
+
+	t := reflect.TypeFor[T]()
+	if t.Kind() != reflect.Struct {
+		return fmt.Errorf("entity must be struct: %s", t)
+	}
+
+	e := &entity{
+		goType:  t,
+		name:    def.Type,
+		indexes: make(map[string]*entityKeyTemplates, len(def.Indexes)),
+	}
+	if e.name == "" {
+		e.name = t.Name()
+	}
+
+	var err error
+	e.keys, err = newEntityKeyTemplates(def.Keys, r.rc.sortKeyName)
+	if err != nil {
+		return fmt.Errorf("keys of entity %s: %w", e.name, err)
+	}
+	for indexName, keys := range def.Indexes {
+		index, ok := r.rc.indexes[indexName]
+		if !ok {
+			return fmt.Errorf("index %s of entity %s is not registered by RegistryOptionIndex", indexName, e.name)
+		}
+		e.indexes[indexName], err = newEntityKeyTemplates(keys, index.sortKeyName)
+		if err != nil {
+			return fmt.Errorf("index %s of entity %s: %w", indexName, e.name, err)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.byGoType[t]; ok {
+		return fmt.Errorf("type %s is already registered", t)
+	}
+	if _, ok := r.byType[e.name]; ok {
+		return fmt.Errorf("entity %s is already registered", e.name)
+	}
+	r.byGoType[t] = e
+	r.byType[e.name] = e
+
+	return nil
+}
+
+func newEntityKeyTemplates(keys EntityKeys, sortKeyName string) (*entityKeyTemplates, error) {
+	if keys.PartitionKey == "" {
+		return nil, errors.New("partition key template is empty")
+	}
+	if (keys.SortKey == "") != (sortKeyName == "") {
+		return nil, errors.New("sort key template must be set only when sort key exists")
+	}
+
+	ret := &entityKeyTemplates{}
+
+	var err error
+	ret.partitionKey, err = parseKeyTemplate(keys.PartitionKey)
+	if err != nil {
+		return nil, err
+	}
+	if keys.SortKey != "" {
+		ret.sortKey, err = parseKeyTemplate(keys.SortKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ret, nil
+}
+
+// Key returns the primary key of entity v. Only the attributes used by the key templates need to be set
+func (r *Registry) Key(v any) (Key, error) {
+	e, err := r.entity(reflect.TypeOf(v))
+	if err != nil {
+		return nil, err
+	}
+
+	av, err := attributevalue.MarshalMap(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.key(e, av)
+}
+
+// PutEntity puts v with the key attributes, the overloaded index keys and the type attribute on the table of the registry.
+// With WriteOptionVersion v is updated to the new version
+func PutEntity[T any](ctx context.Context, r *Registry, v *T, writeOpts ...WriteOption) error {
+	e, err := r.entity(reflect.TypeFor[T]())
+	if err != nil {
+		return err
+	}
+
+	av, err := attributevalue.MarshalMap(v)
+	if err != nil {
+		return err
+	}
+	key, err := r.key(e, av)
+	if err != nil {
+		return err
+	}
+	keyAV, err := key.attributeValues()
+	if err != nil {
+		return err
+	}
+	maps.Copy(av, keyAV)
+
+	for indexName, templates := range e.indexes {
+		index := r.rc.indexes[indexName]
+		indexAV, err := indexKey(templates, index, av)
+		if errors.Is(err, errMissingAttribute) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("index %s: %w", indexName, err)
+		}
+		maps.Copy(av, indexAV)
+	}
+	av[r.rc.typeName] = &types.AttributeValueMemberS{Value: e.name}
+
+	wc, err := createWriteConfig(append([]WriteOption{WriteOptionTable(r.tableName)}, writeOpts...)...)
+	if err != nil {
+		return err
+	}
+
+	in, av, err := r.d.putInput(wc, av)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.d.DynamoDB.PutItem(ctx, in, wc.clientOpts...)
+	if err != nil {
+		return conditionError(err)
+	}
+
+	if wc.versionName != "" {
+		return attributevalue.UnmarshalMap(av, v)
+	}
+
+	return nil
+}
+
+// GetEntity gets the entity with the key of key. ErrNotFound is returned when the item is absent or is another entity
+func GetEntity[T any](ctx context.Context, r *Registry, key *T, optFns ...func(*dynamodb.Options)) (*T, error) {
+	e, err := r.entity(reflect.TypeFor[T]())
+	if err != nil {
+		return nil, err
+	}
+
+	av, err := attributevalue.MarshalMap(key)
+	if err != nil {
+		return nil, err
+	}
+	k, err := r.key(e, av)
+	if err != nil {
+		return nil, err
+	}
+	keyAV, err := k.attributeValues()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := r.d.DynamoDB.GetItem(ctx, &dynamodb.GetItemInput{
+		Key:       keyAV,
+		TableName: aws.String(r.tableName),
+	}, optFns...)
+	if err != nil {
+		return nil, err
+	}
+
+	if typeAV, ok := res.Item[r.rc.typeName].(*types.AttributeValueMemberS); !ok || typeAV.Value != e.name {
+		return nil, ErrNotFound
+	}
+
+	ret := new(T)
+	err = attributevalue.UnmarshalMap(res.Item, ret)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// DeleteEntity deletes the entity with the key of key.
+// With WriteOptionVersion key must have the version attribute
+func DeleteEntity[T any](ctx context.Context, r *Registry, key *T, writeOpts ...WriteOption) error {
+	e, err := r.entity(reflect.TypeFor[T]())
+	if err != nil {
+		return err
+	}
+
+	av, err := attributevalue.MarshalMap(key)
+	if err != nil {
+		return err
+	}
+	k, err := r.key(e, av)
+	if err != nil {
+		return err
+	}
+
+	wc, err := createWriteConfig(append([]WriteOption{WriteOptionTable(r.tableName)}, writeOpts...)...)
+	if err != nil {
+		return err
+	}
+	if version, ok := av[wc.versionName]; ok && wc.versionName != "" {
+		k[wc.versionName] = version
+	}
+
+	in, err := r.d.deleteInput(wc, k)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.d.DynamoDB.DeleteItem(ctx, in, wc.clientOpts...)
+	if err != nil {
+		return conditionError(err)
+	}
+
+	return nil
+}
+
+// Query reads a page of entities with partitionKey and decodes them into pointers of registered types. e.g. *User and *Order.
+// With QueryOptionIndex partitionKey is the key of the overloaded index.
+// ErrUnknownEntity is returned when the type attribute of item is not registered.
+// The token of the next page is returned with entities. It is empty on the last page
+func (r *Registry) Query(ctx context.Context, partitionKey string, queryOpts ...QueryOption) ([]any, string, error) {
+	qc, err := createQueryConfig(append([]QueryOption{QueryOptionTable(r.tableName)}, queryOpts...)...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	partitionKeyName, _, err := r.keyNames(qc.indexName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	page, err := r.query(ctx, qc, expression.Key(partitionKeyName).Equal(expression.Value(partitionKey)))
+	if err != nil {
+		return nil, "", err
+	}
+
+	ret := make([]any, 0, len(page.Items))
+	for _, item := range page.Items {
+		v, err := r.decode(item)
+		if err != nil {
+			return nil, "", err
+		}
+		ret = append(ret, v)
+	}
+
+	return ret, page.NextToken, nil
+}
+
+// QueryEntity reads a page of entities of T with partitionKey.
+// Sort key is narrowed down to the fixed prefix of the sort key template of T and items of other entities are filtered out.
+// With QueryOptionIndex partitionKey is the key of the overloaded index.
+// The token of the next page is returned with entities. It is empty on the last page
+func QueryEntity[T any](ctx context.Context, r *Registry, partitionKey string, queryOpts ...QueryOption) ([]T, string, error) {
+	e, err := r.entity(reflect.TypeFor[T]())
+	if err != nil {
+		return nil, "", err
+	}
+
+	qc, err := createQueryConfig(append([]QueryOption{QueryOptionTable(r.tableName)}, queryOpts...)...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	partitionKeyName, sortKeyName, err := r.keyNames(qc.indexName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	templates := e.keys
+	if qc.indexName != "" {
+		var ok bool
+		templates, ok = e.indexes[qc.indexName]
+		if !ok {
+			return nil, "", fmt.Errorf("entity %s is not in index %s", e.name, qc.indexName)
+		}
+	}
+
+	keyCond := expression.Key(partitionKeyName).Equal(expression.Value(partitionKey))
+	if templates.sortKey != nil {
+		if prefix := templates.sortKey.prefix(); prefix != "" {
+			keyCond = keyCond.And(expression.Key(sortKeyName).BeginsWith(prefix))
+		}
+	}
+
+	filter := expression.Name(r.rc.typeName).Equal(expression.Value(e.name))
+	if qc.filter != nil {
+		filter = filter.And(*qc.filter)
+	}
+	qc.filter = &filter
+
+	page, err := r.query(ctx, qc, keyCond)
+	if err != nil {
+		return nil, "", err
+	}
+
+	ret := make([]T, 0, len(page.Items))
+	err = page.Unmarshal(&ret)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return ret, page.NextToken, nil
+}
+
+func (r *Registry) query(ctx context.Context, qc *queryConfig, keyCond expression.KeyConditionBuilder) (*Page, error) {
+	in, err := r.d.queryInput(qc, keyCond)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.d.queryPage(ctx, qc, in)
+}
+
+// keyNames returns key attribute names of the table, or of the overloaded index when indexName is not empty
+func (r *Registry) keyNames(indexName string) (string, string, error) {
+	if indexName == "" {
+		return r.rc.partitionKeyName, r.rc.sortKeyName, nil
+	}
+
+	index, ok := r.rc.indexes[indexName]
+	if !ok {
+		return "", "", fmt.Errorf("index %s is not registered by RegistryOptionIndex", indexName)
+	}
+
+	return index.partitionKeyName, index.sortKeyName, nil
+}
+
+// entity returns the registered entity of t. Pointer type is dereferenced
+func (r *Registry) entity(t reflect.Type) (*entity, error) {
+	if t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	e, ok := r.byGoType[t]
+	if !ok {
+		return nil, fmt.Errorf("%w: %v", ErrUnknownEntity, t)
+	}
+
+	return e, nil
+}
+
+// decode unmarshals item into pointer of the registered type of the type attribute
+func (r *Registry) decode(item map[string]types.AttributeValue) (any, error) {
+	typeAV, _ := item[r.rc.typeName].(*types.AttributeValueMemberS)
+	if typeAV == nil {
+		return nil, fmt.Errorf("%w: item without %s", ErrUnknownEntity, r.rc.typeName)
+	}
+
+	r.mu.RLock()
+	e, ok := r.byType[typeAV.Value]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEntity, typeAV.Value)
+	}
+
+	v := reflect.New(e.goType).Interface()
+	err := attributevalue.UnmarshalMap(item, v)
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// key returns the primary key of marshaled entity
+func (r *Registry) key(e *entity, av map[string]types.AttributeValue) (Key, error) {
+	pk, err := e.keys.partitionKey.format(av)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := Key{r.rc.partitionKeyName: pk}
+	if e.keys.sortKey != nil {
+		sk, err := e.keys.sortKey.format(av)
+		if err != nil {
+			return nil, err
+		}
+		ret[r.rc.sortKeyName] = sk
+	}
+
+	return ret, nil
+}
+
+// indexKey returns key attributes of the overloaded index of marshaled entity
+func indexKey(templates *entityKeyTemplates, index registryIndex, av map[string]types.AttributeValue) (map[string]types.AttributeValue, error) {
+	pk, err := templates.partitionKey.format(av)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := map[string]types.AttributeValue{index.partitionKeyName: &types.AttributeValueMemberS{Value: pk}}
+	if templates.sortKey != nil {
+		sk, err := templates.sortKey.format(av)
+		if err != nil {
+			return nil, err
+		}
+		ret[index.sortKeyName] = &types.AttributeValueMemberS{Value: sk}
+	}
+
+	return ret, nil
+}
+
+// errMissingAttribute is returned by keyTemplate.format when the attribute of the template is missing
+var errMissingAttribute = errors.New("attribute of key template is missing")
+
+// keyTemplate is parsed key template. Attribute names are at odd indexes of parts
+type keyTemplate struct {
+	raw   string
+	parts []string
+}
+
+func parseKeyTemplate(s string) (*keyTemplate, error) {
+	t := &keyTemplate{raw: s}
+
+	rest := s
+	for {
+		start := strings.IndexAny(rest, "{}")
+		if start < 0 {
+			t.parts = append(t.parts, rest)
+			return t, nil
+		}
+		if rest[start] == '}' {
+			return nil, fmt.Errorf("unexpected } in key template %s", s)
+		}
+
+		end := strings.IndexAny(rest[start+1:], "{}")
+		if end < 0 || rest[start+1+end] == '{' {
+			return nil, fmt.Errorf("unclosed { in key template %s", s)
+		}
+		name := rest[start+1 : start+1+end]
+		if name == "" {
+			return nil, fmt.Errorf("empty attribute name in key template %s", s)
+		}
+
+		t.parts = append(t.parts, rest[:start], name)
+		rest = rest[start+1+end+1:]
+	}
+}
+
+// format replaces attribute names of the template with string or number attributes of av. Empty string is regarded as missing
+func (t *keyTemplate) format(av map[string]types.AttributeValue) (string, error) {
+	var b strings.Builder
+	for i, part := range t.parts {
+		if i%2 == 0 {
+			b.WriteString(part)
+			continue
+		}
+
+		switch v := av[part].(type) {
+		case *types.AttributeValueMemberS:
+			if v.Value == "" {
+				return "", fmt.Errorf("%w: %s of %s", errMissingAttribute, part, t.raw)
+			}
+			b.WriteString(v.Value)
+		case *types.AttributeValueMemberN:
+			b.WriteString(v.Value)
+		case nil, *types.AttributeValueMemberNULL:
+			return "", fmt.Errorf("%w: %s of %s", errMissingAttribute, part, t.raw)
+		default:
+			return "", fmt.Errorf("attribute %s of key template %s must be string or number", part, t.raw)
+		}
+	}
+
+	return b.String(), nil
+}
+
+// prefix returns the fixed text before the first attribute of the template
+func (t *keyTemplate) prefix() string {
+	return t.parts[0]
+}
@@ -0,0 +1,239 @@
+package dynamodb_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/rssh-jp/data-access-library/aws/dynamodb"
+)
+
+type account struct {
+	ID   string `dynamodbav:"id"`
+	Name string `dynamodbav:"name"`
+}
+
+type purchase struct {
+	AccountID string `dynamodbav:"account_id"`
+	ID        int    `dynamodbav:"id"`
+	Date      string `dynamodbav:"date"`
+	Status    string `dynamodbav:"status,omitempty"`
+}
+
+func TestRegistry(t *testing.T) {
+	newRegistry := func(t *testing.T) (*dynamodb.DynamoDB, *dynamodb.Registry) {
+		t.Helper()
+
+		c := newTestClient(t)
+		d := c.newDynamoDB()
+
+		err := d.CreateTableFromDefinition(&dynamodb.TableDefinition{
+			Name:         "app",
+			PartitionKey: dynamodb.KeyAttribute{Name: "PK"},
+			SortKey:      &dynamodb.KeyAttribute{Name: "SK"},
+			GlobalIndexes: []*dynamodb.IndexDefinition{
+				{Name: "GSI1", PartitionKey: dynamodb.KeyAttribute{Name: "GSI1PK"}, SortKey: &dynamodb.KeyAttribute{Name: "GSI1SK"}},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r, err := d.NewRegistry("app", dynamodb.RegistryOptionIndex("GSI1", "GSI1PK", "GSI1SK"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = dynamodb.Register[account](r, &dynamodb.EntityDefinition{
+			Keys: dynamodb.EntityKeys{PartitionKey: "ACCOUNT#{id}", SortKey: "PROFILE"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = dynamodb.Register[purchase](r, &dynamodb.EntityDefinition{
+			Type: "Purchase",
+			Keys: dynamodb.EntityKeys{PartitionKey: "ACCOUNT#{account_id}", SortKey: "PURCHASE#{date}#{id}"},
+			Indexes: map[string]dynamodb.EntityKeys{
+				"GSI1": {PartitionKey: "STATUS#{status}", SortKey: "{date}"},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return d, r
+	}
+	put := func(t *testing.T, r *dynamodb.Registry) {
+		t.Helper()
+
+		ctx := context.Background()
+		if err := dynamodb.PutEntity(ctx, r, &account{ID: "a1", Name: "alice"}); err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range []*purchase{
+			{AccountID: "a1", ID: 2, Date: "2024-02-01", Status: "open"},
+			{AccountID: "a1", ID: 1, Date: "2024-01-01"},
+			{AccountID: "a2", ID: 3, Date: "2024-03-01", Status: "open"},
+		} {
+			if err := dynamodb.PutEntity(ctx, r, p); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	t.Run("Put and Get", func(t *testing.T) {
+		d, r := newRegistry(t)
+		put(t, r)
+
+		d.DefaultTableName = "app"
+		item, err := dynamodb.GetItem[map[string]any](context.Background(), d, dynamodb.Key{"PK": "ACCOUNT#a1", "SK": "PURCHASE#2024-02-01#2"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect := map[string]any{
+			"PK":         "ACCOUNT#a1",
+			"SK":         "PURCHASE#2024-02-01#2",
+			"GSI1PK":     "STATUS#open",
+			"GSI1SK":     "2024-02-01",
+			"_type":      "Purchase",
+			"account_id": "a1",
+			"id":         float64(2),
+			"date":       "2024-02-01",
+			"status":     "open",
+		}
+		if !reflect.DeepEqual(expect, *item) {
+			t.Errorf("Could not match item.\nexpect: %v\nactual: %v", expect, *item)
+		}
+
+		// index keys of purchase without status are not written
+		item, err = dynamodb.GetItem[map[string]any](context.Background(), d, dynamodb.Key{"PK": "ACCOUNT#a1", "SK": "PURCHASE#2024-01-01#1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := (*item)["GSI1PK"]; ok {
+			t.Errorf("Bug. Index key is written without the attribute: %v", *item)
+		}
+
+		a, err := dynamodb.GetEntity(context.Background(), r, &account{ID: "a1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expect := (account{ID: "a1", Name: "alice"}); *a != expect {
+			t.Errorf("Could not match account.\nexpect: %v\nactual: %v", expect, *a)
+		}
+
+		_, err = dynamodb.GetEntity(context.Background(), r, &account{ID: "a2"})
+		if !errors.Is(err, dynamodb.ErrNotFound) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrNotFound, err)
+		}
+	})
+	t.Run("Query", func(t *testing.T) {
+		_, r := newRegistry(t)
+		put(t, r)
+
+		items, token, err := r.Query(context.Background(), "ACCOUNT#a1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "" {
+			t.Errorf("Bug. Query reads the last page. But token: %s", token)
+		}
+
+		expect := []any{
+			&account{ID: "a1", Name: "alice"},
+			&purchase{AccountID: "a1", ID: 1, Date: "2024-01-01"},
+			&purchase{AccountID: "a1", ID: 2, Date: "2024-02-01", Status: "open"},
+		}
+		if !reflect.DeepEqual(expect, items) {
+			t.Errorf("Could not match entities.\nexpect: %v\nactual: %v", expect, items)
+		}
+	})
+	t.Run("QueryEntity", func(t *testing.T) {
+		_, r := newRegistry(t)
+		put(t, r)
+
+		purchases, _, err := dynamodb.QueryEntity[purchase](context.Background(), r, "ACCOUNT#a1", dynamodb.QueryOptionDescending())
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect := []purchase{
+			{AccountID: "a1", ID: 2, Date: "2024-02-01", Status: "open"},
+			{AccountID: "a1", ID: 1, Date: "2024-01-01"},
+		}
+		if !reflect.DeepEqual(expect, purchases) {
+			t.Errorf("Could not match purchases.\nexpect: %v\nactual: %v", expect, purchases)
+		}
+
+		purchases, _, err = dynamodb.QueryEntity[purchase](context.Background(), r, "STATUS#open", dynamodb.QueryOptionIndex("GSI1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect = []purchase{
+			{AccountID: "a1", ID: 2, Date: "2024-02-01", Status: "open"},
+			{AccountID: "a2", ID: 3, Date: "2024-03-01", Status: "open"},
+		}
+		if !reflect.DeepEqual(expect, purchases) {
+			t.Errorf("Could not match purchases of index.\nexpect: %v\nactual: %v", expect, purchases)
+		}
+
+		_, _, err = dynamodb.QueryEntity[account](context.Background(), r, "STATUS#open", dynamodb.QueryOptionIndex("GSI1"))
+		if err == nil {
+			t.Error("Bug. Account is not in the index. But no error")
+		}
+	})
+	t.Run("Delete", func(t *testing.T) {
+		_, r := newRegistry(t)
+		put(t, r)
+
+		err := dynamodb.DeleteEntity(context.Background(), r, &purchase{AccountID: "a1", ID: 1, Date: "2024-01-01"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = dynamodb.GetEntity(context.Background(), r, &purchase{AccountID: "a1", ID: 1, Date: "2024-01-01"})
+		if !errors.Is(err, dynamodb.ErrNotFound) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrNotFound, err)
+		}
+	})
+	t.Run("Unknown entity", func(t *testing.T) {
+		d, r := newRegistry(t)
+		put(t, r)
+
+		err := dynamodb.PutItem(context.Background(), d, map[string]any{"PK": "ACCOUNT#a1", "SK": "SETTING", "_type": "Setting"}, dynamodb.WriteOptionTable("app"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, _, err = r.Query(context.Background(), "ACCOUNT#a1")
+		if !errors.Is(err, dynamodb.ErrUnknownEntity) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrUnknownEntity, err)
+		}
+
+		type unregistered struct{}
+		err = dynamodb.PutEntity(context.Background(), r, &unregistered{})
+		if !errors.Is(err, dynamodb.ErrUnknownEntity) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrUnknownEntity, err)
+		}
+	})
+	t.Run("Invalid definition", func(t *testing.T) {
+		_, r := newRegistry(t)
+
+		type other struct{}
+		for name, def := range map[string]*dynamodb.EntityDefinition{
+			"Duplicated type":    {Type: "Purchase", Keys: dynamodb.EntityKeys{PartitionKey: "P", SortKey: "S"}},
+			"Unclosed template":  {Keys: dynamodb.EntityKeys{PartitionKey: "OTHER#{id", SortKey: "S"}},
+			"Missing sort key":   {Keys: dynamodb.EntityKeys{PartitionKey: "OTHER#{id}"}},
+			"Unregistered index": {Keys: dynamodb.EntityKeys{PartitionKey: "P", SortKey: "S"}, Indexes: map[string]dynamodb.EntityKeys{"GSI2": {PartitionKey: "P", SortKey: "S"}}},
+		} {
+			err := dynamodb.Register[other](r, def)
+			if err == nil {
+				t.Errorf("Bug. %s. But no error", name)
+			}
+		}
+
+		err := dynamodb.PutEntity(context.Background(), r, &purchase{ID: 1, Date: "2024-01-01"})
+		if err == nil {
+			t.Error("Bug. Attribute of key template is missing. But no error")
+		}
+	})
+}
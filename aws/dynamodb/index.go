@@ -0,0 +1,297 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Projection types of secondary index
+const (
+	ProjectionAll      = types.ProjectionTypeAll
+	ProjectionKeysOnly = types.ProjectionTypeKeysOnly
+	ProjectionInclude  = types.ProjectionTypeInclude
+)
+
+// indexPollInterval is interval of DescribeTable while waiting for index
+const indexPollInterval = time.Second
+
+// IndexDefinition is definition of secondary index
+type IndexDefinition struct {
+	Name         string
+	PartitionKey KeyAttribute
+	// SortKey is nil for global index without sort key. It is required by local index
+	SortKey *KeyAttribute
+
+	// ProjectionType is ProjectionAll when empty
+	ProjectionType types.ProjectionType
+	// NonKeyAttributes is attributes projected with ProjectionInclude
+	NonKeyAttributes []string
+	// Throughput is used by global index of table with BillingModeProvisioned. The throughput of the table when nil
+	Throughput *Throughput
+}
+
+func (index *IndexDefinition) validate(local bool, billingMode types.BillingMode) error {
+	if index.Name == "" {
+		return errors.New("index name is empty")
+	}
+	if !local && index.PartitionKey.Name == "" {
+		return fmt.Errorf("partition key name of index %s is empty", index.Name)
+	}
+	if local && index.SortKey == nil {
+		return fmt.Errorf("local index %s must have sort key", index.Name)
+	}
+	if index.SortKey != nil && index.SortKey.Name == "" {
+		return fmt.Errorf("sort key name of index %s is empty", index.Name)
+	}
+	if len(index.NonKeyAttributes) > 0 && index.ProjectionType != ProjectionInclude {
+		return fmt.Errorf("non key attributes of index %s require ProjectionInclude", index.Name)
+	}
+	if index.Throughput != nil && (local || billingMode == BillingModePayPerRequest) {
+		return fmt.Errorf("throughput of index %s is allowed only for global index with provisioned billing", index.Name)
+	}
+	return nil
+}
+
+func (index *IndexDefinition) projection() *types.Projection {
+	p := &types.Projection{ProjectionType: ProjectionAll}
+	if index.ProjectionType != "" {
+		p.ProjectionType = index.ProjectionType
+	}
+	if len(index.NonKeyAttributes) > 0 {
+		p.NonKeyAttributes = index.NonKeyAttributes
+	}
+	return p
+}
+
+// globalSecondaryIndex returns the global index. tableThroughput is used when the index has no throughput
+func (index *IndexDefinition) globalSecondaryIndex(billingMode types.BillingMode, tableThroughput *Throughput) types.GlobalSecondaryIndex {
+	gsi := types.GlobalSecondaryIndex{
+		IndexName:  aws.String(index.Name),
+		KeySchema:  keySchema(index.PartitionKey, index.SortKey),
+		Projection: index.projection(),
+	}
+
+	if billingMode != BillingModePayPerRequest {
+		throughput := Throughput{Read: 5, Write: 5}
+		if tableThroughput != nil {
+			throughput = *tableThroughput
+		}
+		if index.Throughput != nil {
+			throughput = *index.Throughput
+		}
+		gsi.ProvisionedThroughput = &types.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(throughput.Read),
+			WriteCapacityUnits: aws.Int64(throughput.Write),
+		}
+	}
+
+	return gsi
+}
+
+// IndexStatus is status of global index
+type IndexStatus struct {
+	Status types.IndexStatus
+	// Backfilling is true while existing items are being added to new index
+	Backfilling bool
+	ItemCount   int64
+}
+
+// Active reports whether the index is ACTIVE and backfilling is finished
+func (s *IndexStatus) Active() bool {
+	return s.Status == types.IndexStatusActive && !s.Backfilling
+}
+
+// CreateGlobalIndex adds global index to existing table. Existing items are backfilled in background.
+// Use WaitUntilIndexActive to wait until the index is queryable
+func (d *DynamoDB) CreateGlobalIndex(tableName string, index *IndexDefinition) error {
+	return d.CreateGlobalIndexWithContext(context.Background(), tableName, index)
+}
+
+// CreateGlobalIndexWithContext is CreateGlobalIndex with context and request options
+func (d *DynamoDB) CreateGlobalIndexWithContext(ctx context.Context, tableName string, index *IndexDefinition, optFns ...func(*dynamodb.Options)) error {
+	res, err := d.DynamoDB.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(tableName)}, optFns...)
+	if err != nil {
+		return err
+	}
+
+	billingMode := BillingModeProvisioned
+	if res.Table.BillingModeSummary != nil && res.Table.BillingModeSummary.BillingMode == BillingModePayPerRequest {
+		billingMode = BillingModePayPerRequest
+	}
+
+	err = index.validate(false, billingMode)
+	if err != nil {
+		return err
+	}
+
+	var tableThroughput *Throughput
+	if pt := res.Table.ProvisionedThroughput; pt != nil && aws.ToInt64(pt.ReadCapacityUnits) > 0 {
+		tableThroughput = &Throughput{Read: aws.ToInt64(pt.ReadCapacityUnits), Write: aws.ToInt64(pt.WriteCapacityUnits)}
+	}
+
+	attrs, err := attributeDefinitions(&index.PartitionKey, index.SortKey)
+	if err != nil {
+		return err
+	}
+
+	gsi := index.globalSecondaryIndex(billingMode, tableThroughput)
+	_, err = d.DynamoDB.UpdateTable(ctx, &dynamodb.UpdateTableInput{
+		TableName:            aws.String(tableName),
+		AttributeDefinitions: attrs,
+		GlobalSecondaryIndexUpdates: []types.GlobalSecondaryIndexUpdate{
+			{Create: &types.CreateGlobalSecondaryIndexAction{
+				IndexName:             gsi.IndexName,
+				KeySchema:             gsi.KeySchema,
+				Projection:            gsi.Projection,
+				ProvisionedThroughput: gsi.ProvisionedThroughput,
+			}},
+		},
+	}, optFns...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteGlobalIndex deletes global index from the table. Use WaitUntilIndexDeleted to wait until the index is gone
+func (d *DynamoDB) DeleteGlobalIndex(tableName, indexName string) error {
+	return d.DeleteGlobalIndexWithContext(context.Background(), tableName, indexName)
+}
+
+// DeleteGlobalIndexWithContext is DeleteGlobalIndex with context and request options
+func (d *DynamoDB) DeleteGlobalIndexWithContext(ctx context.Context, tableName, indexName string, optFns ...func(*dynamodb.Options)) error {
+	_, err := d.DynamoDB.UpdateTable(ctx, &dynamodb.UpdateTableInput{
+		TableName: aws.String(tableName),
+		GlobalSecondaryIndexUpdates: []types.GlobalSecondaryIndexUpdate{
+			{Delete: &types.DeleteGlobalSecondaryIndexAction{IndexName: aws.String(indexName)}},
+		},
+	}, optFns...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GlobalIndexStatus returns status of global index. ErrNotFound is returned when the index does not exist
+func (d *DynamoDB) GlobalIndexStatus(tableName, indexName string) (*IndexStatus, error) {
+	return d.GlobalIndexStatusWithContext(context.Background(), tableName, indexName)
+}
+
+// GlobalIndexStatusWithContext is GlobalIndexStatus with context and request options
+func (d *DynamoDB) GlobalIndexStatusWithContext(ctx context.Context, tableName, indexName string, optFns ...func(*dynamodb.Options)) (*IndexStatus, error) {
+	res, err := d.DynamoDB.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(tableName)}, optFns...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, gsi := range res.Table.GlobalSecondaryIndexes {
+		if aws.ToString(gsi.IndexName) != indexName {
+			continue
+		}
+		return &IndexStatus{
+			Status:      gsi.IndexStatus,
+			Backfilling: aws.ToBool(gsi.Backfilling),
+			ItemCount:   aws.ToInt64(gsi.ItemCount),
+		}, nil
+	}
+
+	return nil, ErrNotFound
+}
+
+// WaitUntilIndexActive waits until global index becomes ACTIVE and backfilling finishes up to maxWait
+func (d *DynamoDB) WaitUntilIndexActive(tableName, indexName string, maxWait time.Duration) error {
+	return d.WaitUntilIndexActiveWithContext(context.Background(), tableName, indexName, maxWait)
+}
+
+// WaitUntilIndexActiveWithContext is WaitUntilIndexActive with context and request options
+func (d *DynamoDB) WaitUntilIndexActiveWithContext(ctx context.Context, tableName, indexName string, maxWait time.Duration, optFns ...func(*dynamodb.Options)) error {
+	return d.waitIndex(ctx, tableName, indexName, maxWait, func(s *IndexStatus, err error) (bool, error) {
+		if err != nil {
+			return false, err
+		}
+		return s.Active(), nil
+	}, optFns...)
+}
+
+// WaitUntilIndexDeleted waits until global index is gone up to maxWait
+func (d *DynamoDB) WaitUntilIndexDeleted(tableName, indexName string, maxWait time.Duration) error {
+	return d.WaitUntilIndexDeletedWithContext(context.Background(), tableName, indexName, maxWait)
+}
+
+// WaitUntilIndexDeletedWithContext is WaitUntilIndexDeleted with context and request options
+func (d *DynamoDB) WaitUntilIndexDeletedWithContext(ctx context.Context, tableName, indexName string, maxWait time.Duration, optFns ...func(*dynamodb.Options)) error {
+	return d.waitIndex(ctx, tableName, indexName, maxWait, func(s *IndexStatus, err error) (bool, error) {
+		if errors.Is(err, ErrNotFound) {
+			return true, nil
+		}
+		return false, err
+	}, optFns...)
+}
+
+// waitIndex polls status of the index until done returns true
+func (d *DynamoDB) waitIndex(ctx context.Context, tableName, indexName string, maxWait time.Duration, done func(*IndexStatus, error) (bool, error), optFns ...func(*dynamodb.Options)) error {
+	ctx, cancel := context.WithTimeout(ctx, maxWait)
+	defer cancel()
+
+	for {
+		ok, err := done(d.GlobalIndexStatusWithContext(ctx, tableName, indexName, optFns...))
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+
+		err = sleep(ctx, indexPollInterval)
+		if err != nil {
+			return fmt.Errorf("index %s of table %s: %w", indexName, tableName, err)
+		}
+	}
+}
+
+// QueryIndex reads a page of items of the index matching keyCond and unmarshals them into T.
+// The token of the next page is returned with items. It is empty on the last page
+func QueryIndex[T any](ctx context.Context, d *DynamoDB, indexName string, keyCond expression.KeyConditionBuilder, queryOpts ...QueryOption) ([]T, string, error) {
+	page, err := d.Query(ctx, keyCond, append(slices.Clone(queryOpts), QueryOptionIndex(indexName))...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	ret := make([]T, 0, len(page.Items))
+	err = page.Unmarshal(&ret)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return ret, page.NextToken, nil
+}
+
+// QueryIndexAll returns iterator of all items of the index matching keyCond unmarshaled into T. Pages are read on demand
+func QueryIndexAll[T any](ctx context.Context, d *DynamoDB, indexName string, keyCond expression.KeyConditionBuilder, queryOpts ...QueryOption) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		for item, err := range d.QueryAll(ctx, keyCond, append(slices.Clone(queryOpts), QueryOptionIndex(indexName))...) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			ret := new(T)
+			err := attributevalue.UnmarshalMap(item, ret)
+			if !yield(ret, err) || err != nil {
+				return
+			}
+		}
+	}
+}
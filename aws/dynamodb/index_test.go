@@ -0,0 +1,258 @@
+package dynamodb_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	awsdynamodb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/rssh-jp/data-access-library/aws/dynamodb"
+)
+
+type ticket struct {
+	ID       string `dynamodbav:"key"`
+	Assignee string `dynamodbav:"assignee,omitempty"`
+	Priority int    `dynamodbav:"priority"`
+}
+
+func TestIndex(t *testing.T) {
+	assigneeIndex := &dynamodb.IndexDefinition{
+		Name:         "assignee_index",
+		PartitionKey: dynamodb.KeyAttribute{Name: "assignee", Type: dynamodb.AttributeTypeString},
+		SortKey:      &dynamodb.KeyAttribute{Name: "priority", Type: dynamodb.AttributeTypeNumber},
+	}
+
+	t.Run("Definition", func(t *testing.T) {
+		c := newTestClient(t)
+		d := c.newDynamoDB()
+
+		err := d.CreateTableFromDefinition(&dynamodb.TableDefinition{
+			Name:         "t",
+			PartitionKey: dynamodb.KeyAttribute{Name: "key", Type: dynamodb.AttributeTypeString},
+			Throughput:   &dynamodb.Throughput{Read: 3, Write: 2},
+			GlobalIndexes: []*dynamodb.IndexDefinition{
+				assigneeIndex,
+				{
+					Name:             "priority_index",
+					PartitionKey:     dynamodb.KeyAttribute{Name: "priority", Type: dynamodb.AttributeTypeNumber},
+					ProjectionType:   dynamodb.ProjectionInclude,
+					NonKeyAttributes: []string{"assignee"},
+					Throughput:       &dynamodb.Throughput{Read: 1, Write: 1},
+				},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		in := lastRequest[awsdynamodb.CreateTableInput](t, c)
+		expectAttrs := []types.AttributeDefinition{
+			{AttributeName: aws.String("key"), AttributeType: types.ScalarAttributeTypeS},
+			{AttributeName: aws.String("assignee"), AttributeType: types.ScalarAttributeTypeS},
+			{AttributeName: aws.String("priority"), AttributeType: types.ScalarAttributeTypeN},
+		}
+		if !reflect.DeepEqual(expectAttrs, in.AttributeDefinitions) {
+			t.Errorf("Could not match attribute definitions.\nexpect: %v\nactual: %v", expectAttrs, in.AttributeDefinitions)
+		}
+		expect := []types.GlobalSecondaryIndex{
+			{
+				IndexName: aws.String("assignee_index"),
+				KeySchema: []types.KeySchemaElement{
+					{AttributeName: aws.String("assignee"), KeyType: types.KeyTypeHash},
+					{AttributeName: aws.String("priority"), KeyType: types.KeyTypeRange},
+				},
+				Projection:            &types.Projection{ProjectionType: types.ProjectionTypeAll},
+				ProvisionedThroughput: &types.ProvisionedThroughput{ReadCapacityUnits: aws.Int64(3), WriteCapacityUnits: aws.Int64(2)},
+			},
+			{
+				IndexName: aws.String("priority_index"),
+				KeySchema: []types.KeySchemaElement{
+					{AttributeName: aws.String("priority"), KeyType: types.KeyTypeHash},
+				},
+				Projection:            &types.Projection{ProjectionType: types.ProjectionTypeInclude, NonKeyAttributes: []string{"assignee"}},
+				ProvisionedThroughput: &types.ProvisionedThroughput{ReadCapacityUnits: aws.Int64(1), WriteCapacityUnits: aws.Int64(1)},
+			},
+		}
+		if !reflect.DeepEqual(expect, in.GlobalSecondaryIndexes) {
+			t.Errorf("Could not match global indexes.\nexpect: %v\nactual: %v", expect, in.GlobalSecondaryIndexes)
+		}
+	})
+	t.Run("Local index", func(t *testing.T) {
+		c := newTestClient(t)
+		newOrderTable(t, c)
+
+		in := lastRequest[awsdynamodb.CreateTableInput](t, c)
+		expect := []types.LocalSecondaryIndex{
+			{
+				IndexName: aws.String("status_index"),
+				KeySchema: []types.KeySchemaElement{
+					{AttributeName: aws.String("user_id"), KeyType: types.KeyTypeHash},
+					{AttributeName: aws.String("status"), KeyType: types.KeyTypeRange},
+				},
+				Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+			},
+		}
+		if !reflect.DeepEqual(expect, in.LocalSecondaryIndexes) {
+			t.Errorf("Could not match local indexes.\nexpect: %v\nactual: %v", expect, in.LocalSecondaryIndexes)
+		}
+	})
+	t.Run("Invalid definition", func(t *testing.T) {
+		c := newTestClient(t)
+		d := c.newDynamoDB()
+
+		key := dynamodb.KeyAttribute{Name: "key", Type: dynamodb.AttributeTypeString}
+		defs := map[string]*dynamodb.TableDefinition{
+			"duplicated index": {Name: "t", PartitionKey: key, GlobalIndexes: []*dynamodb.IndexDefinition{assigneeIndex, assigneeIndex}},
+			"conflicting type": {Name: "t", PartitionKey: key, GlobalIndexes: []*dynamodb.IndexDefinition{
+				{Name: "i", PartitionKey: dynamodb.KeyAttribute{Name: "key", Type: dynamodb.AttributeTypeNumber}},
+			}},
+			"local index without sort key": {Name: "t", PartitionKey: key, LocalIndexes: []*dynamodb.IndexDefinition{{Name: "i"}}},
+			"non key attributes without include": {Name: "t", PartitionKey: key, GlobalIndexes: []*dynamodb.IndexDefinition{
+				{Name: "i", PartitionKey: key, NonKeyAttributes: []string{"a"}},
+			}},
+			"throughput with pay per request": {Name: "t", PartitionKey: key, BillingMode: dynamodb.BillingModePayPerRequest, GlobalIndexes: []*dynamodb.IndexDefinition{
+				{Name: "i", PartitionKey: key, Throughput: &dynamodb.Throughput{Read: 1, Write: 1}},
+			}},
+		}
+		for name, def := range defs {
+			err := d.CreateTableFromDefinition(def)
+			if err == nil {
+				t.Errorf("Bug. %s is invalid. But no error", name)
+			}
+		}
+	})
+	t.Run("Global index lifecycle", func(t *testing.T) {
+		c := newTestClient(t)
+		d := c.newDynamoDB()
+
+		err := d.CreateTableFromDefinition(&dynamodb.TableDefinition{
+			Name:         d.DefaultTableName,
+			PartitionKey: dynamodb.KeyAttribute{Name: d.DefaultKeyName},
+			BillingMode:  dynamodb.BillingModePayPerRequest,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tickets := []ticket{
+			{ID: "t1", Assignee: "alice", Priority: 2},
+			{ID: "t2", Assignee: "bob", Priority: 1},
+			{ID: "t3", Assignee: "alice", Priority: 1},
+			{ID: "t4", Priority: 1},
+		}
+		for _, item := range tickets {
+			err := dynamodb.PutItem(context.Background(), d, item)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		c.creatingIndexDescribes = 1
+		err = d.CreateGlobalIndex(d.DefaultTableName, assigneeIndex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lastRequest[awsdynamodb.UpdateTableInput](t, c).GlobalSecondaryIndexUpdates[0].Create.ProvisionedThroughput != nil {
+			t.Error("Bug. Index of pay per request table has throughput")
+		}
+
+		status, err := d.GlobalIndexStatus(d.DefaultTableName, assigneeIndex.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.Status != types.IndexStatusCreating || !status.Backfilling || status.Active() {
+			t.Errorf("Could not match status. actual: %+v", status)
+		}
+
+		err = d.WaitUntilIndexActive(d.DefaultTableName, assigneeIndex.Name, 10*time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		keyCond := expression.Key("assignee").Equal(expression.Value("alice"))
+		actual, token, err := dynamodb.QueryIndex[ticket](context.Background(), d, assigneeIndex.Name, keyCond)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect := []ticket{tickets[2], tickets[0]}
+		if !reflect.DeepEqual(expect, actual) || token != "" {
+			t.Errorf("Could not match tickets.\nexpect: %v\nactual: %v, %q", expect, actual, token)
+		}
+
+		// spare capacity of options of the caller is not written
+		queryOpts := make([]dynamodb.QueryOption, 1, 2)
+		queryOpts[0] = dynamodb.QueryOptionDescending()
+		_, _, err = dynamodb.QueryIndex[ticket](context.Background(), d, assigneeIndex.Name, keyCond, queryOpts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if queryOpts[:2][1] != nil {
+			t.Error("Bug. options of the caller should not be modified. But index option is appended")
+		}
+
+		var all []ticket
+		for item, err := range dynamodb.QueryIndexAll[ticket](context.Background(), d, assigneeIndex.Name, keyCond, dynamodb.QueryOptionLimit(1), dynamodb.QueryOptionDescending()) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			all = append(all, *item)
+		}
+		expect = []ticket{tickets[0], tickets[2]}
+		if !reflect.DeepEqual(expect, all) {
+			t.Errorf("Could not match tickets.\nexpect: %v\nactual: %v", expect, all)
+		}
+
+		err = d.DeleteGlobalIndex(d.DefaultTableName, assigneeIndex.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = d.WaitUntilIndexDeleted(d.DefaultTableName, assigneeIndex.Name, 10*time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = d.GlobalIndexStatus(d.DefaultTableName, assigneeIndex.Name)
+		if !errors.Is(err, dynamodb.ErrNotFound) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrNotFound, err)
+		}
+	})
+	t.Run("Index of provisioned table", func(t *testing.T) {
+		c := newTestClient(t)
+		d := c.newDynamoDB()
+
+		err := d.CreateTableFromDefinition(&dynamodb.TableDefinition{
+			Name:         "t",
+			PartitionKey: dynamodb.KeyAttribute{Name: "key"},
+			Throughput:   &dynamodb.Throughput{Read: 7, Write: 3},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = d.CreateGlobalIndex("t", assigneeIndex)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req := lastRequest[awsdynamodb.UpdateTableInput](t, c)
+		actual := req.GlobalSecondaryIndexUpdates[0].Create.ProvisionedThroughput
+		expect := &types.ProvisionedThroughput{ReadCapacityUnits: aws.Int64(7), WriteCapacityUnits: aws.Int64(3)}
+		if !reflect.DeepEqual(expect, actual) {
+			t.Errorf("Could not match throughput.\nexpect: %v\nactual: %v", expect, actual)
+		}
+
+		expectAttrs := []types.AttributeDefinition{
+			{AttributeName: aws.String("assignee"), AttributeType: types.ScalarAttributeTypeS},
+			{AttributeName: aws.String("priority"), AttributeType: types.ScalarAttributeTypeN},
+		}
+		if !reflect.DeepEqual(expectAttrs, req.AttributeDefinitions) {
+			t.Errorf("Could not match attribute definitions.\nexpect: %v\nactual: %v", expectAttrs, req.AttributeDefinitions)
+		}
+	})
+}
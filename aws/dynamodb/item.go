@@ -0,0 +1,202 @@
+package dynamodb
+
+import (
+	"context"
+	"maps"
+	"reflect"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// PutItem marshals item by attributevalue.MarshalMap and puts it on the default table.
+// item of map[string]types.AttributeValue is put as it is.
+// Struct fields are mapped by `dynamodbav` tags. e.g. `dynamodbav:"name,omitempty"`, `dynamodbav:",stringset"`.
+// item must contain the key attributes of the table.
+// With WriteOptionVersion the stored version is incremented, and item passed by pointer is updated to the new version
+func PutItem[T any](ctx context.Context, d *DynamoDB, item T, writeOpts ...WriteOption) error {
+	wc, err := createWriteConfig(writeOpts...)
+	if err != nil {
+		return err
+	}
+
+	in, av, err := d.putInput(wc, item)
+	if err != nil {
+		return err
+	}
+
+	_, err = d.DynamoDB.PutItem(ctx, in, wc.clientOpts...)
+	if err != nil {
+		return conditionError(err)
+	}
+
+	if wc.versionName != "" && reflect.ValueOf(item).Kind() == reflect.Pointer {
+		return attributevalue.UnmarshalMap(av, item)
+	}
+
+	return nil
+}
+
+// DeleteItem deletes the item with key.
+// With WriteOptionVersion key must have the version attribute. It is checked and is not sent as key
+func DeleteItem(ctx context.Context, d *DynamoDB, key Key, writeOpts ...WriteOption) error {
+	wc, err := createWriteConfig(writeOpts...)
+	if err != nil {
+		return err
+	}
+
+	in, err := d.deleteInput(wc, key)
+	if err != nil {
+		return err
+	}
+
+	_, err = d.DynamoDB.DeleteItem(ctx, in, wc.clientOpts...)
+	if err != nil {
+		return conditionError(err)
+	}
+
+	return nil
+}
+
+// DeleteItemReturningOld is DeleteItem returning the deleted item unmarshaled into T.
+// ErrNotFound is returned when the item did not exist
+func DeleteItemReturningOld[T any](ctx context.Context, d *DynamoDB, key Key, writeOpts ...WriteOption) (*T, error) {
+	wc, err := createWriteConfig(writeOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	in, err := d.deleteInput(wc, key)
+	if err != nil {
+		return nil, err
+	}
+	in.ReturnValues = types.ReturnValueAllOld
+
+	res, err := d.DynamoDB.DeleteItem(ctx, in, wc.clientOpts...)
+	if err != nil {
+		return nil, conditionError(err)
+	}
+	if len(res.Attributes) == 0 {
+		return nil, ErrNotFound
+	}
+
+	ret := new(T)
+	err = attributevalue.UnmarshalMap(res.Attributes, ret)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// putInput returns PutItemInput of item and the marshaled item with the incremented version
+func (d *DynamoDB) putInput(wc *writeConfig, item any) (*dynamodb.PutItemInput, map[string]types.AttributeValue, error) {
+	av, err := marshalItem(item)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cond, version, err := wc.conditionBuilder(av)
+	if err != nil {
+		return nil, nil, err
+	}
+	if wc.versionName != "" {
+		av[wc.versionName] = &types.AttributeValueMemberN{Value: strconv.FormatInt(version+1, 10)}
+	}
+
+	in := &dynamodb.PutItemInput{
+		Item:      av,
+		TableName: aws.String(wc.table(d)),
+	}
+	if cond != nil {
+		expr, err := expression.NewBuilder().WithCondition(*cond).Build()
+		if err != nil {
+			return nil, nil, err
+		}
+		in.ConditionExpression = expr.Condition()
+		in.ExpressionAttributeNames = expr.Names()
+		in.ExpressionAttributeValues = expr.Values()
+		in.ReturnValuesOnConditionCheckFailure = types.ReturnValuesOnConditionCheckFailureAllOld
+	}
+
+	return in, av, nil
+}
+
+func (d *DynamoDB) deleteInput(wc *writeConfig, key Key) (*dynamodb.DeleteItemInput, error) {
+	av, err := key.attributeValues()
+	if err != nil {
+		return nil, err
+	}
+
+	cond, _, err := wc.conditionBuilder(av)
+	if err != nil {
+		return nil, err
+	}
+	if wc.versionName != "" {
+		delete(av, wc.versionName)
+	}
+
+	in := &dynamodb.DeleteItemInput{
+		Key:       av,
+		TableName: aws.String(wc.table(d)),
+	}
+	if cond != nil {
+		expr, err := expression.NewBuilder().WithCondition(*cond).Build()
+		if err != nil {
+			return nil, err
+		}
+		in.ConditionExpression = expr.Condition()
+		in.ExpressionAttributeNames = expr.Names()
+		in.ExpressionAttributeValues = expr.Values()
+		in.ReturnValuesOnConditionCheckFailure = types.ReturnValuesOnConditionCheckFailureAllOld
+	}
+
+	return in, nil
+}
+
+// GetItem gets the item with key from the default table and unmarshals it into T.
+// ErrNotFound is returned when the item is absent or its expiration time of DefaultTTLName has passed
+func GetItem[T any](ctx context.Context, d *DynamoDB, key Key, readOpts ...ReadOption) (*T, error) {
+	rc, err := createReadConfig(readOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	av, err := key.attributeValues()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := d.DynamoDB.GetItem(ctx, &dynamodb.GetItemInput{
+		Key:            av,
+		TableName:      aws.String(rc.table(d)),
+		ConsistentRead: aws.Bool(rc.consistentRead),
+	}, rc.clientOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res.Item) == 0 || d.expired(res.Item) {
+		return nil, ErrNotFound
+	}
+
+	ret := new(T)
+	err = attributevalue.UnmarshalMap(res.Item, ret)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// marshalItem marshals item by attributevalue.MarshalMap. map[string]types.AttributeValue is copied without marshaling
+func marshalItem(item any) (map[string]types.AttributeValue, error) {
+	if av, ok := item.(map[string]types.AttributeValue); ok {
+		return maps.Clone(av), nil
+	}
+	return attributevalue.MarshalMap(item)
+}
@@ -0,0 +1,154 @@
+package dynamodb_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/rssh-jp/data-access-library/aws/dynamodb"
+)
+
+// upper is stored in upper case and read in lower case through custom marshaler
+type upper string
+
+func (u upper) MarshalDynamoDBAttributeValue() (types.AttributeValue, error) {
+	return &types.AttributeValueMemberS{Value: strings.ToUpper(string(u))}, nil
+}
+
+func (u *upper) UnmarshalDynamoDBAttributeValue(av types.AttributeValue) error {
+	s, ok := av.(*types.AttributeValueMemberS)
+	if !ok {
+		return errors.New("upper must be string")
+	}
+	*u = upper(strings.ToLower(s.Value))
+	return nil
+}
+
+var _ attributevalue.Marshaler = upper("")
+
+type testItem struct {
+	Key     string            `dynamodbav:"key"`
+	Count   int               `dynamodbav:"count"`
+	Ratio   float64           `dynamodbav:"ratio"`
+	Tags    []string          `dynamodbav:"tags,stringset"`
+	Scores  []int             `dynamodbav:"scores,numberset"`
+	List    []any             `dynamodbav:"list"`
+	Attrs   map[string]string `dynamodbav:"attrs"`
+	Data    []byte            `dynamodbav:"data"`
+	Note    string            `dynamodbav:"note,omitempty"`
+	Code    upper             `dynamodbav:"code"`
+	Ignored string            `dynamodbav:"-"`
+}
+
+func TestItem(t *testing.T) {
+	c := newTestClient(t)
+	d := c.newDynamoDB()
+
+	err := d.CreateDefaultTable()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Round trip", func(t *testing.T) {
+		item := testItem{
+			Key:     "item1",
+			Count:   3,
+			Ratio:   0.5,
+			Tags:    []string{"a", "b"},
+			Scores:  []int{1, 2},
+			List:    []any{"x", 1.5, true},
+			Attrs:   map[string]string{"color": "red"},
+			Data:    []byte{0x00, 0x01},
+			Code:    "abc",
+			Ignored: "ignored",
+		}
+
+		err := dynamodb.PutItem(context.Background(), d, item)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual, err := dynamodb.GetItem[testItem](context.Background(), d, d.Key("item1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expect := item
+		expect.Ignored = ""
+		if !reflect.DeepEqual(&expect, actual) {
+			t.Errorf("Could not match item.\nexpect: %+v\nactual: %+v", &expect, actual)
+		}
+	})
+	t.Run("Stored attributes", func(t *testing.T) {
+		err := dynamodb.PutItem(context.Background(), d, testItem{Key: "item2", Code: "abc"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual, err := dynamodb.GetItem[map[string]any](context.Background(), d, d.Key("item2"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok := (*actual)["note"]; ok {
+			t.Errorf("Bug. Empty note is omitted. But stored: %v", *actual)
+		}
+		if (*actual)["code"] != "ABC" {
+			t.Errorf("Could not match code.\nexpect: %s\nactual: %v", "ABC", (*actual)["code"])
+		}
+	})
+	t.Run("Not found", func(t *testing.T) {
+		_, err := dynamodb.GetItem[testItem](context.Background(), d, d.Key("unknown"))
+		if !errors.Is(err, dynamodb.ErrNotFound) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrNotFound, err)
+		}
+	})
+	t.Run("Table", func(t *testing.T) {
+		err := d.CreateTableFromDefinition(&dynamodb.TableDefinition{Name: "items", PartitionKey: dynamodb.KeyAttribute{Name: "key"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = dynamodb.PutItem(context.Background(), d, testItem{Key: "other", Count: 7}, dynamodb.WriteOptionTable("items"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual, err := dynamodb.GetItem[testItem](context.Background(), d, d.Key("other"), dynamodb.ReadOptionTable("items"), dynamodb.ReadOptionConsistentRead())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual.Count != 7 {
+			t.Errorf("Could not match count.\nexpect: %d\nactual: %d", 7, actual.Count)
+		}
+
+		_, err = dynamodb.GetItem[testItem](context.Background(), d, d.Key("other"))
+		if !errors.Is(err, dynamodb.ErrNotFound) {
+			t.Errorf("Bug. the item is not on the default table. But %v", err)
+		}
+	})
+	t.Run("Delete returning old", func(t *testing.T) {
+		err := dynamodb.PutItem(context.Background(), d, testItem{Key: "deleted", Count: 5})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual, err := dynamodb.DeleteItemReturningOld[testItem](context.Background(), d, d.Key("deleted"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual.Count != 5 {
+			t.Errorf("Could not match count.\nexpect: %v\nactual: %v", 5, actual.Count)
+		}
+
+		_, err = dynamodb.DeleteItemReturningOld[testItem](context.Background(), d, d.Key("deleted"))
+		if !errors.Is(err, dynamodb.ErrNotFound) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrNotFound, err)
+		}
+	})
+}
@@ -0,0 +1,460 @@
+package dynamodb
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+const (
+	lockOwnerName = "lock_owner"
+	lockRVNName   = "lock_rvn"
+	lockLeaseName = "lock_lease_ms"
+	lockTokenName = "lock_token"
+)
+
+var (
+	// ErrLockHeld is returned by TryLock when the lock is held by another owner
+	ErrLockHeld = errors.New("lock is held by another owner")
+	// ErrLockLost is returned by Unlock when the lock was taken over or released before
+	ErrLockLost = errors.New("lock lost")
+)
+
+type lockConfig struct {
+	tableName     string
+	keyName       string
+	owner         string
+	leaseDuration time.Duration
+	heartbeat     time.Duration
+	retryInterval time.Duration
+	clientOpts    []func(*dynamodb.Options)
+}
+
+func newLockConfig() *lockConfig {
+	hostname, _ := os.Hostname()
+
+	return &lockConfig{
+		tableName:     "",
+		keyName:       "",
+		owner:         fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), newRecordVersion()[:8]),
+		leaseDuration: 20 * time.Second,
+		heartbeat:     0,
+		retryInterval: time.Second,
+		clientOpts:    nil,
+	}
+}
+
+func createLockConfig(lockOpts ...LockOption) (*lockConfig, error) {
+	lc := newLockConfig()
+
+	for _, opt := range lockOpts {
+		err := opt(lc)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if lc.heartbeat == 0 {
+		lc.heartbeat = lc.leaseDuration / 3
+	}
+	if lc.heartbeat >= lc.leaseDuration {
+		return nil, errors.New("heartbeat interval must be shorter than lease duration")
+	}
+
+	return lc, nil
+}
+
+// LockOption is functional option pattern option for LockClient
+type LockOption func(*lockConfig) error
+
+// LockOptionTable returns LockOption instance keeping locks in table instead of DefaultTableName
+func LockOptionTable(name string) func(c *lockConfig) error {
+	return func(c *lockConfig) error {
+		if name == "" {
+			return errors.New("table name is empty")
+		}
+		c.tableName = name
+		return nil
+	}
+}
+
+// LockOptionKeyName returns LockOption instance with partition key attribute of the lock table instead of DefaultKeyName.
+// It is needed when the table of LockOptionTable has another key schema
+func LockOptionKeyName(name string) func(c *lockConfig) error {
+	return func(c *lockConfig) error {
+		if name == "" {
+			return errors.New("key name is empty")
+		}
+		c.keyName = name
+		return nil
+	}
+}
+
+// LockOptionOwner returns LockOption instance with owner name. It is generated from host name and process id by default
+func LockOptionOwner(owner string) func(c *lockConfig) error {
+	return func(c *lockConfig) error {
+		if owner == "" {
+			return errors.New("owner is empty")
+		}
+		c.owner = owner
+		return nil
+	}
+}
+
+// LockOptionLeaseDuration returns LockOption instance with lease duration. 20 seconds by default.
+// Other owners take over the lock when it is not extended for the lease duration
+func LockOptionLeaseDuration(d time.Duration) func(c *lockConfig) error {
+	return func(c *lockConfig) error {
+		if d <= 0 {
+			return errors.New("lease duration must be positive")
+		}
+		c.leaseDuration = d
+		return nil
+	}
+}
+
+// LockOptionHeartbeatInterval returns LockOption instance with interval extending the lease. 1/3 of lease duration by default
+func LockOptionHeartbeatInterval(d time.Duration) func(c *lockConfig) error {
+	return func(c *lockConfig) error {
+		if d <= 0 {
+			return errors.New("heartbeat interval must be positive")
+		}
+		c.heartbeat = d
+		return nil
+	}
+}
+
+// LockOptionRetryInterval returns LockOption instance with interval of retries by Lock. 1 second by default
+func LockOptionRetryInterval(d time.Duration) func(c *lockConfig) error {
+	return func(c *lockConfig) error {
+		if d <= 0 {
+			return errors.New("retry interval must be positive")
+		}
+		c.retryInterval = d
+		return nil
+	}
+}
+
+// LockOptionClientOptions returns LockOption instance with request options
+func LockOptionClientOptions(optFns ...func(*dynamodb.Options)) func(c *lockConfig) error {
+	return func(c *lockConfig) error {
+		c.clientOpts = append(c.clientOpts, optFns...)
+		return nil
+	}
+}
+
+// LockClient acquires named locks stored as items keyed by DefaultKeyName, or by the attribute of LockOptionKeyName.
+// The owner extends the lease by rewriting the record version number of the item periodically.
+// Other owners regard the lock as stale when they observe the same record version number for the lease duration,
+// so the lock is taken over without relying on synchronized clocks
+type LockClient struct {
+	d  *DynamoDB
+	lc *lockConfig
+
+	mu sync.Mutex
+	// observed is record version numbers of locks held by other owners and the time first observed
+	observed map[string]lockObservation
+}
+
+type lockObservation struct {
+	rvn string
+	at  time.Time
+}
+
+type lockItem struct {
+	Owner   string `dynamodbav:"lock_owner"`
+	RVN     string `dynamodbav:"lock_rvn"`
+	LeaseMS int64  `dynamodbav:"lock_lease_ms"`
+}
+
+// NewLockClient returns LockClient instance
+func (d *DynamoDB) NewLockClient(lockOpts ...LockOption) (*LockClient, error) {
+	lc, err := createLockConfig(lockOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LockClient{
+		d:        d,
+		lc:       lc,
+		observed: make(map[string]lockObservation),
+	}, nil
+}
+
+// Owner returns the owner name of the client
+func (c *LockClient) Owner() string {
+	return c.lc.owner
+}
+
+// TryLock acquires the lock of name without waiting. ErrLockHeld is returned when it is held by another owner.
+// ctx bounds the lifetime of the lock: the lock is released when ctx is canceled
+func (c *LockClient) TryLock(ctx context.Context, name string) (*Lock, error) {
+	return c.acquire(ctx, name)
+}
+
+// Lock acquires the lock of name waiting until it is released or becomes stale.
+// ctx bounds both the wait and the lifetime of the lock: the lock is released when ctx is canceled
+func (c *LockClient) Lock(ctx context.Context, name string) (*Lock, error) {
+	for {
+		l, err := c.acquire(ctx, name)
+		if !errors.Is(err, ErrLockHeld) {
+			return l, err
+		}
+
+		err = sleep(ctx, c.lc.retryInterval)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (c *LockClient) acquire(ctx context.Context, name string) (*Lock, error) {
+	key := Key{c.keyName(): name}
+	av, err := key.attributeValues()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.d.DynamoDB.GetItem(ctx, &dynamodb.GetItemInput{
+		Key:            av,
+		TableName:      aws.String(c.table()),
+		ConsistentRead: aws.Bool(true),
+	}, c.lc.clientOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	cond := expression.AttributeNotExists(expression.Name(c.keyName()))
+	if len(res.Item) > 0 {
+		var current lockItem
+		err := attributevalue.UnmarshalMap(res.Item, &current)
+		if err != nil {
+			return nil, err
+		}
+
+		if !c.stale(name, &current) {
+			return nil, ErrLockHeld
+		}
+		cond = expression.Name(lockRVNName).Equal(expression.Value(current.RVN))
+	}
+
+	item := map[string]any{
+		c.keyName():   name,
+		lockOwnerName: c.lc.owner,
+		lockRVNName:   newRecordVersion(),
+		lockLeaseName: c.lc.leaseDuration.Milliseconds(),
+		lockTokenName: newRecordVersion(),
+	}
+	// the lease starts no earlier than the write
+	acquired := time.Now()
+	err = PutItem(ctx, c.d, item, c.writeOptions(cond)...)
+	if errors.Is(err, ErrConditionFailed) {
+		return nil, ErrLockHeld
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	delete(c.observed, name)
+	c.mu.Unlock()
+
+	l := &Lock{
+		c:        c,
+		name:     name,
+		rvn:      item[lockRVNName].(string),
+		token:    item[lockTokenName].(string),
+		extended: acquired,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		lost:     make(chan struct{}),
+	}
+	go l.run(ctx)
+
+	return l, nil
+}
+
+// stale reports whether the lock held by another owner has not been extended for its lease duration
+func (c *LockClient) stale(name string, current *lockItem) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	obs, ok := c.observed[name]
+	if !ok || obs.rvn != current.RVN {
+		c.observed[name] = lockObservation{rvn: current.RVN, at: time.Now()}
+		return false
+	}
+
+	return time.Since(obs.at) >= time.Duration(current.LeaseMS)*time.Millisecond
+}
+
+func (c *LockClient) table() string {
+	if c.lc.tableName != "" {
+		return c.lc.tableName
+	}
+	return c.d.DefaultTableName
+}
+
+func (c *LockClient) keyName() string {
+	if c.lc.keyName != "" {
+		return c.lc.keyName
+	}
+	return c.d.DefaultKeyName
+}
+
+func (c *LockClient) writeOptions(cond expression.ConditionBuilder) []WriteOption {
+	return []WriteOption{
+		WriteOptionTable(c.table()),
+		WriteOptionCondition(cond),
+		WriteOptionClientOptions(c.lc.clientOpts...),
+	}
+}
+
+// Lock is acquired lock. The lease is extended in background until Unlock is called or the lock is lost
+type Lock struct {
+	c    *LockClient
+	name string
+	// token is written on acquisition and kept by extension, so it tells the lease from ones acquired later by the same owner
+	token string
+	// rvn and extended are touched only by run until done is closed
+	rvn      string
+	extended time.Time
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+	lostOnce sync.Once
+	lost     chan struct{}
+	// released and err are result of release by run when ctx is canceled
+	released bool
+	err      error
+}
+
+// Name returns the name of the lock
+func (l *Lock) Name() string {
+	return l.name
+}
+
+// Lost returns channel closed when the lock is no longer held: the lease could not be extended, ctx is canceled or Unlock is called.
+// When the lease could not be extended, it is closed a heartbeat interval before the lease runs out, so that other owners do not take over the lock in use
+func (l *Lock) Lost() <-chan struct{} {
+	return l.lost
+}
+
+func (l *Lock) closeLost() {
+	l.lostOnce.Do(func() { close(l.lost) })
+}
+
+// Unlock releases the lock. ErrLockLost is returned when the lock was taken over or the lease could not be extended.
+// It returns nil when the lock was already released by canceled ctx
+func (l *Lock) Unlock(ctx context.Context) error {
+	l.stopOnce.Do(func() { close(l.stop) })
+	<-l.done
+
+	select {
+	case <-l.lost:
+		if l.released || l.err != nil {
+			return l.err
+		}
+		return ErrLockLost
+	default:
+	}
+
+	err := l.release(ctx)
+	l.closeLost()
+	return err
+}
+
+// run extends the lease until stop is closed, ctx is canceled or the lock is lost
+func (l *Lock) run(ctx context.Context) {
+	defer close(l.done)
+
+	ticker := time.NewTicker(l.c.lc.heartbeat)
+	defer ticker.Stop()
+
+	// the lock is given up a heartbeat interval before the lease runs out
+	margin := l.c.lc.leaseDuration - l.c.lc.heartbeat
+	expiry := time.NewTimer(time.Until(l.extended.Add(margin)))
+	defer expiry.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ctx.Done():
+			// ctx is canceled, so the lock is released by another context bounded by the lease
+			releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), l.c.lc.leaseDuration)
+			l.err = l.release(releaseCtx)
+			l.released = l.err == nil
+			cancel()
+			l.closeLost()
+			return
+		case <-expiry.C:
+			l.closeLost()
+			return
+		case <-ticker.C:
+			start := time.Now()
+			extendCtx, cancel := context.WithDeadline(ctx, l.extended.Add(margin))
+			err := l.extend(extendCtx)
+			cancel()
+			if errors.Is(err, ErrConditionFailed) {
+				l.closeLost()
+				return
+			}
+			if err == nil {
+				l.extended = start
+				expiry.Reset(time.Until(start.Add(margin)))
+			}
+		}
+	}
+}
+
+// extend rewrites the record version number when the lock is still owned
+func (l *Lock) extend(ctx context.Context) error {
+	rvn := newRecordVersion()
+	err := l.c.d.Update(ctx, Key{l.c.keyName(): l.name}, NewUpdate().Set(lockRVNName, rvn), l.c.writeOptions(l.owned())...)
+	if err != nil {
+		return err
+	}
+
+	l.rvn = rvn
+	return nil
+}
+
+// release deletes the item when the lock is still owned
+func (l *Lock) release(ctx context.Context) error {
+	err := DeleteItem(ctx, l.c.d, Key{l.c.keyName(): l.name}, l.c.writeOptions(l.owned())...)
+	if errors.Is(err, ErrConditionFailed) {
+		return fmt.Errorf("%w: %v", ErrLockLost, err)
+	}
+	return err
+}
+
+// held returns condition that the lease acquired by l is not taken over. Unlike owned, it is not affected by extension
+func (l *Lock) held() expression.ConditionBuilder {
+	return expression.Name(lockOwnerName).Equal(expression.Value(l.c.lc.owner)).
+		And(expression.Name(lockTokenName).Equal(expression.Value(l.token)))
+}
+
+// owned returns condition that the lock is not taken over
+func (l *Lock) owned() expression.ConditionBuilder {
+	return expression.Name(lockOwnerName).Equal(expression.Value(l.c.lc.owner)).
+		And(expression.Name(lockRVNName).Equal(expression.Value(l.rvn)))
+}
+
+// newRecordVersion returns random record version number
+func newRecordVersion() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,321 @@
+package dynamodb_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	awsdynamodb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+
+	"github.com/rssh-jp/data-access-library/aws/dynamodb"
+)
+
+// updateFailingClient fails UpdateItem while failing is set
+type updateFailingClient struct {
+	dynamodb.Client
+	failing atomic.Bool
+}
+
+func (c *updateFailingClient) UpdateItem(ctx context.Context, params *awsdynamodb.UpdateItemInput, optFns ...func(*awsdynamodb.Options)) (*awsdynamodb.UpdateItemOutput, error) {
+	if c.failing.Load() {
+		return nil, errors.New("update failed")
+	}
+	return c.Client.UpdateItem(ctx, params, optFns...)
+}
+
+// deleteBlockingClient blocks DeleteItem until release is closed. entered receives every DeleteItem
+type deleteBlockingClient struct {
+	dynamodb.Client
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (c *deleteBlockingClient) DeleteItem(ctx context.Context, params *awsdynamodb.DeleteItemInput, optFns ...func(*awsdynamodb.Options)) (*awsdynamodb.DeleteItemOutput, error) {
+	c.entered <- struct{}{}
+	<-c.release
+	return c.Client.DeleteItem(ctx, params, optFns...)
+}
+
+func TestLock(t *testing.T) {
+	newDynamoDB := func(t *testing.T) *dynamodb.DynamoDB {
+		c := newTestClient(t)
+		d := c.newDynamoDB()
+		if err := d.CreateDefaultTable(); err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	newLockClient := func(t *testing.T, d *dynamodb.DynamoDB, owner string, lease time.Duration) *dynamodb.LockClient {
+		t.Helper()
+
+		c, err := d.NewLockClient(
+			dynamodb.LockOptionOwner(owner),
+			dynamodb.LockOptionLeaseDuration(lease),
+			dynamodb.LockOptionRetryInterval(10*time.Millisecond),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	t.Run("TryLock and Unlock", func(t *testing.T) {
+		d := newDynamoDB(t)
+		a := newLockClient(t, d, "a", time.Minute)
+		b := newLockClient(t, d, "b", time.Minute)
+
+		l, err := a.TryLock(context.Background(), "leader")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if l.Name() != "leader" {
+			t.Errorf("Could not match name.\nexpect: %s\nactual: %s", "leader", l.Name())
+		}
+
+		_, err = b.TryLock(context.Background(), "leader")
+		if !errors.Is(err, dynamodb.ErrLockHeld) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrLockHeld, err)
+		}
+
+		// another name is independent
+		other, err := b.TryLock(context.Background(), "other")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer other.Unlock(context.Background())
+
+		err = l.Unlock(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-l.Lost():
+		default:
+			t.Error("Bug. Lock is unlocked. But Lost is not closed")
+		}
+
+		l, err = b.TryLock(context.Background(), "leader")
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.Unlock(context.Background())
+	})
+	t.Run("Heartbeat keeps lock", func(t *testing.T) {
+		d := newDynamoDB(t)
+		a := newLockClient(t, d, "a", 150*time.Millisecond)
+		b := newLockClient(t, d, "b", time.Minute)
+
+		l, err := a.TryLock(context.Background(), "leader")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer l.Unlock(context.Background())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+
+		_, err = b.Lock(ctx, "leader")
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", context.DeadlineExceeded, err)
+		}
+	})
+	t.Run("Stale lock", func(t *testing.T) {
+		d := newDynamoDB(t)
+		b := newLockClient(t, d, "b", time.Minute)
+
+		// lock of crashed owner is never extended
+		err := dynamodb.PutItem(context.Background(), d, map[string]any{
+			d.DefaultKeyName: "leader",
+			"lock_owner":     "crashed",
+			"lock_rvn":       "rvn",
+			"lock_lease_ms":  100,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = b.TryLock(context.Background(), "leader")
+		if !errors.Is(err, dynamodb.ErrLockHeld) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrLockHeld, err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+
+		start := time.Now()
+		l, err := b.Lock(ctx, "leader")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer l.Unlock(context.Background())
+
+		if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+			t.Errorf("Bug. Stale lock is taken over before lease duration: %v", elapsed)
+		}
+	})
+	t.Run("Lost", func(t *testing.T) {
+		d := newDynamoDB(t)
+		a := newLockClient(t, d, "a", 150*time.Millisecond)
+
+		l, err := a.TryLock(context.Background(), "leader")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// taken over by another owner
+		err = dynamodb.PutItem(context.Background(), d, map[string]any{
+			d.DefaultKeyName: "leader",
+			"lock_owner":     "other",
+			"lock_rvn":       "rvn",
+			"lock_lease_ms":  60000,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case <-l.Lost():
+		case <-time.After(time.Second):
+			t.Fatal("Bug. Lock is taken over. But Lost is not closed")
+		}
+
+		err = l.Unlock(context.Background())
+		if !errors.Is(err, dynamodb.ErrLockLost) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrLockLost, err)
+		}
+
+		owner, err := dynamodb.GetItem[map[string]any](context.Background(), d, d.Key("leader"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (*owner)["lock_owner"] != "other" {
+			t.Errorf("Bug. Lock of other owner is released: %v", *owner)
+		}
+	})
+	t.Run("Lost before lease runs out", func(t *testing.T) {
+		client := &updateFailingClient{Client: newTestClient(t)}
+		d := dynamodb.NewFromClient(client)
+		if err := d.CreateDefaultTable(); err != nil {
+			t.Fatal(err)
+		}
+		a := newLockClient(t, d, "a", 300*time.Millisecond)
+
+		start := time.Now()
+		l, err := a.TryLock(context.Background(), "leader")
+		if err != nil {
+			t.Fatal(err)
+		}
+		client.failing.Store(true)
+
+		select {
+		case <-l.Lost():
+		case <-time.After(time.Second):
+			t.Fatal("Bug. Lease is not extended. But Lost is not closed")
+		}
+		// other owners can take over the lock after the lease
+		if elapsed := time.Since(start); elapsed >= 300*time.Millisecond {
+			t.Errorf("Bug. Lost should be closed before the lease runs out. But %v", elapsed)
+		}
+	})
+	t.Run("Concurrent Unlock", func(t *testing.T) {
+		client := &deleteBlockingClient{Client: newTestClient(t), entered: make(chan struct{}), release: make(chan struct{})}
+		d := dynamodb.NewFromClient(client)
+		if err := d.CreateDefaultTable(); err != nil {
+			t.Fatal(err)
+		}
+		a := newLockClient(t, d, "a", time.Minute)
+
+		l, err := a.TryLock(context.Background(), "leader")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		errs := make([]error, 2)
+		var wg sync.WaitGroup
+		for i := range errs {
+			wg.Go(func() {
+				errs[i] = l.Unlock(context.Background())
+			})
+		}
+		// both Unlock release the lock at once
+		for range errs {
+			<-client.entered
+		}
+		close(client.release)
+		wg.Wait()
+
+		if (errs[0] == nil) == (errs[1] == nil) {
+			t.Errorf("Bug. Only one Unlock should release the lock. But %v", errs)
+		}
+	})
+	t.Run("Release on context cancel", func(t *testing.T) {
+		d := newDynamoDB(t)
+		a := newLockClient(t, d, "a", time.Minute)
+		b := newLockClient(t, d, "b", time.Minute)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		l, err := a.TryLock(ctx, "leader")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cancel()
+		<-l.Lost()
+
+		err = l.Unlock(context.Background())
+		if err != nil {
+			t.Errorf("Bug. Lock is released by canceled context. But error: %v", err)
+		}
+
+		l, err = b.TryLock(context.Background(), "leader")
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.Unlock(context.Background())
+	})
+	t.Run("Table with another key", func(t *testing.T) {
+		d := newDynamoDB(t)
+		err := d.CreateTableFromDefinition(&dynamodb.TableDefinition{
+			Name:         "locks",
+			PartitionKey: dynamodb.KeyAttribute{Name: "lock_name"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		newClient := func(owner string) *dynamodb.LockClient {
+			c, err := d.NewLockClient(dynamodb.LockOptionOwner(owner), dynamodb.LockOptionTable("locks"), dynamodb.LockOptionKeyName("lock_name"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return c
+		}
+		a := newClient("a")
+		b := newClient("b")
+
+		l, err := a.TryLock(context.Background(), "leader")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = b.TryLock(context.Background(), "leader")
+		if !errors.Is(err, dynamodb.ErrLockHeld) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrLockHeld, err)
+		}
+
+		err = l.Unlock(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("Invalid option", func(t *testing.T) {
+		d := newDynamoDB(t)
+
+		_, err := d.NewLockClient(dynamodb.LockOptionLeaseDuration(time.Second), dynamodb.LockOptionHeartbeatInterval(time.Second))
+		if err == nil {
+			t.Error("Bug. Heartbeat interval must be shorter than lease duration. But no error")
+		}
+	})
+}
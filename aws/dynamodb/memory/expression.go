@@ -0,0 +1,1250 @@
+package memory
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// expressions parses expressions of a request and tracks usage of the expression attribute names and values,
+// because DynamoDB rejects requests with unused or undefined placeholders
+type expressions struct {
+	names  map[string]string
+	values map[string]types.AttributeValue
+
+	usedNames  map[string]bool
+	usedValues map[string]bool
+}
+
+func newExpressions(names map[string]string, values map[string]types.AttributeValue) *expressions {
+	return &expressions{
+		names:      names,
+		values:     values,
+		usedNames:  make(map[string]bool),
+		usedValues: make(map[string]bool),
+	}
+}
+
+// checkUnused returns error when some of the expression attribute names or values are not used by expressions
+func (e *expressions) checkUnused() error {
+	var unusedNames, unusedValues []string
+	for name := range e.names {
+		if !e.usedNames[name] {
+			unusedNames = append(unusedNames, name)
+		}
+	}
+	for name := range e.values {
+		if !e.usedValues[name] {
+			unusedValues = append(unusedValues, name)
+		}
+	}
+
+	if len(unusedNames) > 0 {
+		slices.Sort(unusedNames)
+		return validationError("Value provided in ExpressionAttributeNames unused in expressions: keys: {%s}", strings.Join(unusedNames, ", "))
+	}
+	if len(unusedValues) > 0 {
+		slices.Sort(unusedValues)
+		return validationError("Value provided in ExpressionAttributeValues unused in expressions: keys: {%s}", strings.Join(unusedValues, ", "))
+	}
+
+	return nil
+}
+
+// condition parses condition expression. nil is returned for empty expression
+func (e *expressions) condition(kind, expr string) (condition, error) {
+	if expr == "" {
+		return nil, nil
+	}
+
+	p, err := e.parser(kind, expr)
+	if err != nil {
+		return nil, err
+	}
+
+	cond, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.end(); err != nil {
+		return nil, err
+	}
+
+	return cond, nil
+}
+
+// update parses update expression
+func (e *expressions) update(expr string) (*update, error) {
+	p, err := e.parser("UpdateExpression", expr)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := p.update()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.end(); err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// projection parses projection expression. nil is returned for empty expression
+func (e *expressions) projection(expr string) ([]path, error) {
+	if expr == "" {
+		return nil, nil
+	}
+
+	p, err := e.parser("ProjectionExpression", expr)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []path
+	for {
+		pa, err := p.path()
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, pa)
+
+		if !p.accept(",") {
+			break
+		}
+	}
+	if err := p.end(); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (e *expressions) parser(kind, expr string) (*parser, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, validationError("Invalid %s: %v", kind, err)
+	}
+
+	return &parser{e: e, kind: kind, tokens: tokens}, nil
+}
+
+// tokenize splits expression into names, placeholders, numbers and operators
+func tokenize(expr string) ([]string, error) {
+	var tokens []string
+
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '#' || c == ':' || isWordByte(c):
+			j := i + 1
+			for j < len(expr) && isWordByte(expr[j]) {
+				j++
+			}
+			if j == i+1 && !isWordByte(c) {
+				return nil, fmt.Errorf("Syntax error; token: %q", string(c))
+			}
+			tokens = append(tokens, expr[i:j])
+			i = j
+		case strings.HasPrefix(expr[i:], "<>") || strings.HasPrefix(expr[i:], "<=") || strings.HasPrefix(expr[i:], ">="):
+			tokens = append(tokens, expr[i:i+2])
+			i += 2
+		case strings.ContainsRune("()[],.=<>+-", rune(c)):
+			tokens = append(tokens, expr[i:i+1])
+			i++
+		default:
+			return nil, fmt.Errorf("Syntax error; token: %q", string(c))
+		}
+	}
+
+	return tokens, nil
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+type parser struct {
+	e      *expressions
+	kind   string
+	tokens []string
+	pos    int
+}
+
+func (p *parser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *parser) peekAt(n int) string {
+	if p.pos+n >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.pos+n]
+}
+
+func (p *parser) next() string {
+	t := p.peek()
+	if p.pos < len(p.tokens) {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the next token when it is t. Keywords are case insensitive
+func (p *parser) accept(t string) bool {
+	if strings.EqualFold(p.peek(), t) && p.peek() != "" {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(t string) error {
+	if !p.accept(t) {
+		return p.errorf("expected %s", t)
+	}
+	return nil
+}
+
+func (p *parser) end() error {
+	if p.pos != len(p.tokens) {
+		return p.errorf("unexpected token")
+	}
+	return nil
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	token := p.peek()
+	if token == "" {
+		token = "<EOF>"
+	}
+	return validationError("Invalid %s: Syntax error; %s; token: %q", p.kind, fmt.Sprintf(format, args...), token)
+}
+
+// value resolves expression attribute value placeholder
+func (p *parser) value() (types.AttributeValue, error) {
+	t := p.peek()
+	if !strings.HasPrefix(t, ":") {
+		return nil, p.errorf("expected expression attribute value")
+	}
+	p.next()
+
+	v, ok := p.e.values[t]
+	if !ok {
+		return nil, validationError("Invalid %s: An expression attribute value used in expression is not defined; attribute value: %s", p.kind, t)
+	}
+	p.e.usedValues[t] = true
+
+	return v, nil
+}
+
+// path parses document path. e.g. #0.#1[2]
+func (p *parser) path() (path, error) {
+	var ret path
+
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	ret = append(ret, pathElement{name: name})
+
+	for {
+		switch {
+		case p.accept("."):
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, pathElement{name: name})
+		case p.accept("["):
+			index, err := strconv.Atoi(p.next())
+			if err != nil || index < 0 {
+				return nil, p.errorf("invalid list index")
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			ret = append(ret, pathElement{index: index, isIndex: true})
+		default:
+			return ret, nil
+		}
+	}
+}
+
+func (p *parser) name() (string, error) {
+	t := p.peek()
+	switch {
+	case strings.HasPrefix(t, "#"):
+		p.next()
+		name, ok := p.e.names[t]
+		if !ok {
+			return "", validationError("Invalid %s: An expression attribute name used in the document path is not defined; attribute name: %s", p.kind, t)
+		}
+		p.e.usedNames[t] = true
+		return name, nil
+	case t != "" && isWordByte(t[0]) && (t[0] < '0' || t[0] > '9'):
+		p.next()
+		return t, nil
+	}
+
+	return "", p.errorf("expected attribute name")
+}
+
+// condition is parsed condition expression
+type condition interface {
+	eval(it item) (bool, error)
+}
+
+type andCondition struct{ left, right condition }
+
+func (c *andCondition) eval(it item) (bool, error) {
+	ok, err := c.left.eval(it)
+	if err != nil || !ok {
+		return false, err
+	}
+	return c.right.eval(it)
+}
+
+type orCondition struct{ left, right condition }
+
+func (c *orCondition) eval(it item) (bool, error) {
+	ok, err := c.left.eval(it)
+	if err != nil || ok {
+		return ok, err
+	}
+	return c.right.eval(it)
+}
+
+type notCondition struct{ cond condition }
+
+func (c *notCondition) eval(it item) (bool, error) {
+	ok, err := c.cond.eval(it)
+	return !ok, err
+}
+
+type compareCondition struct {
+	op          string
+	left, right operand
+}
+
+func (c *compareCondition) eval(it item) (bool, error) {
+	l := c.left.eval(it)
+	r := c.right.eval(it)
+	if l == nil || r == nil {
+		return c.op == "<>" && (l != nil || r != nil), nil
+	}
+
+	switch c.op {
+	case "=":
+		return equalValues(l, r), nil
+	case "<>":
+		return !equalValues(l, r), nil
+	}
+
+	cmp, ok := compareValues(l, r)
+	if !ok {
+		return false, nil
+	}
+	switch c.op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	case ">=":
+		return cmp >= 0, nil
+	}
+
+	return false, validationError("unsupported comparator: %s", c.op)
+}
+
+type betweenCondition struct{ value, low, high operand }
+
+func (c *betweenCondition) eval(it item) (bool, error) {
+	v := c.value.eval(it)
+	low := c.low.eval(it)
+	high := c.high.eval(it)
+	if v == nil || low == nil || high == nil {
+		return false, nil
+	}
+
+	if cmp, ok := compareValues(low, high); ok && cmp > 0 {
+		return false, validationError("Invalid BETWEEN: the lower bound is greater than the upper bound")
+	}
+
+	lcmp, lok := compareValues(low, v)
+	hcmp, hok := compareValues(v, high)
+	return lok && hok && lcmp <= 0 && hcmp <= 0, nil
+}
+
+type inCondition struct {
+	value operand
+	list  []operand
+}
+
+func (c *inCondition) eval(it item) (bool, error) {
+	v := c.value.eval(it)
+	if v == nil {
+		return false, nil
+	}
+	for _, o := range c.list {
+		if w := o.eval(it); w != nil && equalValues(v, w) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type functionCondition struct {
+	name string
+	path path
+	arg  operand
+}
+
+func (c *functionCondition) eval(it item) (bool, error) {
+	v := c.path.get(it)
+
+	switch c.name {
+	case "attribute_exists":
+		return v != nil, nil
+	case "attribute_not_exists":
+		return v == nil, nil
+	}
+
+	arg := c.arg.eval(it)
+	if v == nil || arg == nil {
+		return false, nil
+	}
+
+	switch c.name {
+	case "attribute_type":
+		t, ok := arg.(*types.AttributeValueMemberS)
+		if !ok {
+			return false, validationError("Invalid ConditionExpression: Incorrect operand type for operator or function; operator or function: attribute_type")
+		}
+		return attributeType(v) == t.Value, nil
+	case "begins_with":
+		switch x := v.(type) {
+		case *types.AttributeValueMemberS:
+			y, ok := arg.(*types.AttributeValueMemberS)
+			return ok && strings.HasPrefix(x.Value, y.Value), nil
+		case *types.AttributeValueMemberB:
+			y, ok := arg.(*types.AttributeValueMemberB)
+			return ok && strings.HasPrefix(string(x.Value), string(y.Value)), nil
+		}
+		return false, nil
+	case "contains":
+		switch x := v.(type) {
+		case *types.AttributeValueMemberS:
+			y, ok := arg.(*types.AttributeValueMemberS)
+			return ok && strings.Contains(x.Value, y.Value), nil
+		case *types.AttributeValueMemberB:
+			y, ok := arg.(*types.AttributeValueMemberB)
+			return ok && strings.Contains(string(x.Value), string(y.Value)), nil
+		case *types.AttributeValueMemberL:
+			return slices.ContainsFunc(x.Value, func(e types.AttributeValue) bool { return equalValues(e, arg) }), nil
+		}
+		// element of set matches only the scalar of the same type
+		var element types.AttributeValue
+		switch v.(type) {
+		case *types.AttributeValueMemberSS:
+			if y, ok := arg.(*types.AttributeValueMemberS); ok {
+				element = &types.AttributeValueMemberSS{Value: []string{y.Value}}
+			}
+		case *types.AttributeValueMemberNS:
+			if y, ok := arg.(*types.AttributeValueMemberN); ok {
+				element = &types.AttributeValueMemberNS{Value: []string{y.Value}}
+			}
+		case *types.AttributeValueMemberBS:
+			if y, ok := arg.(*types.AttributeValueMemberB); ok {
+				element = &types.AttributeValueMemberBS{Value: [][]byte{y.Value}}
+			}
+		}
+		if element != nil {
+			return slices.Contains(setElements(v), setElements(element)[0]), nil
+		}
+		return false, nil
+	}
+
+	return false, validationError("Invalid function name; function: %s", c.name)
+}
+
+// operand is operand of condition. eval returns nil when the attribute is missing
+type operand interface {
+	eval(it item) types.AttributeValue
+}
+
+type pathOperand struct{ path path }
+
+func (o *pathOperand) eval(it item) types.AttributeValue {
+	return o.path.get(it)
+}
+
+type valueOperand struct{ value types.AttributeValue }
+
+func (o *valueOperand) eval(it item) types.AttributeValue {
+	return o.value
+}
+
+type sizeOperand struct{ path path }
+
+func (o *sizeOperand) eval(it item) types.AttributeValue {
+	var size int
+	switch v := o.path.get(it).(type) {
+	case *types.AttributeValueMemberS:
+		size = utf8.RuneCountInString(v.Value)
+	case *types.AttributeValueMemberB:
+		size = len(v.Value)
+	case *types.AttributeValueMemberL:
+		size = len(v.Value)
+	case *types.AttributeValueMemberM:
+		size = len(v.Value)
+	case *types.AttributeValueMemberSS, *types.AttributeValueMemberNS, *types.AttributeValueMemberBS:
+		size = len(setElements(v))
+	default:
+		return nil
+	}
+	return &types.AttributeValueMemberN{Value: strconv.Itoa(size)}
+}
+
+var conditionFunctions = map[string]int{
+	"attribute_exists":     1,
+	"attribute_not_exists": 1,
+	"attribute_type":       2,
+	"begins_with":          2,
+	"contains":             2,
+}
+
+func (p *parser) or() (condition, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("OR") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = &orCondition{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) and() (condition, error) {
+	left, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("AND") {
+		right, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		left = &andCondition{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) not() (condition, error) {
+	if p.accept("NOT") {
+		cond, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return &notCondition{cond: cond}, nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (condition, error) {
+	if p.accept("(") {
+		cond, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		return cond, p.expect(")")
+	}
+
+	if args, ok := conditionFunctions[p.peek()]; ok && p.peekAt(1) == "(" {
+		name := p.next()
+		p.next()
+
+		pa, err := p.path()
+		if err != nil {
+			return nil, err
+		}
+		c := &functionCondition{name: name, path: pa}
+		if args == 2 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+			c.arg, err = p.operand()
+			if err != nil {
+				return nil, err
+			}
+		}
+		return c, p.expect(")")
+	}
+
+	left, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+
+	switch op := p.peek(); {
+	case op == "=" || op == "<>" || op == "<" || op == "<=" || op == ">" || op == ">=":
+		p.next()
+		right, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		return &compareCondition{op: op, left: left, right: right}, nil
+	case p.accept("BETWEEN"):
+		low, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("AND"); err != nil {
+			return nil, err
+		}
+		high, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		return &betweenCondition{value: left, low: low, high: high}, nil
+	case p.accept("IN"):
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		c := &inCondition{value: left}
+		for {
+			o, err := p.operand()
+			if err != nil {
+				return nil, err
+			}
+			c.list = append(c.list, o)
+			if !p.accept(",") {
+				break
+			}
+		}
+		return c, p.expect(")")
+	}
+
+	return nil, p.errorf("expected comparator")
+}
+
+func (p *parser) operand() (operand, error) {
+	if strings.HasPrefix(p.peek(), ":") {
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		return &valueOperand{value: v}, nil
+	}
+
+	if p.peek() == "size" && p.peekAt(1) == "(" {
+		p.next()
+		p.next()
+		pa, err := p.path()
+		if err != nil {
+			return nil, err
+		}
+		return &sizeOperand{path: pa}, p.expect(")")
+	}
+
+	pa, err := p.path()
+	if err != nil {
+		return nil, err
+	}
+	return &pathOperand{path: pa}, nil
+}
+
+// update is parsed update expression
+type update struct {
+	sets    []setAction
+	removes []path
+	adds    []addAction
+	deletes []addAction
+}
+
+type setAction struct {
+	path  path
+	value valueExpression
+}
+
+type addAction struct {
+	path  path
+	value types.AttributeValue
+}
+
+// paths returns all paths updated
+func (u *update) paths() []path {
+	var ret []path
+	for _, a := range u.sets {
+		ret = append(ret, a.path)
+	}
+	ret = append(ret, u.removes...)
+	for _, a := range u.adds {
+		ret = append(ret, a.path)
+	}
+	for _, a := range u.deletes {
+		ret = append(ret, a.path)
+	}
+	return ret
+}
+
+// apply applies update to it. Values are evaluated against the item before update
+func (u *update) apply(it item) (item, error) {
+	paths := u.paths()
+	for i, a := range paths {
+		for _, b := range paths[i+1:] {
+			if a.overlaps(b) {
+				return nil, validationError("Invalid UpdateExpression: Two document paths overlap with each other; must remove or rewrite one of these paths; path one: %s, path two: %s", a, b)
+			}
+		}
+	}
+
+	values := make([]types.AttributeValue, len(u.sets))
+	for i, a := range u.sets {
+		v, err := a.value.eval(it)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+
+	ret := copyItem(it)
+	for i, a := range u.sets {
+		if err := a.path.set(ret, copyValue(values[i])); err != nil {
+			return nil, err
+		}
+	}
+
+	// list elements are removed from the last so that indexes are not shifted
+	removes := slices.Clone(u.removes)
+	slices.SortFunc(removes, func(a, b path) int { return b.compareIndex(a) })
+	for _, pa := range removes {
+		pa.remove(ret)
+	}
+
+	for _, a := range u.adds {
+		current := a.path.get(ret)
+		v, err := addValue(current, a.value)
+		if err != nil {
+			return nil, err
+		}
+		if err := a.path.set(ret, v); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, a := range u.deletes {
+		current := a.path.get(ret)
+		if current == nil {
+			continue
+		}
+		v, err := deleteValue(current, a.value)
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			a.path.remove(ret)
+			continue
+		}
+		if err := a.path.set(ret, v); err != nil {
+			return nil, err
+		}
+	}
+
+	return ret, nil
+}
+
+// addValue returns result of ADD action: sum of numbers or union of sets
+func addValue(current, v types.AttributeValue) (types.AttributeValue, error) {
+	if current == nil {
+		switch v.(type) {
+		case *types.AttributeValueMemberN, *types.AttributeValueMemberSS, *types.AttributeValueMemberNS, *types.AttributeValueMemberBS:
+			return copyValue(v), nil
+		}
+		return nil, validationError("Invalid UpdateExpression: Incorrect operand type for operator or function; operator: ADD, operand type: %s", attributeType(v))
+	}
+
+	switch x := current.(type) {
+	case *types.AttributeValueMemberN:
+		y, ok := v.(*types.AttributeValueMemberN)
+		if !ok {
+			break
+		}
+		return arithmetic("+", x, y)
+	case *types.AttributeValueMemberSS:
+		y, ok := v.(*types.AttributeValueMemberSS)
+		if !ok {
+			break
+		}
+		ret := slices.Clone(x.Value)
+		for _, e := range y.Value {
+			if !slices.Contains(ret, e) {
+				ret = append(ret, e)
+			}
+		}
+		return &types.AttributeValueMemberSS{Value: ret}, nil
+	case *types.AttributeValueMemberNS:
+		y, ok := v.(*types.AttributeValueMemberNS)
+		if !ok {
+			break
+		}
+		ret := slices.Clone(x.Value)
+		for _, e := range y.Value {
+			if !slices.Contains(setElements(&types.AttributeValueMemberNS{Value: ret}), setElements(&types.AttributeValueMemberNS{Value: []string{e}})[0]) {
+				ret = append(ret, e)
+			}
+		}
+		return &types.AttributeValueMemberNS{Value: ret}, nil
+	case *types.AttributeValueMemberBS:
+		y, ok := v.(*types.AttributeValueMemberBS)
+		if !ok {
+			break
+		}
+		ret := copyValue(x).(*types.AttributeValueMemberBS)
+		for _, e := range y.Value {
+			if !slices.Contains(setElements(ret), string(e)) {
+				ret.Value = append(ret.Value, e)
+			}
+		}
+		return ret, nil
+	}
+
+	return nil, validationError("Invalid UpdateExpression: Incorrect operand type for operator or function; operator: ADD, operand type: %s", attributeType(current))
+}
+
+// deleteValue returns result of DELETE action: difference of sets. nil is returned when the set becomes empty
+func deleteValue(current, v types.AttributeValue) (types.AttributeValue, error) {
+	if attributeType(current) != attributeType(v) || !isSet(current) {
+		return nil, validationError("Invalid UpdateExpression: Incorrect operand type for operator or function; operator: DELETE, operand type: %s", attributeType(v))
+	}
+
+	remove := setElements(v)
+	var ret types.AttributeValue
+	switch x := current.(type) {
+	case *types.AttributeValueMemberSS:
+		s := &types.AttributeValueMemberSS{}
+		for _, e := range x.Value {
+			if !slices.Contains(remove, e) {
+				s.Value = append(s.Value, e)
+			}
+		}
+		ret = s
+	case *types.AttributeValueMemberNS:
+		s := &types.AttributeValueMemberNS{}
+		for _, e := range x.Value {
+			if !slices.Contains(remove, setElements(&types.AttributeValueMemberNS{Value: []string{e}})[0]) {
+				s.Value = append(s.Value, e)
+			}
+		}
+		ret = s
+	case *types.AttributeValueMemberBS:
+		s := &types.AttributeValueMemberBS{}
+		for _, e := range x.Value {
+			if !slices.Contains(remove, string(e)) {
+				s.Value = append(s.Value, e)
+			}
+		}
+		ret = s
+	}
+
+	if len(setElements(ret)) == 0 {
+		return nil, nil
+	}
+	return ret, nil
+}
+
+// arithmetic returns sum or difference of numbers
+func arithmetic(op string, x, y *types.AttributeValueMemberN) (types.AttributeValue, error) {
+	l, err := parseNumber(x.Value)
+	if err != nil {
+		return nil, err
+	}
+	r, err := parseNumber(y.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	if op == "-" {
+		r.Neg(r)
+	}
+	return &types.AttributeValueMemberN{Value: formatNumber(l.Add(l, r))}, nil
+}
+
+// valueExpression is right hand side of SET action
+type valueExpression interface {
+	eval(it item) (types.AttributeValue, error)
+}
+
+type operandExpression struct{ operand operand }
+
+func (e *operandExpression) eval(it item) (types.AttributeValue, error) {
+	v := e.operand.eval(it)
+	if v == nil {
+		return nil, validationError("The provided expression refers to an attribute that does not exist in the item")
+	}
+	return v, nil
+}
+
+type arithmeticExpression struct {
+	op          string
+	left, right valueExpression
+}
+
+func (e *arithmeticExpression) eval(it item) (types.AttributeValue, error) {
+	l, err := e.left.eval(it)
+	if err != nil {
+		return nil, err
+	}
+	r, err := e.right.eval(it)
+	if err != nil {
+		return nil, err
+	}
+
+	x, xok := l.(*types.AttributeValueMemberN)
+	y, yok := r.(*types.AttributeValueMemberN)
+	if !xok || !yok {
+		return nil, validationError("An operand in the update expression has an incorrect data type")
+	}
+	return arithmetic(e.op, x, y)
+}
+
+type ifNotExistsExpression struct {
+	path  path
+	value valueExpression
+}
+
+func (e *ifNotExistsExpression) eval(it item) (types.AttributeValue, error) {
+	if v := e.path.get(it); v != nil {
+		return v, nil
+	}
+	return e.value.eval(it)
+}
+
+type listAppendExpression struct{ left, right valueExpression }
+
+func (e *listAppendExpression) eval(it item) (types.AttributeValue, error) {
+	l, err := e.left.eval(it)
+	if err != nil {
+		return nil, err
+	}
+	r, err := e.right.eval(it)
+	if err != nil {
+		return nil, err
+	}
+
+	x, xok := l.(*types.AttributeValueMemberL)
+	y, yok := r.(*types.AttributeValueMemberL)
+	if !xok || !yok {
+		return nil, validationError("An operand in the update expression has an incorrect data type")
+	}
+	return &types.AttributeValueMemberL{Value: append(slices.Clone(x.Value), y.Value...)}, nil
+}
+
+func (p *parser) update() (*update, error) {
+	u := &update{}
+	seen := make(map[string]bool)
+
+	for p.peek() != "" {
+		clause := strings.ToUpper(p.next())
+		if seen[clause] {
+			return nil, p.errorf("The %s section can only be used once in an update expression", clause)
+		}
+		seen[clause] = true
+
+		for {
+			switch clause {
+			case "SET":
+				pa, err := p.path()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expect("="); err != nil {
+					return nil, err
+				}
+				v, err := p.valueExpression()
+				if err != nil {
+					return nil, err
+				}
+				u.sets = append(u.sets, setAction{path: pa, value: v})
+			case "REMOVE":
+				pa, err := p.path()
+				if err != nil {
+					return nil, err
+				}
+				u.removes = append(u.removes, pa)
+			case "ADD", "DELETE":
+				pa, err := p.path()
+				if err != nil {
+					return nil, err
+				}
+				v, err := p.value()
+				if err != nil {
+					return nil, err
+				}
+				if clause == "ADD" {
+					u.adds = append(u.adds, addAction{path: pa, value: v})
+				} else {
+					u.deletes = append(u.deletes, addAction{path: pa, value: v})
+				}
+			default:
+				p.pos--
+				return nil, p.errorf("expected SET, REMOVE, ADD or DELETE")
+			}
+
+			if !p.accept(",") {
+				break
+			}
+		}
+	}
+
+	if len(seen) == 0 {
+		return nil, validationError("Invalid UpdateExpression: The expression can not be empty")
+	}
+
+	return u, nil
+}
+
+func (p *parser) valueExpression() (valueExpression, error) {
+	left, err := p.setOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	if op := p.peek(); op == "+" || op == "-" {
+		p.next()
+		right, err := p.setOperand()
+		if err != nil {
+			return nil, err
+		}
+		return &arithmeticExpression{op: op, left: left, right: right}, nil
+	}
+
+	return left, nil
+}
+
+func (p *parser) setOperand() (valueExpression, error) {
+	if p.peekAt(1) == "(" {
+		switch p.peek() {
+		case "if_not_exists":
+			p.pos += 2
+			pa, err := p.path()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+			v, err := p.setOperand()
+			if err != nil {
+				return nil, err
+			}
+			return &ifNotExistsExpression{path: pa, value: v}, p.expect(")")
+		case "list_append":
+			p.pos += 2
+			left, err := p.setOperand()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+			right, err := p.setOperand()
+			if err != nil {
+				return nil, err
+			}
+			return &listAppendExpression{left: left, right: right}, p.expect(")")
+		}
+	}
+
+	o, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	return &operandExpression{operand: o}, nil
+}
+
+// path is document path of attribute
+type path []pathElement
+
+type pathElement struct {
+	name    string
+	index   int
+	isIndex bool
+}
+
+func (pa path) String() string {
+	var b strings.Builder
+	for i, e := range pa {
+		switch {
+		case e.isIndex:
+			fmt.Fprintf(&b, "[%d]", e.index)
+		case i > 0:
+			b.WriteString("." + e.name)
+		default:
+			b.WriteString(e.name)
+		}
+	}
+	return "[" + b.String() + "]"
+}
+
+// overlaps reports whether either path is prefix of the other
+func (pa path) overlaps(other path) bool {
+	n := min(len(pa), len(other))
+	return slices.Equal(pa[:n], other[:n])
+}
+
+// compareIndex orders paths of the same list by index
+func (pa path) compareIndex(other path) int {
+	if len(pa) == len(other) && len(pa) > 0 && slices.Equal(pa[:len(pa)-1], other[:len(other)-1]) {
+		return pa[len(pa)-1].index - other[len(other)-1].index
+	}
+	return 0
+}
+
+// get returns the attribute of path. nil is returned when it is missing
+func (pa path) get(it item) types.AttributeValue {
+	var current types.AttributeValue = &types.AttributeValueMemberM{Value: it}
+	for _, e := range pa {
+		switch v := current.(type) {
+		case *types.AttributeValueMemberM:
+			if e.isIndex {
+				return nil
+			}
+			current = v.Value[e.name]
+		case *types.AttributeValueMemberL:
+			if !e.isIndex || e.index >= len(v.Value) {
+				return nil
+			}
+			current = v.Value[e.index]
+		default:
+			return nil
+		}
+		if current == nil {
+			return nil
+		}
+	}
+	return current
+}
+
+// set sets the attribute of path. The parent of the path must exist. Index beyond the list appends the value
+func (pa path) set(it item, value types.AttributeValue) error {
+	parent := path.get(pa[:len(pa)-1], it)
+	if len(pa) == 1 {
+		it[pa[0].name] = value
+		return nil
+	}
+
+	last := pa[len(pa)-1]
+	switch v := parent.(type) {
+	case *types.AttributeValueMemberM:
+		if !last.isIndex {
+			v.Value[last.name] = value
+			return nil
+		}
+	case *types.AttributeValueMemberL:
+		if last.isIndex {
+			if last.index < len(v.Value) {
+				v.Value[last.index] = value
+			} else {
+				v.Value = append(v.Value, value)
+			}
+			return nil
+		}
+	}
+
+	return validationError("The document path provided in the update expression is invalid for update")
+}
+
+// remove removes the attribute of path. It does nothing when the attribute is missing
+func (pa path) remove(it item) {
+	if len(pa) == 1 {
+		delete(it, pa[0].name)
+		return
+	}
+
+	last := pa[len(pa)-1]
+	switch v := path.get(pa[:len(pa)-1], it).(type) {
+	case *types.AttributeValueMemberM:
+		if !last.isIndex {
+			delete(v.Value, last.name)
+		}
+	case *types.AttributeValueMemberL:
+		if last.isIndex && last.index < len(v.Value) {
+			v.Value = slices.Delete(v.Value, last.index, last.index+1)
+		}
+	}
+}
+
+// project returns attributes of paths of it. Elements of lists are packed in the order of paths
+func project(it item, paths []path) item {
+	if paths == nil {
+		return copyItem(it)
+	}
+
+	ret := make(item)
+	for _, pa := range paths {
+		v := pa.get(it)
+		if v == nil {
+			continue
+		}
+
+		var current types.AttributeValue = &types.AttributeValueMemberM{Value: ret}
+		for i, e := range pa {
+			last := i == len(pa)-1
+			var next types.AttributeValue
+			if last {
+				next = copyValue(v)
+			} else if pa[i+1].isIndex {
+				next = &types.AttributeValueMemberL{}
+			} else {
+				next = &types.AttributeValueMemberM{Value: make(item)}
+			}
+
+			switch c := current.(type) {
+			case *types.AttributeValueMemberM:
+				existing, ok := c.Value[e.name]
+				if !ok || last {
+					c.Value[e.name] = next
+					existing = next
+				}
+				current = existing
+			case *types.AttributeValueMemberL:
+				c.Value = append(c.Value, next)
+				current = next
+			}
+		}
+	}
+
+	return ret
+}
+
+// topLevelNames returns names of top level attributes of paths
+func topLevelNames(paths []path) map[string]bool {
+	ret := make(map[string]bool, len(paths))
+	for _, pa := range paths {
+		ret[pa[0].name] = true
+	}
+	return ret
+}
+
+// namesOf returns names of it in sorted order
+func namesOf(it item) []string {
+	return slices.Sorted(maps.Keys(it))
+}
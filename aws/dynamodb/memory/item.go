@@ -0,0 +1,905 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+)
+
+// itemKey returns string identifying item by the primary key
+func (t *table) itemKey(it item) string {
+	ret := keyString(it[t.keys.hashKey])
+	if t.keys.rangeKey != "" {
+		ret += "\x00" + keyString(it[t.keys.rangeKey])
+	}
+	return ret
+}
+
+// keyOf returns the key attributes of schemas in it
+func keyOf(it item, schemas ...keySchema) item {
+	ret := make(item)
+	for _, keys := range schemas {
+		for _, name := range []string{keys.hashKey, keys.rangeKey} {
+			if v, ok := it[name]; ok && name != "" {
+				ret[name] = copyValue(v)
+			}
+		}
+	}
+	return ret
+}
+
+// validateKey checks that key is exactly the primary key of the table
+func (t *table) validateKey(key item) error {
+	names := []string{t.keys.hashKey}
+	if t.keys.rangeKey != "" {
+		names = append(names, t.keys.rangeKey)
+	}
+
+	if len(key) != len(names) {
+		return validationError("The provided key element does not match the schema")
+	}
+	for _, name := range names {
+		v, ok := key[name]
+		if !ok || attributeType(v) != string(t.attributeType(name)) {
+			return validationError("The provided key element does not match the schema")
+		}
+		if isEmptyKey(v) {
+			return validationError("One or more parameter values are not valid. The AttributeValue for a key attribute cannot contain an empty string value. Key: %s", name)
+		}
+	}
+
+	return nil
+}
+
+// validateItem checks that it has the primary key, valid index keys and values, and does not exceed the maximum size
+func (t *table) validateItem(it item) error {
+	for _, name := range []string{t.keys.hashKey, t.keys.rangeKey} {
+		if name == "" {
+			continue
+		}
+		v, ok := it[name]
+		if !ok {
+			return validationError("One or more parameter values were invalid: Missing the key %s in the item", name)
+		}
+		if typ := t.attributeType(name); attributeType(v) != string(typ) {
+			return validationError("One or more parameter values were invalid: Type mismatch for key %s expected: %s actual: %s", name, typ, attributeType(v))
+		}
+		if isEmptyKey(v) {
+			return validationError("One or more parameter values are not valid. The AttributeValue for a key attribute cannot contain an empty string value. Key: %s", name)
+		}
+	}
+
+	if err := t.validateIndexKeys(it); err != nil {
+		return err
+	}
+
+	for _, v := range it {
+		if err := validateValue(v); err != nil {
+			return err
+		}
+	}
+
+	if itemSize(it) > maxItemSize {
+		return validationError("Item size has exceeded the maximum allowed size")
+	}
+
+	return nil
+}
+
+// validateIndexKeys checks types of index key attributes of it. Missing index keys are allowed, then the item is not in the index
+func (t *table) validateIndexKeys(it item) error {
+	for _, ix := range t.sortedIndexes() {
+		for _, name := range []string{ix.keys.hashKey, ix.keys.rangeKey} {
+			v, ok := it[name]
+			if name == "" || !ok {
+				continue
+			}
+			if typ := t.attributeType(name); attributeType(v) != string(typ) {
+				return validationError("One or more parameter values were invalid: Type mismatch for Index Key %s Expected: %s Actual: %s IndexName: %s", name, typ, attributeType(v), ix.name)
+			}
+			if isEmptyKey(v) {
+				return validationError("One or more parameter values are not valid. A value specified for a secondary index key is not supported. The AttributeValue for a key attribute cannot contain an empty string value. IndexName: %s, IndexKey: %s", ix.name, name)
+			}
+		}
+	}
+
+	return nil
+}
+
+func isEmptyKey(v types.AttributeValue) bool {
+	switch x := v.(type) {
+	case *types.AttributeValueMemberS:
+		return x.Value == ""
+	case *types.AttributeValueMemberB:
+		return len(x.Value) == 0
+	}
+	return false
+}
+
+// contains reports whether it has all the key attributes of the index
+func (ix *index) contains(it item) bool {
+	for _, name := range []string{ix.keys.hashKey, ix.keys.rangeKey} {
+		if _, ok := it[name]; name != "" && !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// expressionsOf returns expressions of request after validating the expression attribute values
+func expressionsOf(names map[string]string, values map[string]types.AttributeValue) (*expressions, error) {
+	for _, v := range values {
+		if err := validateValue(v); err != nil {
+			return nil, err
+		}
+	}
+	return newExpressions(names, values), nil
+}
+
+// write is write of single item by PutItem, UpdateItem, DeleteItem, BatchWriteItem and TransactWriteItems
+type write struct {
+	table *table
+	key   item
+	cond  condition
+	// apply returns the new item from the current item, which is nil when the item does not exist.
+	// nil deletes the item. The item is not written when apply is nil
+	apply func(current item) (item, error)
+	// returnOnFailure is true when ConditionalCheckFailedException has the current item
+	returnOnFailure bool
+}
+
+// evaluate checks the condition against the current item and returns the new item
+func (w *write) evaluate() (current, next item, err error) {
+	current = w.table.items[w.table.itemKey(w.key)]
+
+	if w.cond != nil {
+		ok, err := w.cond.eval(current)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok {
+			ccf := &types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")}
+			if w.returnOnFailure {
+				ccf.Item = copyItem(current)
+			}
+			return nil, nil, ccf
+		}
+	}
+
+	if w.apply == nil {
+		return current, current, nil
+	}
+
+	next, err = w.apply(current)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return current, next, nil
+}
+
+// commit writes the new item returned by evaluate
+func (w *write) commit(next item) {
+	if w.apply == nil {
+		return
+	}
+
+	key := w.table.itemKey(w.key)
+	w.table.record(w.table.items[key], next)
+	if next == nil {
+		delete(w.table.items, key)
+		return
+	}
+	w.table.items[key] = next
+}
+
+// run evaluates and commits the write
+func (w *write) run() (current, next item, err error) {
+	current, next, err = w.evaluate()
+	if err != nil {
+		return nil, nil, err
+	}
+	w.commit(next)
+
+	return current, next, nil
+}
+
+func (t *table) putWrite(it item, conditionExpression *string, names map[string]string, values map[string]types.AttributeValue, onFailure types.ReturnValuesOnConditionCheckFailure) (*write, error) {
+	if err := t.validateItem(it); err != nil {
+		return nil, err
+	}
+
+	w := &write{
+		table:           t,
+		key:             keyOf(it, t.keys),
+		returnOnFailure: onFailure == types.ReturnValuesOnConditionCheckFailureAllOld,
+	}
+
+	e, err := expressionsOf(names, values)
+	if err != nil {
+		return nil, err
+	}
+	w.cond, err = e.condition("ConditionExpression", aws.ToString(conditionExpression))
+	if err != nil {
+		return nil, err
+	}
+	if err := e.checkUnused(); err != nil {
+		return nil, err
+	}
+
+	stored := copyItem(it)
+	w.apply = func(item) (item, error) {
+		return copyItem(stored), nil
+	}
+
+	return w, nil
+}
+
+func (t *table) deleteWrite(key item, conditionExpression *string, names map[string]string, values map[string]types.AttributeValue, onFailure types.ReturnValuesOnConditionCheckFailure) (*write, error) {
+	w, err := t.checkWrite(key, conditionExpression, names, values, onFailure)
+	if err != nil {
+		return nil, err
+	}
+
+	w.apply = func(item) (item, error) {
+		return nil, nil
+	}
+
+	return w, nil
+}
+
+// checkWrite returns write only checking the condition. The condition is optional
+func (t *table) checkWrite(key item, conditionExpression *string, names map[string]string, values map[string]types.AttributeValue, onFailure types.ReturnValuesOnConditionCheckFailure) (*write, error) {
+	if err := t.validateKey(key); err != nil {
+		return nil, err
+	}
+
+	w := &write{
+		table:           t,
+		key:             copyItem(key),
+		returnOnFailure: onFailure == types.ReturnValuesOnConditionCheckFailureAllOld,
+	}
+
+	e, err := expressionsOf(names, values)
+	if err != nil {
+		return nil, err
+	}
+	w.cond, err = e.condition("ConditionExpression", aws.ToString(conditionExpression))
+	if err != nil {
+		return nil, err
+	}
+	if err := e.checkUnused(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// updateWrite returns write of update expression. It returns the updated paths, which are nil without update expression
+func (t *table) updateWrite(key item, updateExpression, conditionExpression *string, names map[string]string, values map[string]types.AttributeValue, onFailure types.ReturnValuesOnConditionCheckFailure) (*write, []path, error) {
+	if err := t.validateKey(key); err != nil {
+		return nil, nil, err
+	}
+
+	w := &write{
+		table:           t,
+		key:             copyItem(key),
+		returnOnFailure: onFailure == types.ReturnValuesOnConditionCheckFailureAllOld,
+	}
+
+	e, err := expressionsOf(names, values)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var u *update
+	if expr := aws.ToString(updateExpression); expr != "" {
+		u, err = e.update(expr)
+		if err != nil {
+			return nil, nil, err
+		}
+		for name := range topLevelNames(u.paths()) {
+			if name == t.keys.hashKey || name == t.keys.rangeKey {
+				return nil, nil, validationError("One or more parameter values were invalid: Cannot update attribute %s. This attribute is part of the key", name)
+			}
+		}
+	}
+
+	w.cond, err = e.condition("ConditionExpression", aws.ToString(conditionExpression))
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := e.checkUnused(); err != nil {
+		return nil, nil, err
+	}
+
+	w.apply = func(current item) (item, error) {
+		if current == nil {
+			current = w.key
+		}
+		if u == nil {
+			return copyItem(current), nil
+		}
+
+		next, err := u.apply(current)
+		if err != nil {
+			return nil, err
+		}
+		if err := t.validateItem(next); err != nil {
+			return nil, err
+		}
+		return next, nil
+	}
+
+	if u == nil {
+		return w, nil, nil
+	}
+	return w, u.paths(), nil
+}
+
+// writeUnits returns write capacity units of item of size
+func writeUnits(size int) float64 {
+	return max(1, math.Ceil(float64(size)/1024))
+}
+
+// readUnits returns read capacity units of items of size. Eventually consistent read consumes half
+func readUnits(size int, consistent bool) float64 {
+	units := max(1, math.Ceil(float64(size)/4096))
+	if !consistent {
+		units /= 2
+	}
+	return units
+}
+
+// consumedCapacity returns consumed capacity of the table to be returned by mode. nil is returned when it is not requested
+func consumedCapacity(t *table, read, write float64, mode types.ReturnConsumedCapacity) *types.ConsumedCapacity {
+	if mode == "" || mode == types.ReturnConsumedCapacityNone {
+		return nil
+	}
+
+	ret := &types.ConsumedCapacity{
+		TableName:     aws.String(t.name),
+		CapacityUnits: aws.Float64(read + write),
+	}
+	if read > 0 {
+		ret.ReadCapacityUnits = aws.Float64(read)
+	}
+	if write > 0 {
+		ret.WriteCapacityUnits = aws.Float64(write)
+	}
+	if mode == types.ReturnConsumedCapacityIndexes {
+		ret.Table = &types.Capacity{
+			CapacityUnits:      ret.CapacityUnits,
+			ReadCapacityUnits:  ret.ReadCapacityUnits,
+			WriteCapacityUnits: ret.WriteCapacityUnits,
+		}
+	}
+
+	return ret
+}
+
+// legacyParameter returns error when legacy parameter of DynamoDB is used, which is not supported
+func legacyParameter(name string, used bool) error {
+	if used {
+		return validationError("Legacy parameter %s is not supported by memory.Client. Use expressions instead", name)
+	}
+	return nil
+}
+
+// PutItem writes item
+func (c *Client) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	if err := errors.Join(legacyParameter("Expected", params.Expected != nil), legacyParameter("ConditionalOperator", params.ConditionalOperator != "")); err != nil {
+		return nil, err
+	}
+	switch params.ReturnValues {
+	case "", types.ReturnValueNone, types.ReturnValueAllOld:
+	default:
+		return nil, validationError("ReturnValues can only be ALL_OLD or NONE")
+	}
+
+	t, err := c.lock(ctx, params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	defer c.mu.Unlock()
+
+	w, err := t.putWrite(params.Item, params.ConditionExpression, params.ExpressionAttributeNames, params.ExpressionAttributeValues, params.ReturnValuesOnConditionCheckFailure)
+	if err != nil {
+		return nil, err
+	}
+
+	current, next, err := w.run()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &dynamodb.PutItemOutput{
+		ConsumedCapacity: consumedCapacity(t, 0, writeUnits(max(itemSize(current), itemSize(next))), params.ReturnConsumedCapacity),
+	}
+	if params.ReturnValues == types.ReturnValueAllOld {
+		ret.Attributes = copyItem(current)
+	}
+
+	return ret, nil
+}
+
+// GetItem reads item. Item of output is nil when the item does not exist
+func (c *Client) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	if err := legacyParameter("AttributesToGet", params.AttributesToGet != nil); err != nil {
+		return nil, err
+	}
+
+	t, err := c.lock(ctx, params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	defer c.mu.Unlock()
+
+	if err := t.validateKey(params.Key); err != nil {
+		return nil, err
+	}
+	paths, err := projectionOf(params.ProjectionExpression, params.ExpressionAttributeNames)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &dynamodb.GetItemOutput{}
+	current, ok := t.items[t.itemKey(params.Key)]
+	if ok {
+		ret.Item = project(current, paths)
+	}
+	ret.ConsumedCapacity = consumedCapacity(t, readUnits(itemSize(current), aws.ToBool(params.ConsistentRead)), 0, params.ReturnConsumedCapacity)
+
+	return ret, nil
+}
+
+// projectionOf parses projection expression, which uses only expression attribute names
+func projectionOf(projectionExpression *string, names map[string]string) ([]path, error) {
+	e := newExpressions(names, nil)
+	paths, err := e.projection(aws.ToString(projectionExpression))
+	if err != nil {
+		return nil, err
+	}
+	if err := e.checkUnused(); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// UpdateItem updates item by update expression. The item is created when it does not exist
+func (c *Client) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	if err := errors.Join(
+		legacyParameter("AttributeUpdates", params.AttributeUpdates != nil),
+		legacyParameter("Expected", params.Expected != nil),
+		legacyParameter("ConditionalOperator", params.ConditionalOperator != ""),
+	); err != nil {
+		return nil, err
+	}
+
+	t, err := c.lock(ctx, params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	defer c.mu.Unlock()
+
+	w, paths, err := t.updateWrite(params.Key, params.UpdateExpression, params.ConditionExpression, params.ExpressionAttributeNames, params.ExpressionAttributeValues, params.ReturnValuesOnConditionCheckFailure)
+	if err != nil {
+		return nil, err
+	}
+
+	current, next, err := w.run()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &dynamodb.UpdateItemOutput{
+		ConsumedCapacity: consumedCapacity(t, 0, writeUnits(max(itemSize(current), itemSize(next))), params.ReturnConsumedCapacity),
+	}
+	switch params.ReturnValues {
+	case "", types.ReturnValueNone:
+	case types.ReturnValueAllOld:
+		ret.Attributes = copyItem(current)
+	case types.ReturnValueAllNew:
+		ret.Attributes = copyItem(next)
+	case types.ReturnValueUpdatedOld:
+		if current != nil && paths != nil {
+			ret.Attributes = project(current, paths)
+		}
+	case types.ReturnValueUpdatedNew:
+		if paths != nil {
+			ret.Attributes = project(next, paths)
+		}
+	default:
+		return nil, validationError("1 validation error detected: Value '%s' at 'returnValues' failed to satisfy constraint: Member must satisfy enum value set: [ALL_NEW, UPDATED_OLD, ALL_OLD, NONE, UPDATED_NEW]", params.ReturnValues)
+	}
+
+	return ret, nil
+}
+
+// DeleteItem deletes item. It succeeds when the item does not exist
+func (c *Client) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	if err := errors.Join(legacyParameter("Expected", params.Expected != nil), legacyParameter("ConditionalOperator", params.ConditionalOperator != "")); err != nil {
+		return nil, err
+	}
+	switch params.ReturnValues {
+	case "", types.ReturnValueNone, types.ReturnValueAllOld:
+	default:
+		return nil, validationError("ReturnValues can only be ALL_OLD or NONE")
+	}
+
+	t, err := c.lock(ctx, params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	defer c.mu.Unlock()
+
+	w, err := t.deleteWrite(params.Key, params.ConditionExpression, params.ExpressionAttributeNames, params.ExpressionAttributeValues, params.ReturnValuesOnConditionCheckFailure)
+	if err != nil {
+		return nil, err
+	}
+
+	current, _, err := w.run()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &dynamodb.DeleteItemOutput{
+		ConsumedCapacity: consumedCapacity(t, 0, writeUnits(itemSize(current)), params.ReturnConsumedCapacity),
+	}
+	if params.ReturnValues == types.ReturnValueAllOld {
+		ret.Attributes = copyItem(current)
+	}
+
+	return ret, nil
+}
+
+// BatchGetItem reads up to 100 items of tables. All the items are processed, so UnprocessedKeys is always empty
+func (c *Client) BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	count := 0
+	for _, ka := range params.RequestItems {
+		if err := legacyParameter("AttributesToGet", ka.AttributesToGet != nil); err != nil {
+			return nil, err
+		}
+		count += len(ka.Keys)
+	}
+	if count == 0 {
+		return nil, validationError("1 validation error detected: Value at 'requestItems' failed to satisfy constraint: Member must have length greater than or equal to 1")
+	}
+	if count > 100 {
+		return nil, validationError("Too many items requested for the BatchGetItem call")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ret := &dynamodb.BatchGetItemOutput{
+		Responses:       make(map[string][]map[string]types.AttributeValue),
+		UnprocessedKeys: make(map[string]types.KeysAndAttributes),
+	}
+	for _, name := range slices.Sorted(maps.Keys(params.RequestItems)) {
+		ka := params.RequestItems[name]
+
+		t, err := c.table(aws.String(name))
+		if err != nil {
+			return nil, err
+		}
+
+		paths, err := projectionOf(ka.ProjectionExpression, ka.ExpressionAttributeNames)
+		if err != nil {
+			return nil, err
+		}
+
+		seen := make(map[string]bool, len(ka.Keys))
+		items := []map[string]types.AttributeValue{}
+		units := 0.0
+		for _, key := range ka.Keys {
+			if err := t.validateKey(key); err != nil {
+				return nil, err
+			}
+			k := t.itemKey(key)
+			if seen[k] {
+				return nil, validationError("Provided list of item keys contains duplicates")
+			}
+			seen[k] = true
+
+			current, ok := t.items[k]
+			units += readUnits(itemSize(current), aws.ToBool(ka.ConsistentRead))
+			if ok {
+				items = append(items, project(current, paths))
+			}
+		}
+		ret.Responses[name] = items
+
+		if cc := consumedCapacity(t, units, 0, params.ReturnConsumedCapacity); cc != nil {
+			ret.ConsumedCapacity = append(ret.ConsumedCapacity, *cc)
+		}
+	}
+
+	return ret, nil
+}
+
+// BatchWriteItem puts and deletes up to 25 items of tables without conditions. All the items are processed, so UnprocessedItems is always empty
+func (c *Client) BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	count := 0
+	for _, requests := range params.RequestItems {
+		count += len(requests)
+	}
+	if count == 0 {
+		return nil, validationError("1 validation error detected: Value at 'requestItems' failed to satisfy constraint: Member must have length greater than or equal to 1")
+	}
+	if count > 25 {
+		return nil, validationError("1 validation error detected: Value at 'requestItems' failed to satisfy constraint: Map value must satisfy constraint: [Member must have length less than or equal to 25, Member must have length greater than or equal to 1]")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// all the requests are validated before any item is written
+	var writes []*write
+	units := make(map[string]float64)
+	var names []string
+	for _, name := range slices.Sorted(maps.Keys(params.RequestItems)) {
+		t, err := c.table(aws.String(name))
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+
+		seen := make(map[string]bool, len(params.RequestItems[name]))
+		for _, r := range params.RequestItems[name] {
+			var w *write
+			switch {
+			case r.PutRequest != nil && r.DeleteRequest == nil:
+				w, err = t.putWrite(r.PutRequest.Item, nil, nil, nil, "")
+			case r.DeleteRequest != nil && r.PutRequest == nil:
+				w, err = t.deleteWrite(r.DeleteRequest.Key, nil, nil, nil, "")
+			default:
+				err = validationError("Supplied AttributeValue has more than one datatypes set, must contain exactly one of the supported datatypes")
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			k := t.itemKey(w.key)
+			if seen[k] {
+				return nil, validationError("Provided list of item keys contains duplicates")
+			}
+			seen[k] = true
+
+			writes = append(writes, w)
+		}
+	}
+
+	for _, w := range writes {
+		current, next, err := w.run()
+		if err != nil {
+			return nil, err
+		}
+		units[w.table.name] += writeUnits(max(itemSize(current), itemSize(next)))
+	}
+
+	ret := &dynamodb.BatchWriteItemOutput{UnprocessedItems: make(map[string][]types.WriteRequest)}
+	for _, name := range names {
+		if cc := consumedCapacity(c.tables[name], 0, units[name], params.ReturnConsumedCapacity); cc != nil {
+			ret.ConsumedCapacity = append(ret.ConsumedCapacity, *cc)
+		}
+	}
+
+	return ret, nil
+}
+
+// TransactWriteItems writes up to 100 items atomically. TransactionCanceledException with the cancellation reasons is returned
+// when some of the conditions fail. Requests with the same ClientRequestToken are idempotent for 10 minutes
+func (c *Client) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(params.TransactItems) == 0 || len(params.TransactItems) > 100 {
+		return nil, validationError("1 validation error detected: Value at 'transactItems' failed to satisfy constraint: Member must have length less than or equal to 100 and greater than or equal to 1")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	token := aws.ToString(params.ClientRequestToken)
+	for k, rt := range c.tokens {
+		if !now.Before(rt.expires) {
+			delete(c.tokens, k)
+		}
+	}
+	if rt, ok := c.tokens[token]; ok && token != "" {
+		if !reflect.DeepEqual(rt.input, params) {
+			return nil, &types.IdempotentParameterMismatchException{Message: aws.String("The request uses the same client token as a previous, but non-identical request.")}
+		}
+		return &dynamodb.TransactWriteItemsOutput{}, nil
+	}
+
+	writes := make([]*write, 0, len(params.TransactItems))
+	seen := make(map[string]bool, len(params.TransactItems))
+	for _, ti := range params.TransactItems {
+		w, err := c.transactWrite(ti)
+		if err != nil {
+			return nil, err
+		}
+
+		k := w.table.name + "\x00" + w.table.itemKey(w.key)
+		if seen[k] {
+			return nil, validationError("Transaction request cannot include multiple operations on one item")
+		}
+		seen[k] = true
+
+		writes = append(writes, w)
+	}
+
+	reasons := make([]types.CancellationReason, len(writes))
+	nexts := make([]item, len(writes))
+	units := make(map[string]float64)
+	var names []string
+	canceled := false
+	for i, w := range writes {
+		current, next, err := w.evaluate()
+		var ccf *types.ConditionalCheckFailedException
+		var apiErr smithy.APIError
+		switch {
+		case err == nil:
+			reasons[i] = types.CancellationReason{Code: aws.String("None")}
+		case errors.As(err, &ccf):
+			reasons[i] = types.CancellationReason{Code: aws.String("ConditionalCheckFailed"), Message: ccf.Message, Item: ccf.Item}
+			canceled = true
+		case errors.As(err, &apiErr) && apiErr.ErrorCode() == "ValidationException":
+			reasons[i] = types.CancellationReason{Code: aws.String("ValidationError"), Message: aws.String(apiErr.ErrorMessage())}
+			canceled = true
+		default:
+			return nil, err
+		}
+		nexts[i] = next
+
+		if _, ok := units[w.table.name]; !ok {
+			names = append(names, w.table.name)
+		}
+		units[w.table.name] += 2 * writeUnits(max(itemSize(current), itemSize(next)))
+	}
+
+	if canceled {
+		codes := make([]string, len(reasons))
+		for i, r := range reasons {
+			codes[i] = aws.ToString(r.Code)
+		}
+		return nil, &types.TransactionCanceledException{
+			Message:             aws.String("Transaction cancelled, please refer cancellation reasons for specific reasons [" + strings.Join(codes, ", ") + "]"),
+			CancellationReasons: reasons,
+		}
+	}
+
+	for i, w := range writes {
+		w.commit(nexts[i])
+	}
+	if token != "" {
+		c.tokens[token] = &requestToken{input: params, expires: now.Add(tokenLifetime)}
+	}
+
+	ret := &dynamodb.TransactWriteItemsOutput{}
+	for _, name := range names {
+		if cc := consumedCapacity(c.tables[name], 0, units[name], params.ReturnConsumedCapacity); cc != nil {
+			ret.ConsumedCapacity = append(ret.ConsumedCapacity, *cc)
+		}
+	}
+
+	return ret, nil
+}
+
+// transactWrite returns write of item of transaction. The client must be locked
+func (c *Client) transactWrite(ti types.TransactWriteItem) (*write, error) {
+	switch {
+	case ti.ConditionCheck != nil && ti.Put == nil && ti.Delete == nil && ti.Update == nil:
+		op := ti.ConditionCheck
+		t, err := c.table(op.TableName)
+		if err != nil {
+			return nil, err
+		}
+		if aws.ToString(op.ConditionExpression) == "" {
+			return nil, validationError("The expression can not be empty; ConditionCheck requires ConditionExpression")
+		}
+		return t.checkWrite(op.Key, op.ConditionExpression, op.ExpressionAttributeNames, op.ExpressionAttributeValues, op.ReturnValuesOnConditionCheckFailure)
+	case ti.Put != nil && ti.ConditionCheck == nil && ti.Delete == nil && ti.Update == nil:
+		op := ti.Put
+		t, err := c.table(op.TableName)
+		if err != nil {
+			return nil, err
+		}
+		return t.putWrite(op.Item, op.ConditionExpression, op.ExpressionAttributeNames, op.ExpressionAttributeValues, op.ReturnValuesOnConditionCheckFailure)
+	case ti.Delete != nil && ti.ConditionCheck == nil && ti.Put == nil && ti.Update == nil:
+		op := ti.Delete
+		t, err := c.table(op.TableName)
+		if err != nil {
+			return nil, err
+		}
+		return t.deleteWrite(op.Key, op.ConditionExpression, op.ExpressionAttributeNames, op.ExpressionAttributeValues, op.ReturnValuesOnConditionCheckFailure)
+	case ti.Update != nil && ti.ConditionCheck == nil && ti.Put == nil && ti.Delete == nil:
+		op := ti.Update
+		t, err := c.table(op.TableName)
+		if err != nil {
+			return nil, err
+		}
+		if aws.ToString(op.UpdateExpression) == "" {
+			return nil, validationError("1 validation error detected: Value null at 'transactItems.member.update.updateExpression' failed to satisfy constraint: Member must not be null")
+		}
+		w, _, err := t.updateWrite(op.Key, op.UpdateExpression, op.ConditionExpression, op.ExpressionAttributeNames, op.ExpressionAttributeValues, op.ReturnValuesOnConditionCheckFailure)
+		return w, err
+	}
+
+	return nil, validationError("TransactItems can only contain one of Check, Put, Update or Delete")
+}
+
+// TransactGetItems reads up to 100 items atomically. Item of response is nil when the item does not exist
+func (c *Client) TransactGetItems(ctx context.Context, params *dynamodb.TransactGetItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactGetItemsOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(params.TransactItems) == 0 || len(params.TransactItems) > 100 {
+		return nil, validationError("1 validation error detected: Value at 'transactItems' failed to satisfy constraint: Member must have length less than or equal to 100 and greater than or equal to 1")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ret := &dynamodb.TransactGetItemsOutput{Responses: make([]types.ItemResponse, 0, len(params.TransactItems))}
+	units := make(map[string]float64)
+	var names []string
+	for _, ti := range params.TransactItems {
+		op := ti.Get
+		if op == nil {
+			return nil, validationError("1 validation error detected: Value null at 'transactItems.member.get' failed to satisfy constraint: Member must not be null")
+		}
+
+		t, err := c.table(op.TableName)
+		if err != nil {
+			return nil, err
+		}
+		if err := t.validateKey(op.Key); err != nil {
+			return nil, err
+		}
+		paths, err := projectionOf(op.ProjectionExpression, op.ExpressionAttributeNames)
+		if err != nil {
+			return nil, err
+		}
+
+		var r types.ItemResponse
+		current, ok := t.items[t.itemKey(op.Key)]
+		if ok {
+			r.Item = project(current, paths)
+		}
+		ret.Responses = append(ret.Responses, r)
+
+		if _, ok := units[t.name]; !ok {
+			names = append(names, t.name)
+		}
+		units[t.name] += 2 * readUnits(itemSize(current), true)
+	}
+
+	for _, name := range names {
+		if cc := consumedCapacity(c.tables[name], units[name], 0, params.ReturnConsumedCapacity); cc != nil {
+			ret.ConsumedCapacity = append(ret.ConsumedCapacity, *cc)
+		}
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,697 @@
+// Package memory is in-memory implementation of DynamoDB for unit tests.
+// Client keeps tables in memory and honors key schemas, secondary indexes, condition, update and projection expressions,
+// query ordering by sort key, pagination limits, transactions and TTL, so tests of services run without containers.
+//
+//	client, err := memory.New()
+//	if err != nil {
+//		return err
+//	}
+//	d := dynamodb.NewFromClient(client)
+//
+// Tables become ACTIVE immediately and expired items are deleted as soon as the TTL passes.
+// Client also serves DynamoDB Streams of the tables by DescribeStream, GetShardIterator and GetRecords,
+// so it can be set to Streams of dynamodb.DynamoDB. PartiQL is not supported
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+)
+
+const (
+	// maxItemSize is the maximum size of item in bytes
+	maxItemSize = 400 * 1024
+	// maxPageSize is the maximum size of items read by Query and Scan in bytes
+	maxPageSize = 1024 * 1024
+	// tokenLifetime is how long ClientRequestToken of TransactWriteItems is remembered
+	tokenLifetime = 10 * time.Minute
+)
+
+// validationError returns ValidationException error like DynamoDB
+func validationError(format string, args ...any) error {
+	return &smithy.GenericAPIError{Code: "ValidationException", Message: fmt.Sprintf(format, args...)}
+}
+
+type config struct {
+	now func() time.Time
+}
+
+func newConfig() *config {
+	return &config{
+		now: time.Now,
+	}
+}
+
+func createConfig(opts ...Option) (*config, error) {
+	c := newConfig()
+
+	for _, opt := range opts {
+		err := opt(c)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// Option is functional option pattern option for Client
+type Option func(*config) error
+
+// OptionNow returns Option instance with the clock of Client. It decides expiration of TTL and timestamps of tables. time.Now by default
+func OptionNow(now func() time.Time) func(c *config) error {
+	return func(c *config) error {
+		if now == nil {
+			return fmt.Errorf("now is nil")
+		}
+		c.now = now
+		return nil
+	}
+}
+
+// Client is in-memory DynamoDB. It is safe for concurrent use
+type Client struct {
+	now func() time.Time
+
+	mu     sync.Mutex
+	tables map[string]*table
+	tokens map[string]*requestToken
+}
+
+type requestToken struct {
+	input   *dynamodb.TransactWriteItemsInput
+	expires time.Time
+}
+
+// New returns Client instance without tables
+func New(opts ...Option) (*Client, error) {
+	c, err := createConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		now:    c.now,
+		tables: make(map[string]*table),
+		tokens: make(map[string]*requestToken),
+	}, nil
+}
+
+type table struct {
+	name                 string
+	created              time.Time
+	keySchema            []types.KeySchemaElement
+	attributeDefinitions []types.AttributeDefinition
+	billingMode          types.BillingMode
+	throughput           *types.ProvisionedThroughput
+	stream               *types.StreamSpecification
+	deletionProtection   bool
+	// ttl is the attribute of expiration time. empty when TTL is disabled
+	ttl string
+	// changes is the stream recording writes. nil when the stream is disabled
+	changes *stream
+
+	keys    keySchema
+	indexes map[string]*index
+	// items is items by itemKey of the primary key
+	items map[string]item
+}
+
+type keySchema struct {
+	hashKey  string
+	rangeKey string
+}
+
+type index struct {
+	name       string
+	global     bool
+	keySchema  []types.KeySchemaElement
+	keys       keySchema
+	projection *types.Projection
+	throughput *types.ProvisionedThroughput
+}
+
+// lock locks the client and returns table of name after deleting expired items.
+// The client is unlocked on error
+func (c *Client) lock(ctx context.Context, name *string) (*table, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+
+	t, err := c.table(name)
+	if err != nil {
+		c.mu.Unlock()
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// table returns table of name after deleting expired items. The client must be locked
+func (c *Client) table(name *string) (*table, error) {
+	if aws.ToString(name) == "" {
+		return nil, validationError("1 validation error detected: Value null at 'tableName' failed to satisfy constraint: Member must not be null")
+	}
+
+	t, ok := c.tables[*name]
+	if !ok {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("Requested resource not found: Table: " + *name + " not found")}
+	}
+
+	t.purge(c.now())
+
+	return t, nil
+}
+
+// purge deletes items expired by TTL
+func (t *table) purge(now time.Time) {
+	if t.ttl == "" {
+		return
+	}
+
+	for key, it := range t.items {
+		n, ok := it[t.ttl].(*types.AttributeValueMemberN)
+		if !ok {
+			continue
+		}
+		r, err := parseNumber(n.Value)
+		if err != nil {
+			continue
+		}
+		if f, _ := r.Float64(); f <= float64(now.Unix()) {
+			delete(t.items, key)
+			t.record(it, nil)
+		}
+	}
+}
+
+// CreateTable creates table. The table is ACTIVE immediately
+func (c *Client) CreateTable(ctx context.Context, params *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	name := aws.ToString(params.TableName)
+	if name == "" {
+		return nil, validationError("1 validation error detected: Value null at 'tableName' failed to satisfy constraint: Member must not be null")
+	}
+
+	t := &table{
+		name:                 name,
+		created:              c.now(),
+		keySchema:            slices.Clone(params.KeySchema),
+		attributeDefinitions: slices.Clone(params.AttributeDefinitions),
+		billingMode:          params.BillingMode,
+		stream:               params.StreamSpecification,
+		deletionProtection:   aws.ToBool(params.DeletionProtectionEnabled),
+		indexes:              make(map[string]*index),
+		items:                make(map[string]item),
+	}
+	if t.billingMode == "" {
+		t.billingMode = types.BillingModeProvisioned
+	}
+
+	var err error
+	t.keys, err = parseKeySchema(params.KeySchema)
+	if err != nil {
+		return nil, err
+	}
+	err = t.enableStream(params.StreamSpecification, c.now)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t.billingMode {
+	case types.BillingModeProvisioned:
+		if params.ProvisionedThroughput == nil {
+			return nil, validationError("One or more parameter values were invalid: ReadCapacityUnits and WriteCapacityUnits must both be specified when BillingMode is PROVISIONED")
+		}
+		t.throughput = params.ProvisionedThroughput
+	case types.BillingModePayPerRequest:
+		if params.ProvisionedThroughput != nil {
+			return nil, validationError("One or more parameter values were invalid: Neither ReadCapacityUnits nor WriteCapacityUnits can be specified when BillingMode is PAY_PER_REQUEST")
+		}
+	default:
+		return nil, validationError("1 validation error detected: Value '%s' at 'billingMode' failed to satisfy constraint: Member must satisfy enum value set: [PROVISIONED, PAY_PER_REQUEST]", t.billingMode)
+	}
+
+	for _, gsi := range params.GlobalSecondaryIndexes {
+		err := t.addIndex(&index{
+			name:       aws.ToString(gsi.IndexName),
+			global:     true,
+			keySchema:  gsi.KeySchema,
+			projection: gsi.Projection,
+			throughput: gsi.ProvisionedThroughput,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, lsi := range params.LocalSecondaryIndexes {
+		err := t.addIndex(&index{
+			name:       aws.ToString(lsi.IndexName),
+			keySchema:  lsi.KeySchema,
+			projection: lsi.Projection,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = t.validateAttributeDefinitions()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.tables[name]; ok {
+		return nil, &types.ResourceInUseException{Message: aws.String("Table already exists: " + name)}
+	}
+	c.tables[name] = t
+
+	return &dynamodb.CreateTableOutput{TableDescription: t.describe()}, nil
+}
+
+// parseKeySchema returns key attribute names of key schema. The hash key is required and the range key is optional
+func parseKeySchema(schema []types.KeySchemaElement) (keySchema, error) {
+	var ret keySchema
+	for i, e := range schema {
+		name := aws.ToString(e.AttributeName)
+		switch {
+		case name == "":
+			return ret, validationError("1 validation error detected: Value null at 'keySchema.%d.member.attributeName' failed to satisfy constraint: Member must not be null", i+1)
+		case e.KeyType == types.KeyTypeHash && i == 0:
+			ret.hashKey = name
+		case e.KeyType == types.KeyTypeRange && i == 1:
+			ret.rangeKey = name
+		default:
+			return ret, validationError("Invalid KeySchema: The first KeySchemaElement is not a HASH key type")
+		}
+	}
+	if ret.hashKey == "" || len(schema) > 2 {
+		return ret, validationError("1 validation error detected: Value at 'keySchema' failed to satisfy constraint: Member must have length less than or equal to 2 and greater than or equal to 1")
+	}
+	if ret.hashKey == ret.rangeKey {
+		return ret, validationError("Both the Hash Key and the Range Key element in the KeySchema have the same name")
+	}
+
+	return ret, nil
+}
+
+// addIndex adds secondary index to the table. Attribute definitions are validated separately
+func (t *table) addIndex(ix *index) error {
+	if ix.name == "" {
+		return validationError("1 validation error detected: Value null at 'indexName' failed to satisfy constraint: Member must not be null")
+	}
+	if _, ok := t.indexes[ix.name]; ok {
+		return validationError("One or more parameter values were invalid: Duplicate index name: %s", ix.name)
+	}
+
+	var err error
+	ix.keys, err = parseKeySchema(ix.keySchema)
+	if err != nil {
+		return err
+	}
+	if !ix.global && (ix.keys.hashKey != t.keys.hashKey || ix.keys.rangeKey == "" || t.keys.rangeKey == "") {
+		return validationError("One or more parameter values were invalid: Index KeySchema does not have a range key for index: %s, or the hash key is not the same as the table", ix.name)
+	}
+
+	if ix.projection == nil || ix.projection.ProjectionType == "" {
+		return validationError("One or more parameter values were invalid: Unknown ProjectionType: null")
+	}
+	if ix.global && t.billingMode == types.BillingModeProvisioned && ix.throughput == nil {
+		return validationError("One or more parameter values were invalid: ProvisionedThroughput must be specified for index: %s", ix.name)
+	}
+
+	t.indexes[ix.name] = ix
+
+	return nil
+}
+
+// validateAttributeDefinitions checks that attribute definitions are exactly key attributes of the table and the indexes
+func (t *table) validateAttributeDefinitions() error {
+	defined := make(map[string]types.ScalarAttributeType, len(t.attributeDefinitions))
+	for _, def := range t.attributeDefinitions {
+		name := aws.ToString(def.AttributeName)
+		if _, ok := defined[name]; ok {
+			return validationError("Cannot have two attributes with the same name: %s", name)
+		}
+		switch def.AttributeType {
+		case types.ScalarAttributeTypeS, types.ScalarAttributeTypeN, types.ScalarAttributeTypeB:
+		default:
+			return validationError("1 validation error detected: Value '%s' at 'attributeDefinitions.member.attributeType' failed to satisfy constraint: Member must satisfy enum value set: [B, N, S]", def.AttributeType)
+		}
+		defined[name] = def.AttributeType
+	}
+
+	used := make(map[string]bool)
+	for _, keys := range t.allKeys() {
+		for _, name := range []string{keys.hashKey, keys.rangeKey} {
+			if name == "" {
+				continue
+			}
+			if _, ok := defined[name]; !ok {
+				return validationError("One or more parameter values were invalid: Some index key attributes are not defined in AttributeDefinitions. Keys: [%s], AttributeDefinitions: [%s]", name, strings.Join(slices.Sorted(maps.Keys(defined)), ", "))
+			}
+			used[name] = true
+		}
+	}
+	if len(used) != len(defined) {
+		return validationError("One or more parameter values were invalid: Number of attributes in KeySchema does not exactly match number of attributes defined in AttributeDefinitions")
+	}
+
+	return nil
+}
+
+// allKeys returns key schema of the table and the indexes
+func (t *table) allKeys() []keySchema {
+	ret := []keySchema{t.keys}
+	for _, ix := range t.sortedIndexes() {
+		ret = append(ret, ix.keys)
+	}
+	return ret
+}
+
+func (t *table) sortedIndexes() []*index {
+	ret := make([]*index, 0, len(t.indexes))
+	for _, ix := range t.indexes {
+		ret = append(ret, ix)
+	}
+	slices.SortFunc(ret, func(a, b *index) int { return cmp.Compare(a.name, b.name) })
+	return ret
+}
+
+// attributeType returns the type of key attribute defined by attribute definitions
+func (t *table) attributeType(name string) types.ScalarAttributeType {
+	for _, def := range t.attributeDefinitions {
+		if aws.ToString(def.AttributeName) == name {
+			return def.AttributeType
+		}
+	}
+	return ""
+}
+
+func (t *table) arn() string {
+	return "arn:aws:dynamodb:memory:000000000000:table/" + t.name
+}
+
+// describe returns description of the table. All the indexes are ACTIVE
+func (t *table) describe() *types.TableDescription {
+	size := 0
+	for _, it := range t.items {
+		size += itemSize(it)
+	}
+
+	ret := &types.TableDescription{
+		TableName:                 aws.String(t.name),
+		TableArn:                  aws.String(t.arn()),
+		TableStatus:               types.TableStatusActive,
+		CreationDateTime:          aws.Time(t.created),
+		KeySchema:                 slices.Clone(t.keySchema),
+		AttributeDefinitions:      slices.Clone(t.attributeDefinitions),
+		BillingModeSummary:        &types.BillingModeSummary{BillingMode: t.billingMode},
+		ProvisionedThroughput:     throughputDescription(t.throughput),
+		ItemCount:                 aws.Int64(int64(len(t.items))),
+		TableSizeBytes:            aws.Int64(int64(size)),
+		StreamSpecification:       t.stream,
+		DeletionProtectionEnabled: aws.Bool(t.deletionProtection),
+	}
+	if t.changes != nil {
+		ret.LatestStreamArn = aws.String(t.changes.arn)
+		ret.LatestStreamLabel = aws.String(t.changes.label)
+	}
+
+	for _, ix := range t.sortedIndexes() {
+		count := int64(0)
+		for _, it := range t.items {
+			if ix.contains(it) {
+				count++
+			}
+		}
+
+		if !ix.global {
+			ret.LocalSecondaryIndexes = append(ret.LocalSecondaryIndexes, types.LocalSecondaryIndexDescription{
+				IndexName:  aws.String(ix.name),
+				IndexArn:   aws.String(t.arn() + "/index/" + ix.name),
+				KeySchema:  slices.Clone(ix.keySchema),
+				Projection: ix.projection,
+				ItemCount:  aws.Int64(count),
+			})
+			continue
+		}
+		ret.GlobalSecondaryIndexes = append(ret.GlobalSecondaryIndexes, types.GlobalSecondaryIndexDescription{
+			IndexName:             aws.String(ix.name),
+			IndexArn:              aws.String(t.arn() + "/index/" + ix.name),
+			IndexStatus:           types.IndexStatusActive,
+			Backfilling:           aws.Bool(false),
+			KeySchema:             slices.Clone(ix.keySchema),
+			Projection:            ix.projection,
+			ProvisionedThroughput: throughputDescription(ix.throughput),
+			ItemCount:             aws.Int64(count),
+		})
+	}
+
+	return ret
+}
+
+func throughputDescription(pt *types.ProvisionedThroughput) *types.ProvisionedThroughputDescription {
+	ret := &types.ProvisionedThroughputDescription{
+		ReadCapacityUnits:  aws.Int64(0),
+		WriteCapacityUnits: aws.Int64(0),
+	}
+	if pt != nil {
+		ret.ReadCapacityUnits = aws.Int64(aws.ToInt64(pt.ReadCapacityUnits))
+		ret.WriteCapacityUnits = aws.Int64(aws.ToInt64(pt.WriteCapacityUnits))
+	}
+	return ret
+}
+
+// DescribeTable returns description of table
+func (c *Client) DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	t, err := c.lock(ctx, params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	defer c.mu.Unlock()
+
+	return &dynamodb.DescribeTableOutput{Table: t.describe()}, nil
+}
+
+// UpdateTable updates billing mode, throughput, stream specification, deletion protection and global secondary indexes of table.
+// New global secondary index is backfilled immediately
+func (c *Client) UpdateTable(ctx context.Context, params *dynamodb.UpdateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateTableOutput, error) {
+	t, err := c.lock(ctx, params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	defer c.mu.Unlock()
+
+	// the table is replaced by the updated copy only when all the updates are valid
+	u := *t
+	u.indexes = maps.Clone(t.indexes)
+	u.attributeDefinitions = slices.Clone(t.attributeDefinitions)
+
+	for _, def := range params.AttributeDefinitions {
+		name := aws.ToString(def.AttributeName)
+		if typ := u.attributeType(name); typ != "" {
+			if typ != def.AttributeType {
+				return nil, validationError("One or more parameter values were invalid: Attribute %s is already defined with type %s", name, typ)
+			}
+			continue
+		}
+		u.attributeDefinitions = append(u.attributeDefinitions, def)
+	}
+
+	if params.BillingMode != "" {
+		u.billingMode = params.BillingMode
+		if u.billingMode == types.BillingModePayPerRequest {
+			u.throughput = nil
+		}
+	}
+	if params.ProvisionedThroughput != nil {
+		if u.billingMode != types.BillingModeProvisioned {
+			return nil, validationError("One or more parameter values were invalid: Neither ReadCapacityUnits nor WriteCapacityUnits can be specified when BillingMode is PAY_PER_REQUEST")
+		}
+		u.throughput = params.ProvisionedThroughput
+	}
+	if u.billingMode == types.BillingModeProvisioned && u.throughput == nil {
+		return nil, validationError("One or more parameter values were invalid: ProvisionedThroughput must be specified when BillingMode is PROVISIONED")
+	}
+	if params.StreamSpecification != nil {
+		err := u.enableStream(params.StreamSpecification, c.now)
+		if err != nil {
+			return nil, err
+		}
+		u.stream = params.StreamSpecification
+	}
+	if params.DeletionProtectionEnabled != nil {
+		u.deletionProtection = *params.DeletionProtectionEnabled
+	}
+
+	for _, update := range params.GlobalSecondaryIndexUpdates {
+		switch {
+		case update.Create != nil:
+			err := u.addIndex(&index{
+				name:       aws.ToString(update.Create.IndexName),
+				global:     true,
+				keySchema:  update.Create.KeySchema,
+				projection: update.Create.Projection,
+				throughput: update.Create.ProvisionedThroughput,
+			})
+			if err != nil {
+				return nil, err
+			}
+		case update.Delete != nil:
+			name := aws.ToString(update.Delete.IndexName)
+			if ix, ok := u.indexes[name]; !ok || !ix.global {
+				return nil, &types.ResourceNotFoundException{Message: aws.String("Requested resource not found: Index: " + name + " not found")}
+			}
+			delete(u.indexes, name)
+		case update.Update != nil:
+			name := aws.ToString(update.Update.IndexName)
+			ix, ok := u.indexes[name]
+			if !ok || !ix.global {
+				return nil, &types.ResourceNotFoundException{Message: aws.String("Requested resource not found: Index: " + name + " not found")}
+			}
+			updated := *ix
+			updated.throughput = update.Update.ProvisionedThroughput
+			u.indexes[name] = &updated
+		}
+	}
+
+	// attributes only used by deleted indexes remain defined like DynamoDB
+	for _, keys := range u.allKeys() {
+		for _, name := range []string{keys.hashKey, keys.rangeKey} {
+			if name != "" && u.attributeType(name) == "" {
+				return nil, validationError("One or more parameter values were invalid: Some index key attributes are not defined in AttributeDefinitions. Keys: [%s]", name)
+			}
+		}
+	}
+	// DynamoDB reports items violating types of new index keys in background, which is not supported
+	for _, it := range u.items {
+		if err := u.validateIndexKeys(it); err != nil {
+			return nil, err
+		}
+	}
+
+	*t = u
+
+	return &dynamodb.UpdateTableOutput{TableDescription: t.describe()}, nil
+}
+
+// DeleteTable deletes table and the items. The table is gone immediately
+func (c *Client) DeleteTable(ctx context.Context, params *dynamodb.DeleteTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteTableOutput, error) {
+	t, err := c.lock(ctx, params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	defer c.mu.Unlock()
+
+	if t.deletionProtection {
+		return nil, validationError("Resource cannot be deleted as it is currently protected against deletion. Disable deletion protection first.")
+	}
+
+	desc := t.describe()
+	desc.TableStatus = types.TableStatusDeleting
+	delete(c.tables, t.name)
+
+	return &dynamodb.DeleteTableOutput{TableDescription: desc}, nil
+}
+
+// ListTables returns names of tables in alphabetical order
+func (c *Client) ListTables(ctx context.Context, params *dynamodb.ListTablesInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ListTablesOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	limit := int(aws.ToInt32(params.Limit))
+	if params.Limit != nil && (limit < 1 || limit > 100) {
+		return nil, validationError("1 validation error detected: Value '%d' at 'limit' failed to satisfy constraint: Member must have value between 1 and 100", limit)
+	}
+	if limit == 0 {
+		limit = 100
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	names := slices.Sorted(maps.Keys(c.tables))
+	start := aws.ToString(params.ExclusiveStartTableName)
+
+	ret := &dynamodb.ListTablesOutput{TableNames: []string{}}
+	for _, name := range names {
+		if start != "" && name <= start {
+			continue
+		}
+		if len(ret.TableNames) == limit {
+			ret.LastEvaluatedTableName = aws.String(ret.TableNames[limit-1])
+			break
+		}
+		ret.TableNames = append(ret.TableNames, name)
+	}
+
+	return ret, nil
+}
+
+// DescribeTimeToLive returns TTL setting of table
+func (c *Client) DescribeTimeToLive(ctx context.Context, params *dynamodb.DescribeTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTimeToLiveOutput, error) {
+	t, err := c.lock(ctx, params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	defer c.mu.Unlock()
+
+	desc := &types.TimeToLiveDescription{TimeToLiveStatus: types.TimeToLiveStatusDisabled}
+	if t.ttl != "" {
+		desc.TimeToLiveStatus = types.TimeToLiveStatusEnabled
+		desc.AttributeName = aws.String(t.ttl)
+	}
+
+	return &dynamodb.DescribeTimeToLiveOutput{TimeToLiveDescription: desc}, nil
+}
+
+// UpdateTimeToLive enables or disables TTL of table. Expired items are deleted immediately
+func (c *Client) UpdateTimeToLive(ctx context.Context, params *dynamodb.UpdateTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateTimeToLiveOutput, error) {
+	t, err := c.lock(ctx, params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	defer c.mu.Unlock()
+
+	spec := params.TimeToLiveSpecification
+	if spec == nil || aws.ToString(spec.AttributeName) == "" || spec.Enabled == nil {
+		return nil, validationError("1 validation error detected: Value null at 'timeToLiveSpecification' failed to satisfy constraint: Member must not be null")
+	}
+
+	switch {
+	case *spec.Enabled && t.ttl != "":
+		return nil, validationError("TimeToLive is already enabled")
+	case !*spec.Enabled && t.ttl == "":
+		return nil, validationError("TimeToLive is already disabled")
+	case !*spec.Enabled && t.ttl != *spec.AttributeName:
+		return nil, validationError("TimeToLive is active on a different AttributeName: current AttributeName is %s", t.ttl)
+	}
+
+	t.ttl = ""
+	if *spec.Enabled {
+		t.ttl = *spec.AttributeName
+		t.purge(c.now())
+	}
+
+	return &dynamodb.UpdateTimeToLiveOutput{TimeToLiveSpecification: spec}, nil
+}
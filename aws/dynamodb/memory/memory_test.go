@@ -0,0 +1,468 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	awsdynamodb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams"
+	streamtypes "github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+	"github.com/aws/smithy-go"
+	"github.com/rssh-jp/data-access-library/aws/dynamodb"
+	"github.com/rssh-jp/data-access-library/aws/dynamodb/memory"
+)
+
+type order struct {
+	UserID  string `dynamodbav:"user_id"`
+	OrderID int    `dynamodbav:"order_id"`
+	Status  string `dynamodbav:"status,omitempty"`
+}
+
+func newDynamoDB(t *testing.T, opts ...memory.Option) (*dynamodb.DynamoDB, *memory.Client) {
+	t.Helper()
+
+	client, err := memory.New(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dynamodb.NewFromClient(client), client
+}
+
+func createOrders(t *testing.T, d *dynamodb.DynamoDB) {
+	t.Helper()
+
+	err := d.CreateTableFromDefinition(&dynamodb.TableDefinition{
+		Name:         "orders",
+		PartitionKey: dynamodb.KeyAttribute{Name: "user_id", Type: dynamodb.AttributeTypeString},
+		SortKey:      &dynamodb.KeyAttribute{Name: "order_id", Type: dynamodb.AttributeTypeNumber},
+		GlobalIndexes: []*dynamodb.IndexDefinition{
+			{Name: "status", PartitionKey: dynamodb.KeyAttribute{Name: "status", Type: dynamodb.AttributeTypeString}, ProjectionType: dynamodb.ProjectionKeysOnly},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.DefaultTableName = "orders"
+}
+
+func validationMessage(err error) string {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) || apiErr.ErrorCode() != "ValidationException" {
+		return ""
+	}
+	return apiErr.ErrorMessage()
+}
+
+func TestClient(t *testing.T) {
+	t.Run("Key schema", func(t *testing.T) {
+		d, client := newDynamoDB(t)
+		createOrders(t, d)
+
+		err := dynamodb.PutItem(context.Background(), d, map[string]any{"user_id": "u1"})
+		if validationMessage(err) == "" {
+			t.Errorf("Bug. Sort key is missing. But error: %v", err)
+		}
+		err = dynamodb.PutItem(context.Background(), d, map[string]any{"user_id": "u1", "order_id": "1"})
+		if validationMessage(err) == "" {
+			t.Errorf("Bug. Type of sort key is mismatched. But error: %v", err)
+		}
+		err = dynamodb.PutItem(context.Background(), d, map[string]any{"user_id": "u1", "order_id": 1, "status": ""})
+		if validationMessage(err) == "" {
+			t.Errorf("Bug. Index key is empty. But error: %v", err)
+		}
+		_, err = dynamodb.GetItem[order](context.Background(), d, dynamodb.Key{"user_id": "u1"})
+		if validationMessage(err) == "" {
+			t.Errorf("Bug. Key is incomplete. But error: %v", err)
+		}
+
+		expect := order{UserID: "u1", OrderID: 1}
+		err = dynamodb.PutItem(context.Background(), d, expect)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, err := dynamodb.GetItem[order](context.Background(), d, dynamodb.Key{"user_id": "u1", "order_id": 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *actual != expect {
+			t.Errorf("Could not match item.\nexpect: %+v\nactual: %+v", expect, *actual)
+		}
+
+		// number keys are compared by value
+		res, err := client.GetItem(context.Background(), &awsdynamodb.GetItemInput{
+			TableName: aws.String("orders"),
+			Key: map[string]types.AttributeValue{
+				"user_id":  &types.AttributeValueMemberS{Value: "u1"},
+				"order_id": &types.AttributeValueMemberN{Value: "1.0"},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Item == nil {
+			t.Error("Bug. 1.0 is the same key as 1. But item is not found")
+		}
+
+		err = d.CreateTableFromDefinition(&dynamodb.TableDefinition{Name: "orders", PartitionKey: dynamodb.KeyAttribute{Name: "id"}})
+		var inUse *types.ResourceInUseException
+		if !errors.As(err, &inUse) {
+			t.Errorf("Could not match error.\nexpect: %T\nactual: %v", inUse, err)
+		}
+	})
+	t.Run("Condition and update", func(t *testing.T) {
+		d, _ := newDynamoDB(t)
+		createOrders(t, d)
+		ctx := context.Background()
+
+		notExists := dynamodb.WriteOptionCondition(expression.AttributeNotExists(expression.Name("user_id")))
+		err := dynamodb.PutItem(ctx, d, order{UserID: "u1", OrderID: 1}, notExists)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = dynamodb.PutItem(ctx, d, order{UserID: "u1", OrderID: 1}, notExists)
+		if !errors.Is(err, dynamodb.ErrConditionFailed) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrConditionFailed, err)
+		}
+
+		key := dynamodb.Key{"user_id": "u1", "order_id": 1}
+		actual, err := dynamodb.UpdateItem[map[string]any](ctx, d, key, dynamodb.NewUpdate().
+			Set("status", "open").
+			Increment("count", 2).
+			Append("tags", []string{"a", "b"}).
+			Add("labels", dynamodb.StringSet{"x"}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect := map[string]any{
+			"user_id":  "u1",
+			"order_id": float64(1),
+			"status":   "open",
+			"count":    float64(2),
+			"tags":     []any{"a", "b"},
+			"labels":   []string{"x"},
+		}
+		if !reflect.DeepEqual(expect, *actual) {
+			t.Errorf("Could not match item.\nexpect: %v\nactual: %v", expect, *actual)
+		}
+
+		err = d.Update(ctx, key, dynamodb.NewUpdate().Set("order_id", 2))
+		if validationMessage(err) == "" {
+			t.Errorf("Bug. Key attribute is updated. But error: %v", err)
+		}
+
+		isOpen := dynamodb.WriteOptionCondition(expression.Name("status").Equal(expression.Value("closed")))
+		err = d.Update(ctx, key, dynamodb.NewUpdate().Remove("status"), isOpen)
+		var cfe *dynamodb.ConditionFailedError
+		if !errors.As(err, &cfe) {
+			t.Fatalf("Could not match error.\nexpect: %T\nactual: %v", cfe, err)
+		}
+		if cfe.Item["status"] == nil {
+			t.Errorf("Bug. The current item is returned on failure. But item: %v", cfe.Item)
+		}
+	})
+	t.Run("Query order and pagination", func(t *testing.T) {
+		d, _ := newDynamoDB(t)
+		createOrders(t, d)
+		ctx := context.Background()
+
+		for _, id := range []int{10, 2, 7, 1, 5} {
+			status := "open"
+			if id == 5 {
+				status = "closed"
+			}
+			err := dynamodb.PutItem(ctx, d, order{UserID: "u1", OrderID: id, Status: status})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		err := dynamodb.PutItem(ctx, d, order{UserID: "u2", OrderID: 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		keyCond := expression.Key("user_id").Equal(expression.Value("u1"))
+		var ids []int
+		token := ""
+		pages := 0
+		for {
+			opts := []dynamodb.QueryOption{dynamodb.QueryOptionLimit(2)}
+			if token != "" {
+				opts = append(opts, dynamodb.QueryOptionStartToken(token))
+			}
+			page, err := d.Query(ctx, keyCond, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var orders []order
+			if err := page.Unmarshal(&orders); err != nil {
+				t.Fatal(err)
+			}
+			for _, o := range orders {
+				ids = append(ids, o.OrderID)
+			}
+			pages++
+			token = page.NextToken
+			if token == "" {
+				break
+			}
+		}
+		if expect := []int{1, 2, 5, 7, 10}; !reflect.DeepEqual(expect, ids) {
+			t.Errorf("Could not match order ids.\nexpect: %v\nactual: %v", expect, ids)
+		}
+		if expect := 3; pages != expect {
+			t.Errorf("Could not match pages.\nexpect: %d\nactual: %d", expect, pages)
+		}
+
+		page, err := d.Query(ctx, keyCond.And(expression.Key("order_id").Between(expression.Value(2), expression.Value(7))),
+			dynamodb.QueryOptionDescending(),
+			dynamodb.QueryOptionFilter(expression.Name("status").Equal(expression.Value("open"))))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var orders []order
+		if err := page.Unmarshal(&orders); err != nil {
+			t.Fatal(err)
+		}
+		expect := []order{{UserID: "u1", OrderID: 7, Status: "open"}, {UserID: "u1", OrderID: 2, Status: "open"}}
+		if !reflect.DeepEqual(expect, orders) {
+			t.Errorf("Could not match orders.\nexpect: %v\nactual: %v", expect, orders)
+		}
+		if page.Count != 2 || page.ScannedCount != 3 {
+			t.Errorf("Could not match counts.\nexpect: %d %d\nactual: %d %d", 2, 3, page.Count, page.ScannedCount)
+		}
+
+		// the index projects only the keys and items without status are not in the index
+		page, err = d.Query(ctx, expression.Key("status").Equal(expression.Value("open")), dynamodb.QueryOptionIndex("status"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expect := 4; len(page.Items) != expect {
+			t.Errorf("Could not match the number of items.\nexpect: %d\nactual: %d", expect, len(page.Items))
+		}
+		for _, item := range page.Items {
+			if len(item) != 3 {
+				t.Errorf("Bug. Index projects only keys. But item: %v", item)
+			}
+		}
+
+		_, err = d.Query(ctx, expression.Key("order_id").Equal(expression.Value(1)))
+		if validationMessage(err) == "" {
+			t.Errorf("Bug. Partition key is missing in key condition. But error: %v", err)
+		}
+	})
+	t.Run("TTL", func(t *testing.T) {
+		now := time.Now()
+		d, _ := newDynamoDB(t, memory.OptionNow(func() time.Time { return now }))
+		d.DefaultTTLName = "expires_at"
+
+		err := d.CreateDefaultTable()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = d.SetWithTTL("session", "value", time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual, err := d.Get("session")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expect := "value"; actual != expect {
+			t.Errorf("Could not match value.\nexpect: %s\nactual: %s", expect, actual)
+		}
+
+		// Get filters expired items by the real clock, so ErrNotFound means the client deleted the item
+		now = now.Add(2 * time.Hour)
+		_, err = d.Get("session")
+		if !errors.Is(err, dynamodb.ErrNotFound) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrNotFound, err)
+		}
+	})
+	t.Run("Stream", func(t *testing.T) {
+		d, client := newDynamoDB(t)
+		createOrders(t, d)
+		ctx := context.Background()
+
+		err := d.EnableStream("orders", types.StreamViewTypeNewAndOldImages)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key := dynamodb.Key{"user_id": "u1", "order_id": 1}
+		for range 2 {
+			// the second put does not modify the item, so it is not recorded
+			err := dynamodb.PutItem(ctx, d, order{UserID: "u1", OrderID: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		err = d.Update(ctx, key, dynamodb.NewUpdate().Set("status", "shipped"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = client.SplitShard("orders")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = dynamodb.DeleteItem(ctx, d, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		table, err := client.DescribeTable(ctx, &awsdynamodb.DescribeTableInput{TableName: aws.String("orders")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		stream, err := client.DescribeStream(ctx, &dynamodbstreams.DescribeStreamInput{StreamArn: table.Table.LatestStreamArn})
+		if err != nil {
+			t.Fatal(err)
+		}
+		shards := stream.StreamDescription.Shards
+		if len(shards) != 2 || aws.ToString(shards[1].ParentShardId) != aws.ToString(shards[0].ShardId) {
+			t.Fatalf("Bug. the split shard should be parent of the open shard. But %+v", shards)
+		}
+
+		read := func(shardID *string) *dynamodbstreams.GetRecordsOutput {
+			t.Helper()
+
+			it, err := client.GetShardIterator(ctx, &dynamodbstreams.GetShardIteratorInput{
+				StreamArn:         table.Table.LatestStreamArn,
+				ShardId:           shardID,
+				ShardIteratorType: streamtypes.ShardIteratorTypeTrimHorizon,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := client.GetRecords(ctx, &dynamodbstreams.GetRecordsInput{ShardIterator: it.ShardIterator})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return res
+		}
+
+		closed := read(shards[0].ShardId)
+		var events []streamtypes.OperationType
+		for _, r := range closed.Records {
+			events = append(events, r.EventName)
+		}
+		if expect := []streamtypes.OperationType{streamtypes.OperationTypeInsert, streamtypes.OperationTypeModify}; !reflect.DeepEqual(events, expect) {
+			t.Errorf("Could not match events.\nexpect: %v\nactual: %v", expect, events)
+		}
+		if closed.NextShardIterator != nil {
+			t.Error("Bug. all records of the closed shard are read. But next iterator is returned")
+		}
+		if status, ok := closed.Records[1].Dynamodb.NewImage["status"].(*streamtypes.AttributeValueMemberS); !ok || status.Value != "shipped" {
+			t.Errorf("Could not match new image. actual: %v", closed.Records[1].Dynamodb.NewImage)
+		}
+
+		open := read(shards[1].ShardId)
+		if len(open.Records) != 1 || open.Records[0].EventName != streamtypes.OperationTypeRemove || open.Records[0].Dynamodb.OldImage == nil {
+			t.Errorf("Could not match records of the open shard. actual: %+v", open.Records)
+		}
+		if open.NextShardIterator == nil {
+			t.Error("Bug. the open shard is read again. But next iterator is nil")
+		}
+	})
+	t.Run("Transaction", func(t *testing.T) {
+		d, _ := newDynamoDB(t)
+		createOrders(t, d)
+		ctx := context.Background()
+
+		err := dynamodb.PutItem(ctx, d, order{UserID: "u1", OrderID: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = d.Transaction().
+			Put(order{UserID: "u1", OrderID: 2}).
+			ConditionCheck(dynamodb.Key{"user_id": "u1", "order_id": 1}, expression.AttributeExists(expression.Name("status"))).
+			Commit(ctx)
+		var tce *dynamodb.TransactionCanceledError
+		if !errors.As(err, &tce) {
+			t.Fatalf("Could not match error.\nexpect: %T\nactual: %v", tce, err)
+		}
+		if len(tce.Reasons) != 1 || tce.Reasons[0].Index != 1 || tce.Reasons[0].Code != "ConditionalCheckFailed" {
+			t.Errorf("Could not match reasons.\nexpect: %s of %d\nactual: %v", "ConditionalCheckFailed", 1, tce.Reasons)
+		}
+		_, err = dynamodb.GetItem[order](ctx, d, dynamodb.Key{"user_id": "u1", "order_id": 2})
+		if !errors.Is(err, dynamodb.ErrNotFound) {
+			t.Errorf("Bug. Canceled transaction writes nothing. But error: %v", err)
+		}
+
+		tx := func() *dynamodb.Transaction {
+			return d.Transaction().Update(dynamodb.Key{"user_id": "u1", "order_id": 1}, dynamodb.NewUpdate().Increment("count", 1))
+		}
+		for range 2 {
+			err := tx().Commit(ctx, dynamodb.TransactOptionClientRequestToken("token"))
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		m, err := dynamodb.GetItem[map[string]any](ctx, d, dynamodb.Key{"user_id": "u1", "order_id": 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expect := float64(1); (*m)["count"] != expect {
+			t.Errorf("Bug. Retried transaction is applied once. But count: %v", (*m)["count"])
+		}
+	})
+	t.Run("Expression attributes", func(t *testing.T) {
+		d, client := newDynamoDB(t)
+		createOrders(t, d)
+
+		_, err := client.PutItem(context.Background(), &awsdynamodb.PutItemInput{
+			TableName: aws.String("orders"),
+			Item: map[string]types.AttributeValue{
+				"user_id":  &types.AttributeValueMemberS{Value: "u1"},
+				"order_id": &types.AttributeValueMemberN{Value: "1"},
+			},
+			ConditionExpression:       aws.String("attribute_not_exists(#id)"),
+			ExpressionAttributeNames:  map[string]string{"#id": "user_id"},
+			ExpressionAttributeValues: map[string]types.AttributeValue{":unused": &types.AttributeValueMemberS{Value: "x"}},
+		})
+		if expect := "Value provided in ExpressionAttributeValues unused in expressions: keys: {:unused}"; validationMessage(err) != expect {
+			t.Errorf("Could not match error.\nexpect: %s\nactual: %v", expect, err)
+		}
+
+		_, err = client.PutItem(context.Background(), &awsdynamodb.PutItemInput{
+			TableName: aws.String("orders"),
+			Item: map[string]types.AttributeValue{
+				"user_id":  &types.AttributeValueMemberS{Value: "u1"},
+				"order_id": &types.AttributeValueMemberN{Value: "1"},
+			},
+			ConditionExpression: aws.String("#undefined = :v"),
+		})
+		if validationMessage(err) == "" {
+			t.Errorf("Bug. Placeholders are undefined. But error: %v", err)
+		}
+	})
+	t.Run("Consumed capacity", func(t *testing.T) {
+		d, _ := newDynamoDB(t)
+		createOrders(t, d)
+		ctx := context.Background()
+
+		for i := range 3 {
+			err := dynamodb.PutItem(ctx, d, map[string]any{"user_id": "u1", "order_id": i, "data": string(make([]byte, 3000)) + strconv.Itoa(i)})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		page, err := d.Query(ctx, expression.Key("user_id").Equal(expression.Value("u1")), dynamodb.QueryOptionConsistentRead(), dynamodb.QueryOptionReturnConsumedCapacity())
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 3 items of about 3KB are read by 3 units of strongly consistent read
+		if expect := float64(3); page.ConsumedCapacity != expect {
+			t.Errorf("Could not match consumed capacity.\nexpect: %v\nactual: %v", expect, page.ConsumedCapacity)
+		}
+	})
+}
@@ -0,0 +1,454 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"hash/fnv"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// reader reads items of table or index by Query and Scan
+type reader struct {
+	table *table
+	// index is nil when the table is read
+	index *index
+	// keys is the key schema of the index or the table
+	keys       keySchema
+	consistent bool
+	countOnly  bool
+	paths      []path
+	limit      int
+	start      item
+}
+
+// newReader returns reader after validating the common parameters of Query and Scan
+func (t *table) newReader(indexName *string, consistentRead *bool, selectAttributes types.Select, paths []path, limit *int32, exclusiveStartKey item) (*reader, error) {
+	r := &reader{
+		table:      t,
+		keys:       t.keys,
+		consistent: aws.ToBool(consistentRead),
+		paths:      paths,
+		limit:      int(aws.ToInt32(limit)),
+	}
+
+	if name := aws.ToString(indexName); name != "" {
+		ix, ok := t.indexes[name]
+		if !ok {
+			return nil, validationError("The table does not have the specified index: %s", name)
+		}
+		if ix.global && r.consistent {
+			return nil, validationError("Consistent reads are not supported on global secondary indexes")
+		}
+		r.index = ix
+		r.keys = ix.keys
+	}
+
+	if limit != nil && r.limit < 1 {
+		return nil, validationError("1 validation error detected: Value '%d' at 'limit' failed to satisfy constraint: Member must have value greater than or equal to 1", r.limit)
+	}
+
+	switch selectAttributes {
+	case "":
+	case types.SelectAllAttributes:
+		if r.index != nil && r.index.global && r.index.projection.ProjectionType != types.ProjectionTypeAll {
+			return nil, validationError("One or more parameter values were invalid: Select type ALL_ATTRIBUTES is not supported for global secondary index %s because its projection type is not ALL", r.index.name)
+		}
+	case types.SelectAllProjectedAttributes:
+		if r.index == nil {
+			return nil, validationError("One or more parameter values were invalid: Select type ALL_PROJECTED_ATTRIBUTES is only supported when using an index")
+		}
+	case types.SelectCount:
+		r.countOnly = true
+	case types.SelectSpecificAttributes:
+		if paths == nil {
+			return nil, validationError("One or more parameter values were invalid: Select type SPECIFIC_ATTRIBUTES requires ProjectionExpression")
+		}
+	default:
+		return nil, validationError("1 validation error detected: Value '%s' at 'select' failed to satisfy constraint: Member must satisfy enum value set: [SPECIFIC_ATTRIBUTES, COUNT, ALL_ATTRIBUTES, ALL_PROJECTED_ATTRIBUTES]", selectAttributes)
+	}
+	if paths != nil && selectAttributes != "" && selectAttributes != types.SelectSpecificAttributes {
+		return nil, validationError("One or more parameter values were invalid: Cannot specify the ProjectionExpression when choosing to get %s", selectAttributes)
+	}
+
+	if exclusiveStartKey != nil {
+		expect := keyOf(exclusiveStartKey, t.keys, r.keys)
+		if len(expect) != len(exclusiveStartKey) || len(expect) != len(r.keyNames()) {
+			return nil, validationError("The provided starting key is invalid: The provided key element does not match the schema")
+		}
+		r.start = expect
+	}
+
+	return r, nil
+}
+
+// keyNames returns names of the key attributes of the table and the index
+func (r *reader) keyNames() []string {
+	var ret []string
+	for _, name := range []string{r.table.keys.hashKey, r.table.keys.rangeKey, r.keys.hashKey, r.keys.rangeKey} {
+		if name != "" && !slices.Contains(ret, name) {
+			ret = append(ret, name)
+		}
+	}
+	return ret
+}
+
+// items returns items of the index or the table in the order of partitions and sort keys
+func (r *reader) items() []item {
+	ret := make([]item, 0, len(r.table.items))
+	for _, it := range r.table.items {
+		if r.index == nil || r.index.contains(it) {
+			ret = append(ret, it)
+		}
+	}
+	slices.SortFunc(ret, r.compare)
+	return ret
+}
+
+// compare orders items by the hash of the partition key, the sort key and the primary key of the table
+func (r *reader) compare(a, b item) int {
+	if c := cmp.Compare(partitionHash(a[r.keys.hashKey]), partitionHash(b[r.keys.hashKey])); c != 0 {
+		return c
+	}
+	if c := strings.Compare(keyString(a[r.keys.hashKey]), keyString(b[r.keys.hashKey])); c != 0 {
+		return c
+	}
+	if r.keys.rangeKey != "" {
+		if c, _ := compareValues(a[r.keys.rangeKey], b[r.keys.rangeKey]); c != 0 {
+			return c
+		}
+	}
+	return strings.Compare(r.table.itemKey(a), r.table.itemKey(b))
+}
+
+// partitionHash returns hash of partition key deciding the order of partitions and the segment of parallel scan
+func partitionHash(av types.AttributeValue) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(keyString(av)))
+	return h.Sum32()
+}
+
+// view returns attributes of it projected to the index
+func (r *reader) view(it item) item {
+	if r.index == nil || !r.index.global {
+		return it
+	}
+
+	switch r.index.projection.ProjectionType {
+	case types.ProjectionTypeKeysOnly:
+		return keyOf(it, r.table.keys, r.keys)
+	case types.ProjectionTypeInclude:
+		ret := keyOf(it, r.table.keys, r.keys)
+		for _, name := range r.index.projection.NonKeyAttributes {
+			if v, ok := it[name]; ok {
+				ret[name] = v
+			}
+		}
+		return ret
+	}
+
+	return it
+}
+
+type page struct {
+	items            []map[string]types.AttributeValue
+	count            int32
+	scanned          int32
+	lastEvaluatedKey item
+	units            float64
+}
+
+// read reads sorted items after the exclusive start key up to the limit and 1MB.
+// Like DynamoDB the limit is of items evaluated before the filter, and LastEvaluatedKey is returned whenever the limit is reached
+func (r *reader) read(sorted []item, forward bool, filter condition) (*page, error) {
+	if !forward {
+		slices.Reverse(sorted)
+	}
+
+	ret := &page{}
+	if !r.countOnly {
+		ret.items = []map[string]types.AttributeValue{}
+	}
+
+	size := 0
+	for _, it := range sorted {
+		if r.start != nil {
+			c := r.compare(it, r.start)
+			if c == 0 || (c < 0) == forward {
+				continue
+			}
+		}
+
+		v := r.view(it)
+		ret.scanned++
+		size += itemSize(v)
+
+		ok := true
+		if filter != nil {
+			var err error
+			ok, err = filter.eval(v)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if ok {
+			ret.count++
+			if !r.countOnly {
+				ret.items = append(ret.items, project(v, r.paths))
+			}
+		}
+
+		if int(ret.scanned) == r.limit || size >= maxPageSize {
+			ret.lastEvaluatedKey = keyOf(it, r.table.keys, r.keys)
+			break
+		}
+	}
+
+	ret.units = readUnits(size, r.consistent)
+
+	return ret, nil
+}
+
+// Query reads items of a partition of table or index in the order of the sort key
+func (c *Client) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	if err := errors.Join(
+		legacyParameter("KeyConditions", params.KeyConditions != nil),
+		legacyParameter("QueryFilter", params.QueryFilter != nil),
+		legacyParameter("AttributesToGet", params.AttributesToGet != nil),
+		legacyParameter("ConditionalOperator", params.ConditionalOperator != ""),
+	); err != nil {
+		return nil, err
+	}
+	if aws.ToString(params.KeyConditionExpression) == "" {
+		return nil, validationError("Either the KeyConditions or KeyConditionExpression parameter must be specified in the request.")
+	}
+
+	t, err := c.lock(ctx, params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	defer c.mu.Unlock()
+
+	e, err := expressionsOf(params.ExpressionAttributeNames, params.ExpressionAttributeValues)
+	if err != nil {
+		return nil, err
+	}
+	keyCondition, err := e.condition("KeyConditionExpression", aws.ToString(params.KeyConditionExpression))
+	if err != nil {
+		return nil, err
+	}
+	filter, err := e.condition("FilterExpression", aws.ToString(params.FilterExpression))
+	if err != nil {
+		return nil, err
+	}
+	paths, err := e.projection(aws.ToString(params.ProjectionExpression))
+	if err != nil {
+		return nil, err
+	}
+	if err := e.checkUnused(); err != nil {
+		return nil, err
+	}
+
+	r, err := t.newReader(params.IndexName, params.ConsistentRead, params.Select, paths, params.Limit, params.ExclusiveStartKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateKeyCondition(keyCondition, r.keys); err != nil {
+		return nil, err
+	}
+	for _, name := range conditionNames(filter) {
+		if name == r.keys.hashKey || name == r.keys.rangeKey {
+			return nil, validationError("Filter Expression can only contain non-primary key attributes: Primary key attribute: %s", name)
+		}
+	}
+
+	var items []item
+	for _, it := range r.items() {
+		ok, err := keyCondition.eval(it)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			items = append(items, it)
+		}
+	}
+
+	p, err := r.read(items, params.ScanIndexForward == nil || *params.ScanIndexForward, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dynamodb.QueryOutput{
+		Items:            p.items,
+		Count:            p.count,
+		ScannedCount:     p.scanned,
+		LastEvaluatedKey: p.lastEvaluatedKey,
+		ConsumedCapacity: consumedCapacity(t, p.units, 0, params.ReturnConsumedCapacity),
+	}, nil
+}
+
+// validateKeyCondition checks that cond is equality of the partition key, optionally AND condition of the sort key
+func validateKeyCondition(cond condition, keys keySchema) error {
+	conds := []condition{cond}
+	if and, ok := cond.(*andCondition); ok {
+		conds = []condition{and.left, and.right}
+	}
+
+	var hashFound, rangeFound bool
+	for _, c := range conds {
+		name, equality, ok := keyConditionAttribute(c)
+		switch {
+		case !ok:
+			return validationError("Invalid operator used in KeyConditionExpression")
+		case name == keys.hashKey && equality && !hashFound:
+			hashFound = true
+		case name == keys.rangeKey && keys.rangeKey != "" && !rangeFound:
+			rangeFound = true
+		default:
+			return validationError("Query key condition not supported")
+		}
+	}
+	if !hashFound {
+		return validationError("Query condition missed key schema element: %s", keys.hashKey)
+	}
+
+	return nil
+}
+
+// keyConditionAttribute returns the attribute name of condition of key attribute and whether it is equality.
+// ok is false when cond is not supported by key condition
+func keyConditionAttribute(cond condition) (name string, equality, ok bool) {
+	attribute := func(o operand) (string, bool) {
+		p, ok := o.(*pathOperand)
+		if !ok || len(p.path) != 1 {
+			return "", false
+		}
+		return p.path[0].name, true
+	}
+	isValue := func(o operand) bool {
+		_, ok := o.(*valueOperand)
+		return ok
+	}
+
+	switch c := cond.(type) {
+	case *compareCondition:
+		name, ok := attribute(c.left)
+		return name, c.op == "=", ok && c.op != "<>" && isValue(c.right)
+	case *betweenCondition:
+		name, ok := attribute(c.value)
+		return name, false, ok && isValue(c.low) && isValue(c.high)
+	case *functionCondition:
+		if c.name != "begins_with" || len(c.path) != 1 {
+			return "", false, false
+		}
+		return c.path[0].name, false, isValue(c.arg)
+	}
+
+	return "", false, false
+}
+
+// conditionNames returns top level attribute names referred by cond
+func conditionNames(cond condition) []string {
+	var operands []operand
+	switch c := cond.(type) {
+	case *andCondition:
+		return append(conditionNames(c.left), conditionNames(c.right)...)
+	case *orCondition:
+		return append(conditionNames(c.left), conditionNames(c.right)...)
+	case *notCondition:
+		return conditionNames(c.cond)
+	case *compareCondition:
+		operands = []operand{c.left, c.right}
+	case *betweenCondition:
+		operands = []operand{c.value, c.low, c.high}
+	case *inCondition:
+		operands = append([]operand{c.value}, c.list...)
+	case *functionCondition:
+		operands = []operand{&pathOperand{path: c.path}, c.arg}
+	}
+
+	var ret []string
+	for _, o := range operands {
+		switch x := o.(type) {
+		case *pathOperand:
+			ret = append(ret, x.path[0].name)
+		case *sizeOperand:
+			ret = append(ret, x.path[0].name)
+		}
+	}
+	return ret
+}
+
+// Scan reads all items of table or index. Items are ordered by partition, and by sort key in the partition
+func (c *Client) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	if err := errors.Join(
+		legacyParameter("ScanFilter", params.ScanFilter != nil),
+		legacyParameter("AttributesToGet", params.AttributesToGet != nil),
+		legacyParameter("ConditionalOperator", params.ConditionalOperator != ""),
+	); err != nil {
+		return nil, err
+	}
+
+	totalSegments := aws.ToInt32(params.TotalSegments)
+	segment := aws.ToInt32(params.Segment)
+	switch {
+	case (params.TotalSegments == nil) != (params.Segment == nil):
+		return nil, validationError("The TotalSegments parameter is required but was not present in the request when Segment parameter is present")
+	case params.TotalSegments != nil && (totalSegments < 1 || totalSegments > 1000000):
+		return nil, validationError("1 validation error detected: Value '%d' at 'totalSegments' failed to satisfy constraint: Member must have value between 1 and 1000000", totalSegments)
+	case params.Segment != nil && (segment < 0 || segment >= totalSegments):
+		return nil, validationError("The Segment parameter is zero-based and must be less than parameter TotalSegments: Segment: %d is not less than TotalSegments: %d", segment, totalSegments)
+	}
+
+	t, err := c.lock(ctx, params.TableName)
+	if err != nil {
+		return nil, err
+	}
+	defer c.mu.Unlock()
+
+	e, err := expressionsOf(params.ExpressionAttributeNames, params.ExpressionAttributeValues)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := e.condition("FilterExpression", aws.ToString(params.FilterExpression))
+	if err != nil {
+		return nil, err
+	}
+	paths, err := e.projection(aws.ToString(params.ProjectionExpression))
+	if err != nil {
+		return nil, err
+	}
+	if err := e.checkUnused(); err != nil {
+		return nil, err
+	}
+
+	r, err := t.newReader(params.IndexName, params.ConsistentRead, params.Select, paths, params.Limit, params.ExclusiveStartKey)
+	if err != nil {
+		return nil, err
+	}
+
+	items := r.items()
+	if params.TotalSegments != nil {
+		items = slices.DeleteFunc(items, func(it item) bool {
+			return partitionHash(it[r.keys.hashKey])%uint32(totalSegments) != uint32(segment)
+		})
+	}
+
+	p, err := r.read(items, true, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dynamodb.ScanOutput{
+		Items:            p.items,
+		Count:            p.count,
+		ScannedCount:     p.scanned,
+		LastEvaluatedKey: p.lastEvaluatedKey,
+		ConsumedCapacity: consumedCapacity(t, p.units, 0, params.ReturnConsumedCapacity),
+	}, nil
+}
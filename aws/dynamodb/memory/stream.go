@@ -0,0 +1,349 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams"
+	streamtypes "github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+)
+
+// stream is stream of table recording writes into the open shard, which is the last shard
+type stream struct {
+	arn      string
+	label    string
+	viewType types.StreamViewType
+	keys     keySchema
+	now      func() time.Time
+	shards   []*shard
+	// seq is the last sequence number
+	seq int
+}
+
+type shard struct {
+	id      string
+	parent  string
+	closed  bool
+	records []streamtypes.Record
+}
+
+func (t *table) newStream(viewType types.StreamViewType, now func() time.Time) *stream {
+	label := now().UTC().Format("2006-01-02T15:04:05.000")
+
+	st := &stream{
+		arn:      t.arn() + "/stream/" + label,
+		label:    label,
+		viewType: viewType,
+		keys:     t.keys,
+		now:      now,
+	}
+	st.openShard("")
+
+	return st
+}
+
+func (st *stream) openShard(parent string) {
+	st.shards = append(st.shards, &shard{id: fmt.Sprintf("shardId-%08d", len(st.shards)), parent: parent})
+}
+
+// enableStream starts or stops recording changes by stream specification of CreateTable or UpdateTable
+func (t *table) enableStream(spec *types.StreamSpecification, now func() time.Time) error {
+	if spec == nil {
+		return nil
+	}
+	if !aws.ToBool(spec.StreamEnabled) {
+		t.changes = nil
+		return nil
+	}
+
+	switch spec.StreamViewType {
+	case types.StreamViewTypeKeysOnly, types.StreamViewTypeNewImage, types.StreamViewTypeOldImage, types.StreamViewTypeNewAndOldImages:
+	default:
+		return validationError("1 validation error detected: Value '%s' at 'streamSpecification.streamViewType' failed to satisfy constraint: Member must satisfy enum value set: [NEW_IMAGE, OLD_IMAGE, NEW_AND_OLD_IMAGES, KEYS_ONLY]", spec.StreamViewType)
+	}
+	if t.changes != nil {
+		return validationError("Table already has an enabled stream: %s", t.changes.arn)
+	}
+	t.changes = t.newStream(spec.StreamViewType, now)
+
+	return nil
+}
+
+// record appends record of the change to the open shard. current or next is nil on insert or remove.
+// Writes not modifying the item are not recorded like DynamoDB
+func (t *table) record(current, next item) {
+	st := t.changes
+	if st == nil || current == nil && next == nil || maps.EqualFunc(current, next, equalValues) {
+		return
+	}
+
+	eventName := streamtypes.OperationTypeModify
+	keyItem := next
+	switch {
+	case current == nil:
+		eventName = streamtypes.OperationTypeInsert
+	case next == nil:
+		eventName = streamtypes.OperationTypeRemove
+		keyItem = current
+	}
+
+	st.seq++
+	seq := fmt.Sprintf("%021d", st.seq)
+
+	change := &streamtypes.StreamRecord{
+		SequenceNumber:              aws.String(seq),
+		StreamViewType:              streamtypes.StreamViewType(st.viewType),
+		ApproximateCreationDateTime: aws.Time(st.now()),
+		Keys:                        streamItem(keyOf(keyItem, st.keys)),
+		SizeBytes:                   aws.Int64(int64(itemSize(keyItem))),
+	}
+	if next != nil && (st.viewType == types.StreamViewTypeNewImage || st.viewType == types.StreamViewTypeNewAndOldImages) {
+		change.NewImage = streamItem(next)
+	}
+	if current != nil && (st.viewType == types.StreamViewTypeOldImage || st.viewType == types.StreamViewTypeNewAndOldImages) {
+		change.OldImage = streamItem(current)
+	}
+
+	sh := st.shards[len(st.shards)-1]
+	sh.records = append(sh.records, streamtypes.Record{
+		EventID:      aws.String("event-" + seq),
+		EventName:    eventName,
+		EventSource:  aws.String("aws:dynamodb"),
+		EventVersion: aws.String("1.1"),
+		AwsRegion:    aws.String("memory"),
+		Dynamodb:     change,
+	})
+}
+
+// streamItem converts item into item of DynamoDB Streams
+func streamItem(it item) map[string]streamtypes.AttributeValue {
+	ret := make(map[string]streamtypes.AttributeValue, len(it))
+	for name, v := range it {
+		ret[name] = streamValue(v)
+	}
+	return ret
+}
+
+func streamValue(av types.AttributeValue) streamtypes.AttributeValue {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		return &streamtypes.AttributeValueMemberS{Value: v.Value}
+	case *types.AttributeValueMemberN:
+		return &streamtypes.AttributeValueMemberN{Value: v.Value}
+	case *types.AttributeValueMemberB:
+		return &streamtypes.AttributeValueMemberB{Value: append([]byte(nil), v.Value...)}
+	case *types.AttributeValueMemberBOOL:
+		return &streamtypes.AttributeValueMemberBOOL{Value: v.Value}
+	case *types.AttributeValueMemberNULL:
+		return &streamtypes.AttributeValueMemberNULL{Value: v.Value}
+	case *types.AttributeValueMemberSS:
+		return &streamtypes.AttributeValueMemberSS{Value: append([]string(nil), v.Value...)}
+	case *types.AttributeValueMemberNS:
+		return &streamtypes.AttributeValueMemberNS{Value: append([]string(nil), v.Value...)}
+	case *types.AttributeValueMemberBS:
+		ret := &streamtypes.AttributeValueMemberBS{}
+		for _, b := range v.Value {
+			ret.Value = append(ret.Value, append([]byte(nil), b...))
+		}
+		return ret
+	case *types.AttributeValueMemberL:
+		ret := &streamtypes.AttributeValueMemberL{Value: make([]streamtypes.AttributeValue, 0, len(v.Value))}
+		for _, e := range v.Value {
+			ret.Value = append(ret.Value, streamValue(e))
+		}
+		return ret
+	case *types.AttributeValueMemberM:
+		return &streamtypes.AttributeValueMemberM{Value: streamItem(v.Value)}
+	}
+	return nil
+}
+
+// SplitShard closes the open shard of the stream of table and opens its child shard, as DynamoDB rotates shards
+func (c *Client) SplitShard(tableName string) error {
+	t, err := c.lock(context.Background(), aws.String(tableName))
+	if err != nil {
+		return err
+	}
+	defer c.mu.Unlock()
+
+	st := t.changes
+	if st == nil {
+		return validationError("Stream is not enabled on table: %s", tableName)
+	}
+	sh := st.shards[len(st.shards)-1]
+	sh.closed = true
+	st.openShard(sh.id)
+
+	return nil
+}
+
+// stream returns stream of arn and purges expired items of the table. The client must be locked
+func (c *Client) stream(arn *string) (*stream, error) {
+	for _, t := range c.tables {
+		if t.changes != nil && t.changes.arn == aws.ToString(arn) {
+			t.purge(c.now())
+			return t.changes, nil
+		}
+	}
+	return nil, &streamtypes.ResourceNotFoundException{Message: aws.String("Requested resource not found: Stream: " + aws.ToString(arn) + " not found")}
+}
+
+func (st *stream) shard(shardID *string) (*shard, error) {
+	for _, sh := range st.shards {
+		if sh.id == aws.ToString(shardID) {
+			return sh, nil
+		}
+	}
+	return nil, &streamtypes.ResourceNotFoundException{Message: aws.String("Requested resource not found: Shard: " + aws.ToString(shardID) + " not found")}
+}
+
+// DescribeStream returns all the shards of the stream. Closed shards have the ending sequence number
+func (c *Client) DescribeStream(ctx context.Context, params *dynamodbstreams.DescribeStreamInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.DescribeStreamOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	st, err := c.stream(params.StreamArn)
+	if err != nil {
+		return nil, err
+	}
+
+	desc := &streamtypes.StreamDescription{
+		StreamArn:      aws.String(st.arn),
+		StreamLabel:    aws.String(st.label),
+		StreamStatus:   streamtypes.StreamStatusEnabled,
+		StreamViewType: streamtypes.StreamViewType(st.viewType),
+		KeySchema:      streamKeySchema(st.keys),
+		Shards:         make([]streamtypes.Shard, 0, len(st.shards)),
+	}
+	for _, sh := range st.shards {
+		seqRange := &streamtypes.SequenceNumberRange{StartingSequenceNumber: aws.String(fmt.Sprintf("%021d", 0))}
+		if len(sh.records) > 0 {
+			seqRange.StartingSequenceNumber = sh.records[0].Dynamodb.SequenceNumber
+			if sh.closed {
+				seqRange.EndingSequenceNumber = sh.records[len(sh.records)-1].Dynamodb.SequenceNumber
+			}
+		}
+
+		s := streamtypes.Shard{ShardId: aws.String(sh.id), SequenceNumberRange: seqRange}
+		if sh.parent != "" {
+			s.ParentShardId = aws.String(sh.parent)
+		}
+		desc.Shards = append(desc.Shards, s)
+	}
+
+	return &dynamodbstreams.DescribeStreamOutput{StreamDescription: desc}, nil
+}
+
+func streamKeySchema(keys keySchema) []streamtypes.KeySchemaElement {
+	ret := []streamtypes.KeySchemaElement{{AttributeName: aws.String(keys.hashKey), KeyType: streamtypes.KeyTypeHash}}
+	if keys.rangeKey != "" {
+		ret = append(ret, streamtypes.KeySchemaElement{AttributeName: aws.String(keys.rangeKey), KeyType: streamtypes.KeyTypeRange})
+	}
+	return ret
+}
+
+// GetShardIterator returns iterator of the shard. The iterator never expires
+func (c *Client) GetShardIterator(ctx context.Context, params *dynamodbstreams.GetShardIteratorInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetShardIteratorOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	st, err := c.stream(params.StreamArn)
+	if err != nil {
+		return nil, err
+	}
+	sh, err := st.shard(params.ShardId)
+	if err != nil {
+		return nil, err
+	}
+
+	pos := 0
+	switch params.ShardIteratorType {
+	case streamtypes.ShardIteratorTypeTrimHorizon:
+	case streamtypes.ShardIteratorTypeLatest:
+		pos = len(sh.records)
+	case streamtypes.ShardIteratorTypeAtSequenceNumber, streamtypes.ShardIteratorTypeAfterSequenceNumber:
+		pos = -1
+		for i, r := range sh.records {
+			if aws.ToString(r.Dynamodb.SequenceNumber) == aws.ToString(params.SequenceNumber) {
+				pos = i
+			}
+		}
+		if pos < 0 {
+			return nil, validationError("Invalid SequenceNumber for ShardId: %s", sh.id)
+		}
+		if params.ShardIteratorType == streamtypes.ShardIteratorTypeAfterSequenceNumber {
+			pos++
+		}
+	default:
+		return nil, validationError("1 validation error detected: Value '%s' at 'shardIteratorType' failed to satisfy constraint: Member must satisfy enum value set: [TRIM_HORIZON, LATEST, AT_SEQUENCE_NUMBER, AFTER_SEQUENCE_NUMBER]", params.ShardIteratorType)
+	}
+
+	return &dynamodbstreams.GetShardIteratorOutput{ShardIterator: aws.String(shardIterator(st.arn, sh.id, pos))}, nil
+}
+
+// shardIterator returns iterator "arn|shard id|position of the next record"
+func shardIterator(arn, shardID string, pos int) string {
+	return arn + "|" + shardID + "|" + strconv.Itoa(pos)
+}
+
+// GetRecords returns records from the iterator up to 1000 or Limit.
+// NextShardIterator is nil when all records of the closed shard are read
+func (c *Client) GetRecords(ctx context.Context, params *dynamodbstreams.GetRecordsInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetRecordsOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	limit := int(aws.ToInt32(params.Limit))
+	if params.Limit != nil && (limit < 1 || limit > 1000) {
+		return nil, validationError("1 validation error detected: Value '%d' at 'limit' failed to satisfy constraint: Member must have value between 1 and 1000", limit)
+	}
+	if limit == 0 {
+		limit = 1000
+	}
+
+	parts := strings.Split(aws.ToString(params.ShardIterator), "|")
+	if len(parts) != 3 {
+		return nil, validationError("Invalid ShardIterator")
+	}
+	pos, err := strconv.Atoi(parts[2])
+	if err != nil || pos < 0 {
+		return nil, validationError("Invalid ShardIterator")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	st, err := c.stream(aws.String(parts[0]))
+	if err != nil {
+		return nil, err
+	}
+	sh, err := st.shard(aws.String(parts[1]))
+	if err != nil {
+		return nil, err
+	}
+	if pos > len(sh.records) {
+		return nil, validationError("Invalid ShardIterator")
+	}
+
+	end := min(pos+limit, len(sh.records))
+	ret := &dynamodbstreams.GetRecordsOutput{Records: append([]streamtypes.Record{}, sh.records[pos:end]...)}
+	if !sh.closed || end < len(sh.records) {
+		ret.NextShardIterator = aws.String(shardIterator(st.arn, sh.id, end))
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,346 @@
+package memory
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// item is attributes of item by attribute name
+type item = map[string]types.AttributeValue
+
+// parseNumber parses number attribute value
+func parseNumber(s string) (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok {
+		return nil, validationError("A value provided cannot be converted into a number")
+	}
+	return r, nil
+}
+
+// formatNumber formats r in the shortest decimal representation. r must be decimal, which has finite digits
+func formatNumber(r *big.Rat) string {
+	if r.IsInt() {
+		return r.Num().String()
+	}
+
+	ten := big.NewInt(10)
+	scaled := new(big.Rat).Set(r)
+	for digits := 1; digits <= 128; digits++ {
+		scaled.Mul(scaled, new(big.Rat).SetInt(ten))
+		if scaled.IsInt() {
+			return r.FloatString(digits)
+		}
+	}
+
+	return r.FloatString(38)
+}
+
+// canonicalNumber returns the normalized representation of number attribute value. e.g. "1.0" is "1"
+func canonicalNumber(s string) (string, error) {
+	r, err := parseNumber(s)
+	if err != nil {
+		return "", err
+	}
+	return formatNumber(r), nil
+}
+
+// compareValues compares scalar attribute values of the same type. false is returned when they are not comparable
+func compareValues(a, b types.AttributeValue) (int, bool) {
+	switch x := a.(type) {
+	case *types.AttributeValueMemberS:
+		y, ok := b.(*types.AttributeValueMemberS)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(x.Value, y.Value), true
+	case *types.AttributeValueMemberN:
+		y, ok := b.(*types.AttributeValueMemberN)
+		if !ok {
+			return 0, false
+		}
+		l, err := parseNumber(x.Value)
+		if err != nil {
+			return 0, false
+		}
+		r, err := parseNumber(y.Value)
+		if err != nil {
+			return 0, false
+		}
+		return l.Cmp(r), true
+	case *types.AttributeValueMemberB:
+		y, ok := b.(*types.AttributeValueMemberB)
+		if !ok {
+			return 0, false
+		}
+		return bytes.Compare(x.Value, y.Value), true
+	}
+
+	return 0, false
+}
+
+// equalValues reports whether attribute values are equal. Sets are equal regardless of order
+func equalValues(a, b types.AttributeValue) bool {
+	switch x := a.(type) {
+	case *types.AttributeValueMemberS, *types.AttributeValueMemberN, *types.AttributeValueMemberB:
+		cmp, ok := compareValues(a, b)
+		return ok && cmp == 0
+	case *types.AttributeValueMemberBOOL:
+		y, ok := b.(*types.AttributeValueMemberBOOL)
+		return ok && x.Value == y.Value
+	case *types.AttributeValueMemberNULL:
+		_, ok := b.(*types.AttributeValueMemberNULL)
+		return ok
+	case *types.AttributeValueMemberSS:
+		y, ok := b.(*types.AttributeValueMemberSS)
+		return ok && equalSets(setElements(x), setElements(y))
+	case *types.AttributeValueMemberNS:
+		y, ok := b.(*types.AttributeValueMemberNS)
+		return ok && equalSets(setElements(x), setElements(y))
+	case *types.AttributeValueMemberBS:
+		y, ok := b.(*types.AttributeValueMemberBS)
+		return ok && equalSets(setElements(x), setElements(y))
+	case *types.AttributeValueMemberL:
+		y, ok := b.(*types.AttributeValueMemberL)
+		return ok && slices.EqualFunc(x.Value, y.Value, equalValues)
+	case *types.AttributeValueMemberM:
+		y, ok := b.(*types.AttributeValueMemberM)
+		if !ok || len(x.Value) != len(y.Value) {
+			return false
+		}
+		for name, v := range x.Value {
+			w, ok := y.Value[name]
+			if !ok || !equalValues(v, w) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+func equalSets(a, b []string) bool {
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
+
+// setElements returns canonical elements of set attribute value. nil is returned for other types
+func setElements(av types.AttributeValue) []string {
+	var ret []string
+	switch v := av.(type) {
+	case *types.AttributeValueMemberSS:
+		ret = append(ret, v.Value...)
+	case *types.AttributeValueMemberNS:
+		for _, n := range v.Value {
+			c, err := canonicalNumber(n)
+			if err != nil {
+				c = n
+			}
+			ret = append(ret, c)
+		}
+	case *types.AttributeValueMemberBS:
+		for _, b := range v.Value {
+			ret = append(ret, string(b))
+		}
+	}
+	return ret
+}
+
+// keyString returns string identifying scalar key attribute value
+func keyString(av types.AttributeValue) string {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		return "S:" + v.Value
+	case *types.AttributeValueMemberN:
+		c, err := canonicalNumber(v.Value)
+		if err != nil {
+			c = v.Value
+		}
+		return "N:" + c
+	case *types.AttributeValueMemberB:
+		return "B:" + base64.StdEncoding.EncodeToString(v.Value)
+	}
+	return fmt.Sprintf("%T", av)
+}
+
+// copyValue returns deep copy of attribute value
+func copyValue(av types.AttributeValue) types.AttributeValue {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		return &types.AttributeValueMemberS{Value: v.Value}
+	case *types.AttributeValueMemberN:
+		return &types.AttributeValueMemberN{Value: v.Value}
+	case *types.AttributeValueMemberB:
+		return &types.AttributeValueMemberB{Value: bytes.Clone(v.Value)}
+	case *types.AttributeValueMemberBOOL:
+		return &types.AttributeValueMemberBOOL{Value: v.Value}
+	case *types.AttributeValueMemberNULL:
+		return &types.AttributeValueMemberNULL{Value: v.Value}
+	case *types.AttributeValueMemberSS:
+		return &types.AttributeValueMemberSS{Value: slices.Clone(v.Value)}
+	case *types.AttributeValueMemberNS:
+		return &types.AttributeValueMemberNS{Value: slices.Clone(v.Value)}
+	case *types.AttributeValueMemberBS:
+		ret := &types.AttributeValueMemberBS{Value: make([][]byte, 0, len(v.Value))}
+		for _, b := range v.Value {
+			ret.Value = append(ret.Value, bytes.Clone(b))
+		}
+		return ret
+	case *types.AttributeValueMemberL:
+		ret := &types.AttributeValueMemberL{Value: make([]types.AttributeValue, 0, len(v.Value))}
+		for _, e := range v.Value {
+			ret.Value = append(ret.Value, copyValue(e))
+		}
+		return ret
+	case *types.AttributeValueMemberM:
+		return &types.AttributeValueMemberM{Value: copyItem(v.Value)}
+	}
+	return av
+}
+
+// copyItem returns deep copy of item. nil is returned for nil
+func copyItem(it item) item {
+	if it == nil {
+		return nil
+	}
+
+	ret := make(item, len(it))
+	for name, v := range it {
+		ret[name] = copyValue(v)
+	}
+	return ret
+}
+
+// itemSize returns approximate size of item in bytes calculated like DynamoDB
+func itemSize(it item) int {
+	size := 0
+	for name, v := range it {
+		size += len(name) + valueSize(v)
+	}
+	return size
+}
+
+func valueSize(av types.AttributeValue) int {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		return len(v.Value)
+	case *types.AttributeValueMemberN:
+		return (len(v.Value)+1)/2 + 1
+	case *types.AttributeValueMemberB:
+		return len(v.Value)
+	case *types.AttributeValueMemberBOOL, *types.AttributeValueMemberNULL:
+		return 1
+	case *types.AttributeValueMemberSS:
+		size := 0
+		for _, s := range v.Value {
+			size += len(s)
+		}
+		return size
+	case *types.AttributeValueMemberNS:
+		size := 0
+		for _, n := range v.Value {
+			size += (len(n)+1)/2 + 1
+		}
+		return size
+	case *types.AttributeValueMemberBS:
+		size := 0
+		for _, b := range v.Value {
+			size += len(b)
+		}
+		return size
+	case *types.AttributeValueMemberL:
+		size := 3
+		for _, e := range v.Value {
+			size += valueSize(e) + 1
+		}
+		return size
+	case *types.AttributeValueMemberM:
+		return 3 + itemSize(v.Value) + len(v.Value)
+	}
+	return 0
+}
+
+// validateValue checks constraints of DynamoDB on attribute value: numbers are valid and sets are neither empty nor duplicated
+func validateValue(av types.AttributeValue) error {
+	switch v := av.(type) {
+	case nil:
+		return validationError("Supplied AttributeValue is empty, must contain exactly one of the supported datatypes")
+	case *types.AttributeValueMemberN:
+		_, err := parseNumber(v.Value)
+		return err
+	case *types.AttributeValueMemberNS:
+		for _, n := range v.Value {
+			if _, err := parseNumber(n); err != nil {
+				return err
+			}
+		}
+	case *types.AttributeValueMemberL:
+		for _, e := range v.Value {
+			if err := validateValue(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *types.AttributeValueMemberM:
+		for _, e := range v.Value {
+			if err := validateValue(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if elements := setElements(av); elements != nil || isSet(av) {
+		if len(elements) == 0 {
+			return validationError("One or more parameter values were invalid: An empty set is not allowed")
+		}
+		slices.Sort(elements)
+		if len(slices.Compact(elements)) != len(elements) {
+			return validationError("One or more parameter values were invalid: Input collection contains duplicates")
+		}
+	}
+
+	return nil
+}
+
+func isSet(av types.AttributeValue) bool {
+	switch av.(type) {
+	case *types.AttributeValueMemberSS, *types.AttributeValueMemberNS, *types.AttributeValueMemberBS:
+		return true
+	}
+	return false
+}
+
+// attributeType returns the type descriptor of attribute value. e.g. "S" and "NS"
+func attributeType(av types.AttributeValue) string {
+	switch av.(type) {
+	case *types.AttributeValueMemberS:
+		return "S"
+	case *types.AttributeValueMemberN:
+		return "N"
+	case *types.AttributeValueMemberB:
+		return "B"
+	case *types.AttributeValueMemberBOOL:
+		return "BOOL"
+	case *types.AttributeValueMemberNULL:
+		return "NULL"
+	case *types.AttributeValueMemberSS:
+		return "SS"
+	case *types.AttributeValueMemberNS:
+		return "NS"
+	case *types.AttributeValueMemberBS:
+		return "BS"
+	case *types.AttributeValueMemberL:
+		return "L"
+	case *types.AttributeValueMemberM:
+		return "M"
+	}
+	return ""
+}
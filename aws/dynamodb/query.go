@@ -0,0 +1,351 @@
+package dynamodb
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+var (
+	// ErrInvalidToken is returned when the token of QueryOptionStartToken is broken
+	ErrInvalidToken = errors.New("invalid token")
+)
+
+// Page is single page of Query or Scan result
+type Page struct {
+	Items        []map[string]types.AttributeValue
+	Count        int32
+	ScannedCount int32
+	// NextToken is opaque token of LastEvaluatedKey. It is empty on the last page
+	NextToken string
+	// ConsumedCapacity is capacity units consumed by the page. It is set with QueryOptionReturnConsumedCapacity
+	ConsumedCapacity float64
+
+	lastKey map[string]types.AttributeValue
+}
+
+// Unmarshal unmarshals items into out. out must be pointer to slice
+func (p *Page) Unmarshal(out any) error {
+	return attributevalue.UnmarshalListOfMaps(p.Items, out)
+}
+
+// Query reads a page of items matching keyCond. Pass Page.NextToken by QueryOptionStartToken to read the next page
+func (d *DynamoDB) Query(ctx context.Context, keyCond expression.KeyConditionBuilder, queryOpts ...QueryOption) (*Page, error) {
+	qc, err := createQueryConfig(queryOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	in, err := d.queryInput(qc, keyCond)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.queryPage(ctx, qc, in)
+}
+
+// QueryAll returns iterator of all items matching keyCond. Pages are read on demand
+func (d *DynamoDB) QueryAll(ctx context.Context, keyCond expression.KeyConditionBuilder, queryOpts ...QueryOption) iter.Seq2[map[string]types.AttributeValue, error] {
+	return func(yield func(map[string]types.AttributeValue, error) bool) {
+		qc, err := createQueryConfig(queryOpts...)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		in, err := d.queryInput(qc, keyCond)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		paginate(func() (*Page, error) {
+			page, err := d.queryPage(ctx, qc, in)
+			if err != nil {
+				return nil, err
+			}
+			in.ExclusiveStartKey = page.lastKey
+			return page, nil
+		}, yield)
+	}
+}
+
+// Scan reads a page of items of whole table. Pass Page.NextToken by QueryOptionStartToken to read the next page
+func (d *DynamoDB) Scan(ctx context.Context, queryOpts ...QueryOption) (*Page, error) {
+	qc, err := createQueryConfig(queryOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	in, err := d.scanInput(qc)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.scanPage(ctx, qc, in)
+}
+
+// ScanAll returns iterator of all items of whole table. Pages are read on demand
+func (d *DynamoDB) ScanAll(ctx context.Context, queryOpts ...QueryOption) iter.Seq2[map[string]types.AttributeValue, error] {
+	return func(yield func(map[string]types.AttributeValue, error) bool) {
+		qc, err := createQueryConfig(queryOpts...)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		in, err := d.scanInput(qc)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		paginate(func() (*Page, error) {
+			page, err := d.scanPage(ctx, qc, in)
+			if err != nil {
+				return nil, err
+			}
+			in.ExclusiveStartKey = page.lastKey
+			return page, nil
+		}, yield)
+	}
+}
+
+// paginate yields items of pages returned by next until the last page
+func paginate(next func() (*Page, error), yield func(map[string]types.AttributeValue, error) bool) {
+	for {
+		page, err := next()
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		for _, item := range page.Items {
+			if !yield(item, nil) {
+				return
+			}
+		}
+
+		if page.NextToken == "" {
+			return
+		}
+	}
+}
+
+func (d *DynamoDB) queryInput(qc *queryConfig, keyCond expression.KeyConditionBuilder) (*dynamodb.QueryInput, error) {
+	expr, err := qc.expression(&keyCond)
+	if err != nil {
+		return nil, err
+	}
+
+	startKey, err := decodeToken(qc.startToken)
+	if err != nil {
+		return nil, err
+	}
+
+	in := &dynamodb.QueryInput{
+		TableName:                 aws.String(qc.table(d)),
+		KeyConditionExpression:    expr.KeyCondition(),
+		FilterExpression:          expr.Filter(),
+		ProjectionExpression:      expr.Projection(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ExclusiveStartKey:         startKey,
+		ScanIndexForward:          aws.Bool(!qc.descending),
+	}
+	qc.apply(&in.IndexName, &in.ConsistentRead, &in.Limit, &in.ReturnConsumedCapacity)
+
+	return in, nil
+}
+
+func (d *DynamoDB) scanInput(qc *queryConfig) (*dynamodb.ScanInput, error) {
+	in := &dynamodb.ScanInput{
+		TableName: aws.String(qc.table(d)),
+	}
+
+	if qc.filter != nil || len(qc.projection) > 0 {
+		expr, err := qc.expression(nil)
+		if err != nil {
+			return nil, err
+		}
+		in.FilterExpression = expr.Filter()
+		in.ProjectionExpression = expr.Projection()
+		in.ExpressionAttributeNames = expr.Names()
+		in.ExpressionAttributeValues = expr.Values()
+	}
+
+	startKey, err := decodeToken(qc.startToken)
+	if err != nil {
+		return nil, err
+	}
+	in.ExclusiveStartKey = startKey
+
+	qc.apply(&in.IndexName, &in.ConsistentRead, &in.Limit, &in.ReturnConsumedCapacity)
+
+	return in, nil
+}
+
+func (d *DynamoDB) queryPage(ctx context.Context, qc *queryConfig, in *dynamodb.QueryInput) (*Page, error) {
+	res, err := d.DynamoDB.Query(ctx, in, qc.clientOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPage(res.Items, res.Count, res.ScannedCount, res.LastEvaluatedKey, res.ConsumedCapacity)
+}
+
+func (d *DynamoDB) scanPage(ctx context.Context, qc *queryConfig, in *dynamodb.ScanInput) (*Page, error) {
+	res, err := d.DynamoDB.Scan(ctx, in, qc.clientOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPage(res.Items, res.Count, res.ScannedCount, res.LastEvaluatedKey, res.ConsumedCapacity)
+}
+
+func newPage(items []map[string]types.AttributeValue, count, scannedCount int32, lastKey map[string]types.AttributeValue, consumed *types.ConsumedCapacity) (*Page, error) {
+	token, err := encodeToken(lastKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Page{
+		Items:            items,
+		Count:            count,
+		ScannedCount:     scannedCount,
+		NextToken:        token,
+		ConsumedCapacity: capacityUnits(consumed),
+		lastKey:          lastKey,
+	}, nil
+}
+
+// capacityUnits returns total capacity units of consumed. 0 is returned for nil
+func capacityUnits(consumed *types.ConsumedCapacity) float64 {
+	if consumed == nil || consumed.CapacityUnits == nil {
+		return 0
+	}
+	return *consumed.CapacityUnits
+}
+
+// table returns the table name to read
+func (qc *queryConfig) table(d *DynamoDB) string {
+	if qc.tableName != "" {
+		return qc.tableName
+	}
+	return d.DefaultTableName
+}
+
+// expression builds key condition, filter and projection expressions. keyCond may be nil
+func (qc *queryConfig) expression(keyCond *expression.KeyConditionBuilder) (expression.Expression, error) {
+	builder := expression.NewBuilder()
+
+	if keyCond != nil {
+		builder = builder.WithKeyCondition(*keyCond)
+	}
+	if qc.filter != nil {
+		builder = builder.WithFilter(*qc.filter)
+	}
+	if len(qc.projection) > 0 {
+		proj := expression.NamesList(expression.Name(qc.projection[0]))
+		for _, name := range qc.projection[1:] {
+			proj = proj.AddNames(expression.Name(name))
+		}
+		builder = builder.WithProjection(proj)
+	}
+
+	return builder.Build()
+}
+
+// apply sets index, consistent read, limit and consumed capacity on input
+func (qc *queryConfig) apply(indexName **string, consistentRead **bool, limit **int32, returnConsumed *types.ReturnConsumedCapacity) {
+	if qc.indexName != "" {
+		*indexName = aws.String(qc.indexName)
+	}
+	if qc.consistentRead {
+		*consistentRead = aws.Bool(true)
+	}
+	if qc.limit > 0 {
+		*limit = aws.Int32(qc.limit)
+	}
+	if qc.returnConsumedCapacity {
+		*returnConsumed = types.ReturnConsumedCapacityTotal
+	}
+}
+
+// tokenValue is JSON representation of key attribute value
+type tokenValue struct {
+	S *string `json:"S,omitempty"`
+	N *string `json:"N,omitempty"`
+	B []byte  `json:"B,omitempty"`
+}
+
+// encodeToken returns opaque token of LastEvaluatedKey. key attributes are S, N or B
+func encodeToken(key map[string]types.AttributeValue) (string, error) {
+	if len(key) == 0 {
+		return "", nil
+	}
+
+	values := make(map[string]tokenValue, len(key))
+	for name, av := range key {
+		switch v := av.(type) {
+		case *types.AttributeValueMemberS:
+			values[name] = tokenValue{S: aws.String(v.Value)}
+		case *types.AttributeValueMemberN:
+			values[name] = tokenValue{N: aws.String(v.Value)}
+		case *types.AttributeValueMemberB:
+			values[name] = tokenValue{B: v.Value}
+		default:
+			return "", fmt.Errorf("unsupported key attribute type: %s %T", name, av)
+		}
+	}
+
+	b, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// decodeToken returns LastEvaluatedKey of token. nil is returned for empty token
+func decodeToken(token string) (map[string]types.AttributeValue, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	var values map[string]tokenValue
+	err = json.Unmarshal(b, &values)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	key := make(map[string]types.AttributeValue, len(values))
+	for name, v := range values {
+		switch {
+		case v.S != nil:
+			key[name] = &types.AttributeValueMemberS{Value: *v.S}
+		case v.N != nil:
+			key[name] = &types.AttributeValueMemberN{Value: *v.N}
+		case v.B != nil:
+			key[name] = &types.AttributeValueMemberB{Value: v.B}
+		default:
+			return nil, fmt.Errorf("%w: empty attribute %s", ErrInvalidToken, name)
+		}
+	}
+
+	return key, nil
+}
@@ -0,0 +1,243 @@
+package dynamodb_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	awsdynamodb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+
+	"github.com/rssh-jp/data-access-library/aws/dynamodb"
+)
+
+type order struct {
+	UserID  string `dynamodbav:"user_id"`
+	OrderID int    `dynamodbav:"order_id"`
+	Status  string `dynamodbav:"status"`
+}
+
+// newOrderTable returns DynamoDB instance with orders table of 2 users having 3 orders each.
+// The table has local index status_index sorted by status
+func newOrderTable(t *testing.T, c *testClient) *dynamodb.DynamoDB {
+	t.Helper()
+
+	d := c.newDynamoDB()
+	d.DefaultTableName = "orders"
+
+	err := d.CreateTableFromDefinition(&dynamodb.TableDefinition{
+		Name:         "orders",
+		PartitionKey: dynamodb.KeyAttribute{Name: "user_id", Type: dynamodb.AttributeTypeString},
+		SortKey:      &dynamodb.KeyAttribute{Name: "order_id", Type: dynamodb.AttributeTypeNumber},
+		LocalIndexes: []*dynamodb.IndexDefinition{
+			{Name: "status_index", SortKey: &dynamodb.KeyAttribute{Name: "status", Type: dynamodb.AttributeTypeString}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, user := range []string{"u1", "u2"} {
+		for i := 1; i <= 3; i++ {
+			err := dynamodb.PutItem(context.Background(), d, order{UserID: user, OrderID: i, Status: "open"})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	return d
+}
+
+func TestQuery(t *testing.T) {
+	keyCond := expression.Key("user_id").Equal(expression.Value("u1"))
+
+	t.Run("Page", func(t *testing.T) {
+		c := newTestClient(t)
+		d := newOrderTable(t, c)
+
+		var actual []int
+		var token string
+		pages := 0
+		for {
+			page, err := d.Query(context.Background(), keyCond, dynamodb.QueryOptionLimit(2), dynamodb.QueryOptionStartToken(token))
+			if err != nil {
+				t.Fatal(err)
+			}
+			pages++
+
+			var orders []order
+			err = page.Unmarshal(&orders)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, o := range orders {
+				if o.UserID != "u1" {
+					t.Errorf("Could not match user id.\nexpect: %s\nactual: %s", "u1", o.UserID)
+				}
+				actual = append(actual, o.OrderID)
+			}
+
+			token = page.NextToken
+			if token == "" {
+				break
+			}
+		}
+
+		expect := []int{1, 2, 3}
+		if !reflect.DeepEqual(expect, actual) {
+			t.Errorf("Could not match order ids.\nexpect: %v\nactual: %v", expect, actual)
+		}
+		if pages != 2 {
+			t.Errorf("Could not match pages.\nexpect: %d\nactual: %d", 2, pages)
+		}
+	})
+	t.Run("Request", func(t *testing.T) {
+		c := newTestClient(t)
+		d := newOrderTable(t, c)
+
+		_, err := d.Query(context.Background(), keyCond,
+			dynamodb.QueryOptionFilter(expression.Name("note").Equal(expression.Value("gift"))),
+			dynamodb.QueryOptionProjection("order_id", "status"),
+			dynamodb.QueryOptionIndex("status_index"),
+			dynamodb.QueryOptionConsistentRead(),
+			dynamodb.QueryOptionDescending(),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req := lastRequest[awsdynamodb.QueryInput](t, c)
+		actual := map[string]any{
+			"TableName":              aws.ToString(req.TableName),
+			"IndexName":              aws.ToString(req.IndexName),
+			"ConsistentRead":         aws.ToBool(req.ConsistentRead),
+			"ScanIndexForward":       aws.ToBool(req.ScanIndexForward),
+			"KeyConditionExpression": aws.ToString(req.KeyConditionExpression),
+			"FilterExpression":       aws.ToString(req.FilterExpression),
+			"ProjectionExpression":   aws.ToString(req.ProjectionExpression),
+		}
+		expect := map[string]any{
+			"TableName":              "orders",
+			"IndexName":              "status_index",
+			"ConsistentRead":         true,
+			"ScanIndexForward":       false,
+			"KeyConditionExpression": "#1 = :1",
+			"FilterExpression":       "#0 = :0",
+			"ProjectionExpression":   "#2, #3",
+		}
+		for k, v := range expect {
+			if !reflect.DeepEqual(v, actual[k]) {
+				t.Errorf("Could not match %s.\nexpect: %v\nactual: %v", k, v, actual[k])
+			}
+		}
+	})
+	t.Run("Filters", func(t *testing.T) {
+		c := newTestClient(t)
+		d := newOrderTable(t, c)
+
+		for i, status := range []string{"closed", "pending"} {
+			err := dynamodb.PutItem(context.Background(), d, order{UserID: "u1", OrderID: 4 + i, Status: status})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// filters are combined by AND
+		page, err := d.Query(context.Background(), keyCond,
+			dynamodb.QueryOptionFilter(expression.Name("status").NotEqual(expression.Value("closed"))),
+			dynamodb.QueryOptionFilter(expression.Name("status").NotEqual(expression.Value("pending"))),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var actual []order
+		err = page.Unmarshal(&actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect := []order{{UserID: "u1", OrderID: 1, Status: "open"}, {UserID: "u1", OrderID: 2, Status: "open"}, {UserID: "u1", OrderID: 3, Status: "open"}}
+		if !reflect.DeepEqual(expect, actual) {
+			t.Errorf("Could not match orders.\nexpect: %v\nactual: %v", expect, actual)
+		}
+	})
+	t.Run("QueryAll", func(t *testing.T) {
+		c := newTestClient(t)
+		d := newOrderTable(t, c)
+
+		var actual []int
+		for item, err := range d.QueryAll(context.Background(), keyCond, dynamodb.QueryOptionLimit(1), dynamodb.QueryOptionDescending()) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			var o order
+			err = attributevalue.UnmarshalMap(item, &o)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual = append(actual, o.OrderID)
+		}
+
+		expect := []int{3, 2, 1}
+		if !reflect.DeepEqual(expect, actual) {
+			t.Errorf("Could not match order ids.\nexpect: %v\nactual: %v", expect, actual)
+		}
+	})
+	t.Run("Invalid token", func(t *testing.T) {
+		c := newTestClient(t)
+		d := newOrderTable(t, c)
+
+		_, err := d.Query(context.Background(), keyCond, dynamodb.QueryOptionStartToken("!!"))
+		if !errors.Is(err, dynamodb.ErrInvalidToken) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrInvalidToken, err)
+		}
+	})
+}
+
+func TestScan(t *testing.T) {
+	c := newTestClient(t)
+	d := newOrderTable(t, c)
+
+	t.Run("ScanAll", func(t *testing.T) {
+		count := 0
+		for _, err := range d.ScanAll(context.Background(), dynamodb.QueryOptionLimit(4)) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			count++
+		}
+
+		if count != 6 {
+			t.Errorf("Could not match count.\nexpect: %d\nactual: %d", 6, count)
+		}
+	})
+	t.Run("Break", func(t *testing.T) {
+		count := 0
+		for range d.ScanAll(context.Background(), dynamodb.QueryOptionLimit(1)) {
+			count++
+			if count == 2 {
+				break
+			}
+		}
+
+		if req := lastRequest[awsdynamodb.ScanInput](t, c); req.ExclusiveStartKey == nil {
+			t.Errorf("Bug. Second page is read. But no start key: %v", req)
+		}
+	})
+	t.Run("Without expression", func(t *testing.T) {
+		page, err := d.Scan(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.Count != 6 || page.NextToken != "" {
+			t.Errorf("Could not match page. actual: %d, %q", page.Count, page.NextToken)
+		}
+		if req := lastRequest[awsdynamodb.ScanInput](t, c); req.FilterExpression != nil {
+			t.Errorf("Bug. No filter. But set: %v", *req.FilterExpression)
+		}
+	})
+}
@@ -0,0 +1,144 @@
+package dynamodb
+
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+type queryConfig struct {
+	tableName      string
+	indexName      string
+	filter         *expression.ConditionBuilder
+	projection     []string
+	consistentRead bool
+	limit          int32
+	startToken     string
+	descending     bool
+	clientOpts     []func(*dynamodb.Options)
+
+	returnConsumedCapacity bool
+}
+
+func newQueryConfig() *queryConfig {
+	return &queryConfig{
+		tableName:      "",
+		indexName:      "",
+		filter:         nil,
+		projection:     nil,
+		consistentRead: false,
+		limit:          0,
+		startToken:     "",
+		descending:     false,
+		clientOpts:     nil,
+
+		returnConsumedCapacity: false,
+	}
+}
+
+func createQueryConfig(queryOpts ...QueryOption) (*queryConfig, error) {
+	qc := newQueryConfig()
+
+	for _, opt := range queryOpts {
+		err := opt(qc)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return qc, nil
+}
+
+// QueryOption is functional option pattern option for Query and Scan
+type QueryOption func(*queryConfig) error
+
+// QueryOptionTable returns QueryOption instance reading table instead of DefaultTableName
+func QueryOptionTable(name string) func(c *queryConfig) error {
+	return func(c *queryConfig) error {
+		if name == "" {
+			return errors.New("table name is empty")
+		}
+		c.tableName = name
+		return nil
+	}
+}
+
+// QueryOptionIndex returns QueryOption instance reading secondary index
+func QueryOptionIndex(name string) func(c *queryConfig) error {
+	return func(c *queryConfig) error {
+		c.indexName = name
+		return nil
+	}
+}
+
+// QueryOptionFilter returns QueryOption instance with filter expression applied after items are read.
+// Filters of the option given more than once are combined by AND
+func QueryOptionFilter(filter expression.ConditionBuilder) func(c *queryConfig) error {
+	return func(c *queryConfig) error {
+		if c.filter != nil {
+			filter = c.filter.And(filter)
+		}
+		c.filter = &filter
+		return nil
+	}
+}
+
+// QueryOptionProjection returns QueryOption instance reading only the attributes
+func QueryOptionProjection(names ...string) func(c *queryConfig) error {
+	return func(c *queryConfig) error {
+		c.projection = append(c.projection, names...)
+		return nil
+	}
+}
+
+// QueryOptionConsistentRead returns QueryOption instance with strongly consistent read
+func QueryOptionConsistentRead() func(c *queryConfig) error {
+	return func(c *queryConfig) error {
+		c.consistentRead = true
+		return nil
+	}
+}
+
+// QueryOptionLimit returns QueryOption instance with the maximum number of items evaluated per page
+func QueryOptionLimit(n int32) func(c *queryConfig) error {
+	return func(c *queryConfig) error {
+		if n < 1 {
+			return errors.New("limit must be positive")
+		}
+		c.limit = n
+		return nil
+	}
+}
+
+// QueryOptionStartToken returns QueryOption instance starting after the page of token. token is Page.NextToken
+func QueryOptionStartToken(token string) func(c *queryConfig) error {
+	return func(c *queryConfig) error {
+		c.startToken = token
+		return nil
+	}
+}
+
+// QueryOptionDescending returns QueryOption instance reading Query result in descending order of sort key
+func QueryOptionDescending() func(c *queryConfig) error {
+	return func(c *queryConfig) error {
+		c.descending = true
+		return nil
+	}
+}
+
+// QueryOptionReturnConsumedCapacity returns QueryOption instance setting Page.ConsumedCapacity
+func QueryOptionReturnConsumedCapacity() func(c *queryConfig) error {
+	return func(c *queryConfig) error {
+		c.returnConsumedCapacity = true
+		return nil
+	}
+}
+
+// QueryOptionClientOptions returns QueryOption instance with request options
+func QueryOptionClientOptions(optFns ...func(*dynamodb.Options)) func(c *queryConfig) error {
+	return func(c *queryConfig) error {
+		c.clientOpts = append(c.clientOpts, optFns...)
+		return nil
+	}
+}
@@ -0,0 +1,706 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+	"golang.org/x/time/rate"
+)
+
+// capacityKind is read or write capacity
+type capacityKind int
+
+const (
+	readCapacity capacityKind = iota
+	writeCapacity
+)
+
+type rateLimitConfig struct {
+	capacities    map[string]Throughput
+	maxRetries    int
+	recovery      time.Duration
+	describeRetry time.Duration
+}
+
+func newRateLimitConfig() *rateLimitConfig {
+	return &rateLimitConfig{
+		capacities:    make(map[string]Throughput),
+		maxRetries:    3,
+		recovery:      time.Minute,
+		describeRetry: time.Minute,
+	}
+}
+
+func createRateLimitConfig(rateOpts ...RateLimitOption) (*rateLimitConfig, error) {
+	rc := newRateLimitConfig()
+
+	for _, opt := range rateOpts {
+		err := opt(rc)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return rc, nil
+}
+
+// RateLimitOption is functional option pattern option for RateLimiter
+type RateLimitOption func(*rateLimitConfig) error
+
+// RateLimitOptionCapacity returns RateLimitOption instance limiting the table to capacity units per second instead of its provisioned throughput.
+// 0 of Read or Write is unlimited
+func RateLimitOptionCapacity(tableName string, capacity Throughput) func(c *rateLimitConfig) error {
+	return func(c *rateLimitConfig) error {
+		if tableName == "" {
+			return errors.New("table name is empty")
+		}
+		if capacity.Read < 0 || capacity.Write < 0 {
+			return errors.New("capacity must not be negative")
+		}
+		c.capacities[tableName] = capacity
+		return nil
+	}
+}
+
+// RateLimitOptionRetry returns RateLimitOption instance with the maximum number of retries of throttled request. 3 by default
+func RateLimitOptionRetry(maxRetries int) func(c *rateLimitConfig) error {
+	return func(c *rateLimitConfig) error {
+		if maxRetries < 0 {
+			return errors.New("max retries must not be negative")
+		}
+		c.maxRetries = maxRetries
+		return nil
+	}
+}
+
+// RateLimitOptionRecovery returns RateLimitOption instance with duration in which the rate lowered by throttles recovers to the capacity.
+// 1 minute by default
+func RateLimitOptionRecovery(d time.Duration) func(c *rateLimitConfig) error {
+	return func(c *rateLimitConfig) error {
+		if d <= 0 {
+			return errors.New("recovery must be positive")
+		}
+		c.recovery = d
+		return nil
+	}
+}
+
+// RateLimitOptionDescribeRetry returns RateLimitOption instance with interval of reading the capacity again after DescribeTable failed.
+// Requests of the table are not limited meanwhile. 1 minute by default
+func RateLimitOptionDescribeRetry(d time.Duration) func(c *rateLimitConfig) error {
+	return func(c *rateLimitConfig) error {
+		if d <= 0 {
+			return errors.New("describe retry interval must be positive")
+		}
+		c.describeRetry = d
+		return nil
+	}
+}
+
+// CapacityUsage is capacity consumed by requests of the table through RateLimiter
+type CapacityUsage struct {
+	// Read and Write are capacity units consumed in total
+	Read  float64
+	Write float64
+	// Throttles is the number of throttled requests including batches with unprocessed items
+	Throttles int
+	// ReadLimit and WriteLimit are capacity units per second allowed now. 0 is unlimited
+	ReadLimit  float64
+	WriteLimit float64
+	// CapacityError is the error of DescribeTable reading the capacity. The table is not limited while it is set
+	CapacityError error
+}
+
+// RateLimiter is Client limiting capacity units consumed per second of every table by token buckets.
+// Requests wait for the bucket of the table, and the capacity consumed by them is taken from the bucket after the response by ReturnConsumedCapacity,
+// so large items and queries take more tokens than small ones.
+// The capacity of table is the provisioned throughput of DescribeTable unless set by RateLimitOptionCapacity. On-demand tables are not limited.
+// Capacity consumed by indexes is taken from the bucket of the table.
+// The rate is halved on every throttle and throttled requests are retried, then the rate recovers to the capacity gradually
+type RateLimiter struct {
+	client Client
+	config *rateLimitConfig
+
+	mu      sync.Mutex
+	buckets map[string]*tableBuckets
+	usages  map[string]*CapacityUsage
+}
+
+// tableBuckets is token buckets of the table. nil bucket is unlimited
+type tableBuckets struct {
+	read  *bucket
+	write *bucket
+	// err is the error of DescribeTable, and the capacity is read again after retryAt
+	err     error
+	retryAt time.Time
+}
+
+// bucket is token bucket of capacity units lowered by throttles
+type bucket struct {
+	limiter  *rate.Limiter
+	capacity float64
+	updated  time.Time
+}
+
+func newBucket(capacity int64) *bucket {
+	if capacity <= 0 {
+		return nil
+	}
+	return &bucket{
+		limiter:  rate.NewLimiter(rate.Limit(capacity), int(capacity)),
+		capacity: float64(capacity),
+		updated:  time.Now(),
+	}
+}
+
+// setLimit sets units per second of the bucket
+func (b *bucket) setLimit(limit float64, now time.Time) {
+	b.limiter.SetLimitAt(now, rate.Limit(limit))
+	b.limiter.SetBurstAt(now, int(math.Ceil(limit)))
+	b.updated = now
+}
+
+// recover raises the rate lowered by throttles toward the capacity linearly in recovery
+func (b *bucket) recover(recovery time.Duration, now time.Time) {
+	limit := float64(b.limiter.Limit())
+	if limit >= b.capacity {
+		return
+	}
+	b.setLimit(min(b.capacity, limit+b.capacity*float64(now.Sub(b.updated))/float64(recovery)), now)
+}
+
+// throttle halves the rate and drains the bucket so that following requests wait
+func (b *bucket) throttle(now time.Time) {
+	b.setLimit(max(1, float64(b.limiter.Limit())/2), now)
+	b.limiter.ReserveN(now, b.limiter.Burst())
+}
+
+// take takes capacity units consumed by the request from the bucket. Units beyond the tokens are owed by following requests
+func (b *bucket) take(units float64, now time.Time) {
+	// a token is taken before the request
+	n := int(math.Ceil(units)) - 1
+	// reservation beyond the burst fails, so the units are owed in chunks of the burst
+	for burst := b.limiter.Burst(); n > 0; n -= burst {
+		b.limiter.ReserveN(now, min(n, burst))
+	}
+}
+
+// NewRateLimiter returns RateLimiter instance sending requests to client
+func NewRateLimiter(client Client, rateOpts ...RateLimitOption) (*RateLimiter, error) {
+	rc, err := createRateLimitConfig(rateOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RateLimiter{
+		client:  client,
+		config:  rc,
+		buckets: make(map[string]*tableBuckets),
+		usages:  make(map[string]*CapacityUsage),
+	}, nil
+}
+
+// EnableRateLimit wraps DynamoDB client of d by RateLimiter. The returned RateLimiter reports consumed capacity
+func (d *DynamoDB) EnableRateLimit(rateOpts ...RateLimitOption) (*RateLimiter, error) {
+	l, err := NewRateLimiter(d.DynamoDB, rateOpts...)
+	if err != nil {
+		return nil, err
+	}
+	d.DynamoDB = l
+
+	return l, nil
+}
+
+// Usage returns capacity consumed by requests of the table
+func (l *RateLimiter) Usage(tableName string) CapacityUsage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.usage(tableName)
+}
+
+// Usages returns capacity consumed by requests of every table
+func (l *RateLimiter) Usages() map[string]CapacityUsage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ret := make(map[string]CapacityUsage, len(l.usages))
+	for name := range l.usages {
+		ret[name] = l.usage(name)
+	}
+	return ret
+}
+
+// usage returns CapacityUsage of the table with the current limits. l.mu must be locked
+func (l *RateLimiter) usage(tableName string) CapacityUsage {
+	var ret CapacityUsage
+	if u, ok := l.usages[tableName]; ok {
+		ret = *u
+	}
+	if b, ok := l.buckets[tableName]; ok {
+		if b.read != nil {
+			ret.ReadLimit = float64(b.read.limiter.Limit())
+		}
+		if b.write != nil {
+			ret.WriteLimit = float64(b.write.limiter.Limit())
+		}
+		ret.CapacityError = b.err
+	}
+	return ret
+}
+
+// tableBuckets returns buckets of the table. Capacity is read by DescribeTable at the first request of the table.
+// When DescribeTable fails, unlimited buckets with the error are kept and the capacity is read again after describeRetry
+func (l *RateLimiter) tableBuckets(ctx context.Context, tableName string) *tableBuckets {
+	l.mu.Lock()
+	b, ok := l.buckets[tableName]
+	l.mu.Unlock()
+	if ok && (b.err == nil || time.Now().Before(b.retryAt)) {
+		return b
+	}
+
+	capacity, ok := l.config.capacities[tableName]
+	if !ok {
+		res, err := l.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(tableName)})
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return l.failBuckets(tableName, err)
+		}
+		if pt := res.Table.ProvisionedThroughput; pt != nil {
+			capacity = Throughput{Read: aws.ToInt64(pt.ReadCapacityUnits), Write: aws.ToInt64(pt.WriteCapacityUnits)}
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// another request may have read the capacity meanwhile
+	if b, ok := l.buckets[tableName]; ok && b.err == nil {
+		return b
+	}
+	b = &tableBuckets{read: newBucket(capacity.Read), write: newBucket(capacity.Write)}
+	l.buckets[tableName] = b
+
+	return b
+}
+
+// failBuckets keeps unlimited buckets with the error of DescribeTable until describeRetry passes
+func (l *RateLimiter) failBuckets(tableName string, err error) *tableBuckets {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// another request may have read the capacity meanwhile
+	if b, ok := l.buckets[tableName]; ok && b.err == nil {
+		return b
+	}
+	b := &tableBuckets{err: err, retryAt: time.Now().Add(l.config.describeRetry)}
+	l.buckets[tableName] = b
+	// the error is reported by Usages even if no capacity is consumed
+	if _, ok := l.usages[tableName]; !ok {
+		l.usages[tableName] = &CapacityUsage{}
+	}
+
+	return b
+}
+
+// bucket returns the bucket of kind. nil is returned when it is unlimited
+func (b *tableBuckets) bucket(kind capacityKind) *bucket {
+	if b == nil {
+		return nil
+	}
+	if kind == readCapacity {
+		return b.read
+	}
+	return b.write
+}
+
+// wait waits for a token of every table
+func (l *RateLimiter) wait(ctx context.Context, kind capacityKind, tableNames []string) error {
+	for _, name := range tableNames {
+		b := l.tableBuckets(ctx, name).bucket(kind)
+		if b == nil {
+			continue
+		}
+
+		l.mu.Lock()
+		b.recover(l.config.recovery, time.Now())
+		l.mu.Unlock()
+
+		err := b.limiter.Wait(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// consume records consumed capacity and takes it from the buckets. Capacity without table name belongs to tableNames[0]
+func (l *RateLimiter) consume(kind capacityKind, tableNames []string, consumed []types.ConsumedCapacity) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	for _, cc := range consumed {
+		name := aws.ToString(cc.TableName)
+		if name == "" && len(tableNames) > 0 {
+			name = tableNames[0]
+		}
+		units := aws.ToFloat64(cc.CapacityUnits)
+
+		u, ok := l.usages[name]
+		if !ok {
+			u = &CapacityUsage{}
+			l.usages[name] = u
+		}
+		if kind == readCapacity {
+			u.Read += units
+		} else {
+			u.Write += units
+		}
+
+		if b := l.buckets[name].bucket(kind); b != nil {
+			b.take(units, now)
+		}
+	}
+}
+
+// throttle lowers the rate of the tables. It reports whether any of them is limited
+func (l *RateLimiter) throttle(kind capacityKind, tableNames []string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	limited := false
+	for _, name := range tableNames {
+		u, ok := l.usages[name]
+		if !ok {
+			u = &CapacityUsage{}
+			l.usages[name] = u
+		}
+		u.Throttles++
+
+		if b := l.buckets[name].bucket(kind); b != nil {
+			b.throttle(now)
+			limited = true
+		}
+	}
+
+	return limited
+}
+
+// forget drops the buckets of the table so that the capacity is read again
+func (l *RateLimiter) forget(tableName string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.buckets, tableName)
+}
+
+// isThrottle reports whether err is caused by exceeding capacity or request rate
+func isThrottle(err error) bool {
+	var tce *types.TransactionCanceledException
+	if errors.As(err, &tce) {
+		for _, r := range tce.CancellationReasons {
+			switch aws.ToString(r.Code) {
+			case "ThrottlingError", "ProvisionedThroughputExceeded":
+				return true
+			}
+		}
+		return false
+	}
+
+	var ae smithy.APIError
+	if !errors.As(err, &ae) {
+		return false
+	}
+	switch ae.ErrorCode() {
+	case "ProvisionedThroughputExceededException", "ThrottlingException", "RequestLimitExceeded":
+		return true
+	}
+	return false
+}
+
+// limit runs fn after waiting for tokens of the tables and takes the capacity consumed by fn.
+// settle returns the consumed capacity of the output and whether it was throttled partially such as unprocessed items of batch.
+// Throttled fn is retried when any of the tables is limited
+func limit[T any](ctx context.Context, l *RateLimiter, kind capacityKind, tableNames []string, fn func() (T, error), settle func(T) ([]types.ConsumedCapacity, bool)) (T, error) {
+	for attempt := 0; ; attempt++ {
+		err := l.wait(ctx, kind, tableNames)
+		if err != nil {
+			var zero T
+			return zero, err
+		}
+
+		out, err := fn()
+		if isThrottle(err) {
+			if l.throttle(kind, tableNames) && attempt < l.config.maxRetries {
+				continue
+			}
+			return out, err
+		}
+		if err != nil {
+			return out, err
+		}
+
+		consumed, throttled := settle(out)
+		l.consume(kind, tableNames, consumed)
+		if throttled {
+			l.throttle(kind, tableNames)
+		}
+		return out, nil
+	}
+}
+
+// returnConsumedCapacity returns mode making DynamoDB return consumed capacity. The mode requested by the caller is kept
+func returnConsumedCapacity(mode types.ReturnConsumedCapacity) types.ReturnConsumedCapacity {
+	if requestedCapacity(mode) {
+		return mode
+	}
+	return types.ReturnConsumedCapacityTotal
+}
+
+// requestedCapacity reports whether mode returns consumed capacity
+func requestedCapacity(mode types.ReturnConsumedCapacity) bool {
+	return mode != "" && mode != types.ReturnConsumedCapacityNone
+}
+
+// capacities returns cc as slice
+func capacities(cc *types.ConsumedCapacity) []types.ConsumedCapacity {
+	if cc == nil {
+		return nil
+	}
+	return []types.ConsumedCapacity{*cc}
+}
+
+// CreateTable implements Client
+func (l *RateLimiter) CreateTable(ctx context.Context, params *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error) {
+	l.forget(aws.ToString(params.TableName))
+	return l.client.CreateTable(ctx, params, optFns...)
+}
+
+// DescribeTable implements Client
+func (l *RateLimiter) DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	return l.client.DescribeTable(ctx, params, optFns...)
+}
+
+// UpdateTable implements Client. The capacity of the table is read again
+func (l *RateLimiter) UpdateTable(ctx context.Context, params *dynamodb.UpdateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateTableOutput, error) {
+	defer l.forget(aws.ToString(params.TableName))
+	return l.client.UpdateTable(ctx, params, optFns...)
+}
+
+// DeleteTable implements Client
+func (l *RateLimiter) DeleteTable(ctx context.Context, params *dynamodb.DeleteTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteTableOutput, error) {
+	defer l.forget(aws.ToString(params.TableName))
+	return l.client.DeleteTable(ctx, params, optFns...)
+}
+
+// ListTables implements Client
+func (l *RateLimiter) ListTables(ctx context.Context, params *dynamodb.ListTablesInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ListTablesOutput, error) {
+	return l.client.ListTables(ctx, params, optFns...)
+}
+
+// DescribeTimeToLive implements Client
+func (l *RateLimiter) DescribeTimeToLive(ctx context.Context, params *dynamodb.DescribeTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTimeToLiveOutput, error) {
+	return l.client.DescribeTimeToLive(ctx, params, optFns...)
+}
+
+// UpdateTimeToLive implements Client
+func (l *RateLimiter) UpdateTimeToLive(ctx context.Context, params *dynamodb.UpdateTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateTimeToLiveOutput, error) {
+	return l.client.UpdateTimeToLive(ctx, params, optFns...)
+}
+
+// PutItem implements Client
+func (l *RateLimiter) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	in := *params
+	in.ReturnConsumedCapacity = returnConsumedCapacity(params.ReturnConsumedCapacity)
+
+	return limit(ctx, l, writeCapacity, []string{aws.ToString(in.TableName)}, func() (*dynamodb.PutItemOutput, error) {
+		return l.client.PutItem(ctx, &in, optFns...)
+	}, func(out *dynamodb.PutItemOutput) ([]types.ConsumedCapacity, bool) {
+		consumed := capacities(out.ConsumedCapacity)
+		if !requestedCapacity(params.ReturnConsumedCapacity) {
+			out.ConsumedCapacity = nil
+		}
+		return consumed, false
+	})
+}
+
+// GetItem implements Client
+func (l *RateLimiter) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	in := *params
+	in.ReturnConsumedCapacity = returnConsumedCapacity(params.ReturnConsumedCapacity)
+
+	return limit(ctx, l, readCapacity, []string{aws.ToString(in.TableName)}, func() (*dynamodb.GetItemOutput, error) {
+		return l.client.GetItem(ctx, &in, optFns...)
+	}, func(out *dynamodb.GetItemOutput) ([]types.ConsumedCapacity, bool) {
+		consumed := capacities(out.ConsumedCapacity)
+		if !requestedCapacity(params.ReturnConsumedCapacity) {
+			out.ConsumedCapacity = nil
+		}
+		return consumed, false
+	})
+}
+
+// UpdateItem implements Client
+func (l *RateLimiter) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	in := *params
+	in.ReturnConsumedCapacity = returnConsumedCapacity(params.ReturnConsumedCapacity)
+
+	return limit(ctx, l, writeCapacity, []string{aws.ToString(in.TableName)}, func() (*dynamodb.UpdateItemOutput, error) {
+		return l.client.UpdateItem(ctx, &in, optFns...)
+	}, func(out *dynamodb.UpdateItemOutput) ([]types.ConsumedCapacity, bool) {
+		consumed := capacities(out.ConsumedCapacity)
+		if !requestedCapacity(params.ReturnConsumedCapacity) {
+			out.ConsumedCapacity = nil
+		}
+		return consumed, false
+	})
+}
+
+// DeleteItem implements Client
+func (l *RateLimiter) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	in := *params
+	in.ReturnConsumedCapacity = returnConsumedCapacity(params.ReturnConsumedCapacity)
+
+	return limit(ctx, l, writeCapacity, []string{aws.ToString(in.TableName)}, func() (*dynamodb.DeleteItemOutput, error) {
+		return l.client.DeleteItem(ctx, &in, optFns...)
+	}, func(out *dynamodb.DeleteItemOutput) ([]types.ConsumedCapacity, bool) {
+		consumed := capacities(out.ConsumedCapacity)
+		if !requestedCapacity(params.ReturnConsumedCapacity) {
+			out.ConsumedCapacity = nil
+		}
+		return consumed, false
+	})
+}
+
+// BatchGetItem implements Client. Unprocessed keys lower the rate like throttle
+func (l *RateLimiter) BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	in := *params
+	in.ReturnConsumedCapacity = returnConsumedCapacity(params.ReturnConsumedCapacity)
+
+	return limit(ctx, l, readCapacity, slices.Sorted(maps.Keys(in.RequestItems)), func() (*dynamodb.BatchGetItemOutput, error) {
+		return l.client.BatchGetItem(ctx, &in, optFns...)
+	}, func(out *dynamodb.BatchGetItemOutput) ([]types.ConsumedCapacity, bool) {
+		consumed := out.ConsumedCapacity
+		if !requestedCapacity(params.ReturnConsumedCapacity) {
+			out.ConsumedCapacity = nil
+		}
+		return consumed, len(out.UnprocessedKeys) > 0
+	})
+}
+
+// BatchWriteItem implements Client. Unprocessed items lower the rate like throttle
+func (l *RateLimiter) BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	in := *params
+	in.ReturnConsumedCapacity = returnConsumedCapacity(params.ReturnConsumedCapacity)
+
+	return limit(ctx, l, writeCapacity, slices.Sorted(maps.Keys(in.RequestItems)), func() (*dynamodb.BatchWriteItemOutput, error) {
+		return l.client.BatchWriteItem(ctx, &in, optFns...)
+	}, func(out *dynamodb.BatchWriteItemOutput) ([]types.ConsumedCapacity, bool) {
+		consumed := out.ConsumedCapacity
+		if !requestedCapacity(params.ReturnConsumedCapacity) {
+			out.ConsumedCapacity = nil
+		}
+		return consumed, len(out.UnprocessedItems) > 0
+	})
+}
+
+// Query implements Client
+func (l *RateLimiter) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	in := *params
+	in.ReturnConsumedCapacity = returnConsumedCapacity(params.ReturnConsumedCapacity)
+
+	return limit(ctx, l, readCapacity, []string{aws.ToString(in.TableName)}, func() (*dynamodb.QueryOutput, error) {
+		return l.client.Query(ctx, &in, optFns...)
+	}, func(out *dynamodb.QueryOutput) ([]types.ConsumedCapacity, bool) {
+		consumed := capacities(out.ConsumedCapacity)
+		if !requestedCapacity(params.ReturnConsumedCapacity) {
+			out.ConsumedCapacity = nil
+		}
+		return consumed, false
+	})
+}
+
+// Scan implements Client
+func (l *RateLimiter) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	in := *params
+	in.ReturnConsumedCapacity = returnConsumedCapacity(params.ReturnConsumedCapacity)
+
+	return limit(ctx, l, readCapacity, []string{aws.ToString(in.TableName)}, func() (*dynamodb.ScanOutput, error) {
+		return l.client.Scan(ctx, &in, optFns...)
+	}, func(out *dynamodb.ScanOutput) ([]types.ConsumedCapacity, bool) {
+		consumed := capacities(out.ConsumedCapacity)
+		if !requestedCapacity(params.ReturnConsumedCapacity) {
+			out.ConsumedCapacity = nil
+		}
+		return consumed, false
+	})
+}
+
+// TransactWriteItems implements Client
+func (l *RateLimiter) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	in := *params
+	in.ReturnConsumedCapacity = returnConsumedCapacity(params.ReturnConsumedCapacity)
+
+	var tableNames []string
+	for _, item := range in.TransactItems {
+		switch {
+		case item.Put != nil:
+			tableNames = append(tableNames, aws.ToString(item.Put.TableName))
+		case item.Update != nil:
+			tableNames = append(tableNames, aws.ToString(item.Update.TableName))
+		case item.Delete != nil:
+			tableNames = append(tableNames, aws.ToString(item.Delete.TableName))
+		case item.ConditionCheck != nil:
+			tableNames = append(tableNames, aws.ToString(item.ConditionCheck.TableName))
+		}
+	}
+	slices.Sort(tableNames)
+
+	return limit(ctx, l, writeCapacity, slices.Compact(tableNames), func() (*dynamodb.TransactWriteItemsOutput, error) {
+		return l.client.TransactWriteItems(ctx, &in, optFns...)
+	}, func(out *dynamodb.TransactWriteItemsOutput) ([]types.ConsumedCapacity, bool) {
+		consumed := out.ConsumedCapacity
+		if !requestedCapacity(params.ReturnConsumedCapacity) {
+			out.ConsumedCapacity = nil
+		}
+		return consumed, false
+	})
+}
+
+// TransactGetItems implements Client
+func (l *RateLimiter) TransactGetItems(ctx context.Context, params *dynamodb.TransactGetItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactGetItemsOutput, error) {
+	in := *params
+	in.ReturnConsumedCapacity = returnConsumedCapacity(params.ReturnConsumedCapacity)
+
+	var tableNames []string
+	for _, item := range in.TransactItems {
+		if item.Get != nil {
+			tableNames = append(tableNames, aws.ToString(item.Get.TableName))
+		}
+	}
+	slices.Sort(tableNames)
+
+	return limit(ctx, l, readCapacity, slices.Compact(tableNames), func() (*dynamodb.TransactGetItemsOutput, error) {
+		return l.client.TransactGetItems(ctx, &in, optFns...)
+	}, func(out *dynamodb.TransactGetItemsOutput) ([]types.ConsumedCapacity, bool) {
+		consumed := out.ConsumedCapacity
+		if !requestedCapacity(params.ReturnConsumedCapacity) {
+			out.ConsumedCapacity = nil
+		}
+		return consumed, false
+	})
+}
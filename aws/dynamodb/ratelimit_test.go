@@ -0,0 +1,313 @@
+package dynamodb_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	awsdynamodb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/rssh-jp/data-access-library/aws/dynamodb"
+	"github.com/rssh-jp/data-access-library/aws/dynamodb/memory"
+)
+
+// throttledClient throttles PutItem as many times as throttles
+type throttledClient struct {
+	dynamodb.Client
+	throttles atomic.Int32
+}
+
+func (c *throttledClient) PutItem(ctx context.Context, params *awsdynamodb.PutItemInput, optFns ...func(*awsdynamodb.Options)) (*awsdynamodb.PutItemOutput, error) {
+	if c.throttles.Add(-1) >= 0 {
+		return nil, &types.ProvisionedThroughputExceededException{Message: aws.String("throughput exceeded")}
+	}
+	return c.Client.PutItem(ctx, params, optFns...)
+}
+
+// describeFailingClient fails DescribeTable while failing is set
+type describeFailingClient struct {
+	dynamodb.Client
+	failing   atomic.Bool
+	describes atomic.Int32
+}
+
+func (c *describeFailingClient) DescribeTable(ctx context.Context, params *awsdynamodb.DescribeTableInput, optFns ...func(*awsdynamodb.Options)) (*awsdynamodb.DescribeTableOutput, error) {
+	c.describes.Add(1)
+	if c.failing.Load() {
+		return nil, errors.New("describe failed")
+	}
+	return c.Client.DescribeTable(ctx, params, optFns...)
+}
+
+// consumingClient reports units of consumed capacity for every Scan
+type consumingClient struct {
+	dynamodb.Client
+	units float64
+}
+
+func (c *consumingClient) Scan(ctx context.Context, params *awsdynamodb.ScanInput, optFns ...func(*awsdynamodb.Options)) (*awsdynamodb.ScanOutput, error) {
+	out, err := c.Client.Scan(ctx, params, optFns...)
+	if err != nil {
+		return nil, err
+	}
+	out.ConsumedCapacity = &types.ConsumedCapacity{TableName: params.TableName, CapacityUnits: aws.Float64(c.units)}
+	return out, nil
+}
+
+func TestRateLimiter(t *testing.T) {
+	newDynamoDB := func(t *testing.T, throttles int32, rateOpts ...dynamodb.RateLimitOption) (*dynamodb.DynamoDB, *dynamodb.RateLimiter) {
+		t.Helper()
+
+		c, err := memory.New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		client := &throttledClient{Client: c}
+		client.throttles.Store(throttles)
+
+		d := dynamodb.NewFromClient(client)
+		err = d.CreateDefaultTable()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		l, err := d.EnableRateLimit(rateOpts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d, l
+	}
+
+	t.Run("Consumed capacity", func(t *testing.T) {
+		d, l := newDynamoDB(t, 0)
+
+		for _, key := range []string{"a", "b", "c"} {
+			err := d.Set(key, "value")
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		_, err := d.Get("a")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// consumed capacity is not returned unless requested
+		keyCond := expression.Key("key").Equal(expression.Value("a"))
+		page, err := d.Query(context.Background(), keyCond)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.ConsumedCapacity != 0 {
+			t.Errorf("Bug. consumed capacity is not requested. But %v", page.ConsumedCapacity)
+		}
+		page, err = d.Query(context.Background(), keyCond, dynamodb.QueryOptionReturnConsumedCapacity())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.ConsumedCapacity == 0 {
+			t.Error("Bug. consumed capacity is requested. But 0")
+		}
+
+		actual := l.Usage(d.DefaultTableName)
+		if actual.Write != 3 {
+			t.Errorf("Could not match write units.\nexpect: %v\nactual: %v", 3, actual.Write)
+		}
+		if actual.Read != 1.5 {
+			t.Errorf("Could not match read units.\nexpect: %v\nactual: %v", 1.5, actual.Read)
+		}
+		if actual.ReadLimit != 5 || actual.WriteLimit != 5 {
+			t.Errorf("Bug. limits should be the provisioned throughput 5/5. But %v/%v", actual.ReadLimit, actual.WriteLimit)
+		}
+
+		if usages := l.Usages(); len(usages) != 1 || usages[d.DefaultTableName] != actual {
+			t.Errorf("Could not match usages.\nexpect: %v\nactual: %v", actual, usages)
+		}
+	})
+	t.Run("On-demand", func(t *testing.T) {
+		d, l := newDynamoDB(t, 0)
+
+		err := d.CreateTableFromDefinition(&dynamodb.TableDefinition{
+			Name:         "on_demand",
+			PartitionKey: dynamodb.KeyAttribute{Name: "key"},
+			BillingMode:  dynamodb.BillingModePayPerRequest,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = dynamodb.PutItem(context.Background(), d, map[string]string{"key": "a"}, dynamodb.WriteOptionTable("on_demand"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual := l.Usage("on_demand")
+		expect := dynamodb.CapacityUsage{Write: 1}
+		if actual != expect {
+			t.Errorf("Could not match usage.\nexpect: %+v\nactual: %+v", expect, actual)
+		}
+	})
+	t.Run("Rate", func(t *testing.T) {
+		d, l := newDynamoDB(t, 0, dynamodb.RateLimitOptionCapacity("default_table", dynamodb.Throughput{Write: 20}))
+
+		// 20 units of the burst and 20 units in a second
+		start := time.Now()
+		for range 40 {
+			err := d.Set("k", "value")
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+			t.Errorf("Bug. 40 units should take a second at 20 units per second. But %v", elapsed)
+		}
+
+		actual := l.Usage(d.DefaultTableName)
+		if actual.ReadLimit != 0 || actual.WriteLimit != 20 {
+			t.Errorf("Bug. only writes should be limited to 20. But %v/%v", actual.ReadLimit, actual.WriteLimit)
+		}
+	})
+	t.Run("Page beyond the burst", func(t *testing.T) {
+		c, err := memory.New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		d := dynamodb.NewFromClient(&consumingClient{Client: c, units: 100})
+		err = d.CreateDefaultTable()
+		if err != nil {
+			t.Fatal(err)
+		}
+		l, err := d.EnableRateLimit(dynamodb.RateLimitOptionCapacity("default_table", dynamodb.Throughput{Read: 50}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = d.Scan(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// 100 units of the page are owed beyond the burst of 50 units, which takes a second at 50 units per second
+		start := time.Now()
+		_, err = d.Scan(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+			t.Errorf("Bug. units beyond the burst should be owed by the next request. But %v", elapsed)
+		}
+		if actual := l.Usage(d.DefaultTableName); actual.Read != 200 {
+			t.Errorf("Could not match read units.\nexpect: %v\nactual: %v", 200, actual.Read)
+		}
+	})
+	t.Run("Throttle", func(t *testing.T) {
+		d, l := newDynamoDB(t, 1, dynamodb.RateLimitOptionRetry(0), dynamodb.RateLimitOptionRecovery(time.Hour))
+
+		err := d.Set("k", "value")
+		var exceeded *types.ProvisionedThroughputExceededException
+		if !errors.As(err, &exceeded) {
+			t.Errorf("Could not match error.\nexpect: %T\nactual: %v", exceeded, err)
+		}
+
+		actual := l.Usage(d.DefaultTableName)
+		expect := dynamodb.CapacityUsage{Throttles: 1, ReadLimit: 5, WriteLimit: 2.5}
+		if actual != expect {
+			t.Errorf("Could not match usage.\nexpect: %+v\nactual: %+v", expect, actual)
+		}
+	})
+	t.Run("Retry and recovery", func(t *testing.T) {
+		d, l := newDynamoDB(t, 1, dynamodb.RateLimitOptionRecovery(200*time.Millisecond))
+
+		err := d.Set("k", "value")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual := l.Usage(d.DefaultTableName)
+		if actual.Throttles != 1 || actual.Write != 1 || actual.WriteLimit >= 5 {
+			t.Errorf("Bug. write should succeed after throttle lowering the limit. But %+v", actual)
+		}
+
+		time.Sleep(300 * time.Millisecond)
+
+		err = d.Set("k", "value")
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual = l.Usage(d.DefaultTableName)
+		if actual.WriteLimit != 5 {
+			t.Errorf("Could not match write limit.\nexpect: %v\nactual: %v", 5, actual.WriteLimit)
+		}
+	})
+	t.Run("DescribeTable failure", func(t *testing.T) {
+		c, err := memory.New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		client := &describeFailingClient{Client: c}
+		d := dynamodb.NewFromClient(client)
+		err = d.CreateDefaultTable()
+		if err != nil {
+			t.Fatal(err)
+		}
+		l, err := d.EnableRateLimit(dynamodb.RateLimitOptionDescribeRetry(100 * time.Millisecond))
+		if err != nil {
+			t.Fatal(err)
+		}
+		client.failing.Store(true)
+		client.describes.Store(0)
+
+		for range 3 {
+			err := d.Set("k", "value")
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if actual := client.describes.Load(); actual != 1 {
+			t.Errorf("Bug. failure of DescribeTable should be kept until the retry interval. But %d calls", actual)
+		}
+		actual := l.Usage(d.DefaultTableName)
+		if actual.CapacityError == nil || actual.Write != 3 || actual.WriteLimit != 0 {
+			t.Errorf("Bug. the table should be unlimited with the error. But %+v", actual)
+		}
+		if usages := l.Usages(); usages[d.DefaultTableName].CapacityError == nil {
+			t.Errorf("Bug. Usages should report the error. But %+v", usages)
+		}
+
+		time.Sleep(150 * time.Millisecond)
+		client.failing.Store(false)
+
+		err = d.Set("k", "value")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := client.describes.Load(); actual != 2 {
+			t.Errorf("Bug. capacity should be read again after the retry interval. But %d calls", actual)
+		}
+		actual = l.Usage(d.DefaultTableName)
+		if actual.CapacityError != nil || actual.WriteLimit != 5 {
+			t.Errorf("Bug. the table should be limited by the capacity. But %+v", actual)
+		}
+	})
+	t.Run("Invalid option", func(t *testing.T) {
+		d := dynamodb.NewFromClient(nil)
+
+		for _, opt := range []dynamodb.RateLimitOption{
+			dynamodb.RateLimitOptionCapacity("", dynamodb.Throughput{Read: 1}),
+			dynamodb.RateLimitOptionCapacity("t", dynamodb.Throughput{Read: -1}),
+			dynamodb.RateLimitOptionRetry(-1),
+			dynamodb.RateLimitOptionRecovery(0),
+			dynamodb.RateLimitOptionDescribeRetry(0),
+		} {
+			_, err := d.EnableRateLimit(opt)
+			if err == nil {
+				t.Error("Bug. invalid option should be rejected. But no error")
+			}
+		}
+	})
+}
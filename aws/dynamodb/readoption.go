@@ -0,0 +1,71 @@
+package dynamodb
+
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+type readConfig struct {
+	tableName      string
+	consistentRead bool
+	clientOpts     []func(*dynamodb.Options)
+}
+
+func newReadConfig() *readConfig {
+	return &readConfig{
+		tableName:      "",
+		consistentRead: false,
+		clientOpts:     nil,
+	}
+}
+
+func createReadConfig(readOpts ...ReadOption) (*readConfig, error) {
+	rc := newReadConfig()
+
+	for _, opt := range readOpts {
+		err := opt(rc)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return rc, nil
+}
+
+// ReadOption is functional option pattern option for GetItem
+type ReadOption func(*readConfig) error
+
+// ReadOptionTable returns ReadOption instance reading table instead of DefaultTableName
+func ReadOptionTable(name string) func(c *readConfig) error {
+	return func(c *readConfig) error {
+		if name == "" {
+			return errors.New("table name is empty")
+		}
+		c.tableName = name
+		return nil
+	}
+}
+
+// ReadOptionConsistentRead returns ReadOption instance with strongly consistent read
+func ReadOptionConsistentRead() func(c *readConfig) error {
+	return func(c *readConfig) error {
+		c.consistentRead = true
+		return nil
+	}
+}
+
+// ReadOptionClientOptions returns ReadOption instance with request options
+func ReadOptionClientOptions(optFns ...func(*dynamodb.Options)) func(c *readConfig) error {
+	return func(c *readConfig) error {
+		c.clientOpts = append(c.clientOpts, optFns...)
+		return nil
+	}
+}
+
+func (rc *readConfig) table(d *DynamoDB) string {
+	if rc.tableName != "" {
+		return rc.tableName
+	}
+	return d.DefaultTableName
+}
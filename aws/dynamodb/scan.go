@@ -0,0 +1,302 @@
+package dynamodb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"golang.org/x/time/rate"
+)
+
+// SegmentProgress is progress of single segment of parallel scan
+type SegmentProgress struct {
+	// NextToken is token of the next page. It is empty before the first page is read
+	NextToken string `json:"next_token,omitempty"`
+	Done      bool   `json:"done"`
+}
+
+// ScanCheckpoint stores progress of parallel scan segments so that interrupted scan resumes
+type ScanCheckpoint interface {
+	// LoadSegment returns saved progress of segment. nil is returned when nothing is saved
+	LoadSegment(ctx context.Context, segment int) (*SegmentProgress, error)
+	// SaveSegment saves progress of segment. It is called after all items of the page are processed
+	SaveSegment(ctx context.Context, segment int, p *SegmentProgress) error
+}
+
+type fileScanCheckpoint struct {
+	mu       sync.Mutex
+	path     string
+	segments map[string]*SegmentProgress
+}
+
+// NewFileScanCheckpoint returns ScanCheckpoint saving progress of all segments to JSON file of path
+func NewFileScanCheckpoint(path string) (ScanCheckpoint, error) {
+	c := &fileScanCheckpoint{
+		path:     path,
+		segments: make(map[string]*SegmentProgress),
+	}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(b, &c.segments)
+	if err != nil {
+		return nil, fmt.Errorf("broken checkpoint %s: %w", path, err)
+	}
+
+	return c, nil
+}
+
+func (c *fileScanCheckpoint) LoadSegment(ctx context.Context, segment int) (*SegmentProgress, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, ok := c.segments[strconv.Itoa(segment)]
+	if !ok {
+		return nil, nil
+	}
+
+	ret := *p
+	return &ret, nil
+}
+
+func (c *fileScanCheckpoint) SaveSegment(ctx context.Context, segment int, p *SegmentProgress) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	saved := *p
+	c.segments[strconv.Itoa(segment)] = &saved
+
+	b, err := json.MarshalIndent(c.segments, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// write and rename so that crash never leaves broken file
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(b)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), c.path)
+}
+
+type parallelScanConfig struct {
+	queryOpts  []QueryOption
+	limiter    *rate.Limiter
+	checkpoint ScanCheckpoint
+}
+
+func newParallelScanConfig() *parallelScanConfig {
+	return &parallelScanConfig{
+		queryOpts:  nil,
+		limiter:    nil,
+		checkpoint: nil,
+	}
+}
+
+func createParallelScanConfig(scanOpts ...ParallelScanOption) (*parallelScanConfig, error) {
+	sc := newParallelScanConfig()
+
+	for _, opt := range scanOpts {
+		err := opt(sc)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return sc, nil
+}
+
+// ParallelScanOption is functional option pattern option for ParallelScan
+type ParallelScanOption func(*parallelScanConfig) error
+
+// ParallelScanOptionQueryOptions returns ParallelScanOption instance applying QueryOption to every segment.
+// QueryOptionStartToken is ignored
+func ParallelScanOptionQueryOptions(queryOpts ...QueryOption) func(c *parallelScanConfig) error {
+	return func(c *parallelScanConfig) error {
+		c.queryOpts = append(c.queryOpts, queryOpts...)
+		return nil
+	}
+}
+
+// ParallelScanOptionCapacity returns ParallelScanOption instance limiting consumed read capacity units per second of all segments
+func ParallelScanOptionCapacity(unitsPerSecond float64) func(c *parallelScanConfig) error {
+	return func(c *parallelScanConfig) error {
+		if unitsPerSecond <= 0 {
+			return errors.New("capacity must be positive")
+		}
+		c.limiter = rate.NewLimiter(rate.Limit(unitsPerSecond), int(math.Ceil(unitsPerSecond)))
+		return nil
+	}
+}
+
+// ParallelScanOptionCheckpoint returns ParallelScanOption instance saving progress to checkpoint.
+// Finished segments are skipped and the others resume from the saved page
+func ParallelScanOptionCheckpoint(checkpoint ScanCheckpoint) func(c *parallelScanConfig) error {
+	return func(c *parallelScanConfig) error {
+		c.checkpoint = checkpoint
+		return nil
+	}
+}
+
+// ScanItem is item read by segment of parallel scan
+type ScanItem struct {
+	Segment int
+	Item    map[string]types.AttributeValue
+}
+
+// ParallelScan scans the table by totalSegments workers and calls fn for every item.
+// fn is called concurrently from the workers. The scan stops with the first error of fn or request
+func (d *DynamoDB) ParallelScan(ctx context.Context, totalSegments int, fn func(ctx context.Context, item *ScanItem) error, scanOpts ...ParallelScanOption) error {
+	if totalSegments < 1 {
+		return errors.New("total segments must be positive")
+	}
+
+	sc, err := createParallelScanConfig(scanOpts...)
+	if err != nil {
+		return err
+	}
+
+	queryOpts := append(slices.Clone(sc.queryOpts), QueryOptionReturnConsumedCapacity())
+	qc, err := createQueryConfig(queryOpts...)
+	if err != nil {
+		return err
+	}
+	qc.startToken = ""
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	for segment := 0; segment < totalSegments; segment++ {
+		wg.Add(1)
+		go func(segment int) {
+			defer wg.Done()
+
+			err := d.scanSegment(ctx, sc, qc, segment, totalSegments, fn)
+			if err != nil {
+				once.Do(func() {
+					firstErr = fmt.Errorf("segment %d: %w", segment, err)
+					cancel()
+				})
+			}
+		}(segment)
+	}
+	wg.Wait()
+
+	return firstErr
+}
+
+// ParallelScanToChannel is ParallelScan sending every item to ch. ch is closed when the scan ends
+func (d *DynamoDB) ParallelScanToChannel(ctx context.Context, totalSegments int, ch chan<- *ScanItem, scanOpts ...ParallelScanOption) error {
+	defer close(ch)
+
+	return d.ParallelScan(ctx, totalSegments, func(ctx context.Context, item *ScanItem) error {
+		select {
+		case ch <- item:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}, scanOpts...)
+}
+
+func (d *DynamoDB) scanSegment(ctx context.Context, sc *parallelScanConfig, qc *queryConfig, segment, totalSegments int, fn func(ctx context.Context, item *ScanItem) error) error {
+	in, err := d.scanInput(qc)
+	if err != nil {
+		return err
+	}
+	in.Segment = aws.Int32(int32(segment))
+	in.TotalSegments = aws.Int32(int32(totalSegments))
+
+	if sc.checkpoint != nil {
+		p, err := sc.checkpoint.LoadSegment(ctx, segment)
+		if err != nil {
+			return err
+		}
+		if p != nil && p.Done {
+			return nil
+		}
+		if p != nil {
+			in.ExclusiveStartKey, err = decodeToken(p.NextToken)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for {
+		page, err := d.scanPage(ctx, qc, in)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range page.Items {
+			err := fn(ctx, &ScanItem{Segment: segment, Item: item})
+			if err != nil {
+				return err
+			}
+		}
+
+		if sc.checkpoint != nil {
+			err := sc.checkpoint.SaveSegment(ctx, segment, &SegmentProgress{NextToken: page.NextToken, Done: page.NextToken == ""})
+			if err != nil {
+				return err
+			}
+		}
+
+		if page.NextToken == "" {
+			return nil
+		}
+		in.ExclusiveStartKey = page.lastKey
+
+		if sc.limiter != nil {
+			err := waitCapacity(ctx, sc.limiter, page.ConsumedCapacity)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// waitCapacity waits until limiter allows units consumed by the last page.
+// Units beyond the burst are waited for in chunks of the burst
+func waitCapacity(ctx context.Context, limiter *rate.Limiter, units float64) error {
+	for n := int(math.Ceil(units)); n > 0; n -= limiter.Burst() {
+		err := limiter.WaitN(ctx, min(n, limiter.Burst()))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
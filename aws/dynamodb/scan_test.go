@@ -0,0 +1,212 @@
+package dynamodb_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	awsdynamodb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/rssh-jp/data-access-library/aws/dynamodb"
+)
+
+// newScanTable returns DynamoDB instance with default table of n items
+func newScanTable(t *testing.T, client *testClient, n int) *dynamodb.DynamoDB {
+	t.Helper()
+
+	d := client.newDynamoDB()
+
+	err := d.CreateDefaultTable()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < n; i++ {
+		err := d.Set(fmt.Sprintf("key%03d", i), "value")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return d
+}
+
+// scanCollector records keys of scanned items
+type scanCollector struct {
+	mu   sync.Mutex
+	keys map[string]int
+}
+
+func (c *scanCollector) collect(ctx context.Context, item *dynamodb.ScanItem) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.keys == nil {
+		c.keys = make(map[string]int)
+	}
+	c.keys[item.Item["key"].(*types.AttributeValueMemberS).Value]++
+
+	return nil
+}
+
+func TestParallelScan(t *testing.T) {
+	const items = 40
+
+	t.Run("All segments", func(t *testing.T) {
+		client := newTestClient(t)
+		d := newScanTable(t, client, items)
+
+		var c scanCollector
+		err := d.ParallelScan(context.Background(), 4, c.collect, dynamodb.ParallelScanOptionQueryOptions(dynamodb.QueryOptionLimit(3)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(c.keys) != items {
+			t.Errorf("Could not match items.\nexpect: %d\nactual: %d", items, len(c.keys))
+		}
+		for k, n := range c.keys {
+			if n != 1 {
+				t.Errorf("Bug. Item is read once. But read %d times: %s", n, k)
+			}
+		}
+	})
+	t.Run("Channel", func(t *testing.T) {
+		client := newTestClient(t)
+		d := newScanTable(t, client, items)
+
+		ch := make(chan *dynamodb.ScanItem)
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- d.ParallelScanToChannel(context.Background(), 3, ch)
+		}()
+
+		count := 0
+		for range ch {
+			count++
+		}
+
+		if err := <-errCh; err != nil {
+			t.Fatal(err)
+		}
+		if count != items {
+			t.Errorf("Could not match items.\nexpect: %d\nactual: %d", items, count)
+		}
+	})
+	t.Run("Error stops scan", func(t *testing.T) {
+		client := newTestClient(t)
+		d := newScanTable(t, client, items)
+
+		errStop := errors.New("stop")
+		err := d.ParallelScan(context.Background(), 2, func(ctx context.Context, item *dynamodb.ScanItem) error {
+			return errStop
+		})
+		if !errors.Is(err, errStop) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", errStop, err)
+		}
+	})
+	t.Run("Checkpoint", func(t *testing.T) {
+		client := newTestClient(t)
+		d := newScanTable(t, client, items)
+
+		path := filepath.Join(t.TempDir(), "checkpoint.json")
+		checkpoint, err := dynamodb.NewFileScanCheckpoint(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// crash after 10 items
+		var first scanCollector
+		errCrash := errors.New("crash")
+		err = d.ParallelScan(context.Background(), 2, func(ctx context.Context, item *dynamodb.ScanItem) error {
+			first.mu.Lock()
+			n := len(first.keys)
+			first.mu.Unlock()
+			if n >= 10 {
+				return errCrash
+			}
+			return first.collect(ctx, item)
+		}, dynamodb.ParallelScanOptionQueryOptions(dynamodb.QueryOptionLimit(4)), dynamodb.ParallelScanOptionCheckpoint(checkpoint))
+		if !errors.Is(err, errCrash) {
+			t.Fatalf("Could not match error.\nexpect: %v\nactual: %v", errCrash, err)
+		}
+
+		// resume with checkpoint loaded from the file
+		checkpoint, err = dynamodb.NewFileScanCheckpoint(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var second scanCollector
+		err = d.ParallelScan(context.Background(), 2, second.collect, dynamodb.ParallelScanOptionQueryOptions(dynamodb.QueryOptionLimit(4)), dynamodb.ParallelScanOptionCheckpoint(checkpoint))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(second.keys) >= items {
+			t.Errorf("Bug. Scan resumes from checkpoint. But read all items: %d", len(second.keys))
+		}
+		for k := range first.keys {
+			second.keys[k]++
+		}
+		if len(second.keys) != items {
+			t.Errorf("Could not match items of both runs.\nexpect: %d\nactual: %d", items, len(second.keys))
+		}
+
+		var third scanCollector
+		err = d.ParallelScan(context.Background(), 2, third.collect, dynamodb.ParallelScanOptionCheckpoint(checkpoint))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(third.keys) != 0 {
+			t.Errorf("Bug. All segments are done. But read: %d", len(third.keys))
+		}
+	})
+	t.Run("Capacity", func(t *testing.T) {
+		client := newTestClient(t)
+		d := newScanTable(t, client, 10)
+
+		// every page consumes 1 unit. 2 units are allowed at once and 2 units per second after that
+		start := time.Now()
+		var c scanCollector
+		err := d.ParallelScan(context.Background(), 1, c.collect,
+			dynamodb.ParallelScanOptionQueryOptions(dynamodb.QueryOptionLimit(2)),
+			dynamodb.ParallelScanOptionCapacity(2),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if elapsed := time.Since(start); elapsed < 800*time.Millisecond {
+			t.Errorf("Could not limit capacity. elapsed: %v", elapsed)
+		}
+		if req := lastRequest[awsdynamodb.ScanInput](t, client); req.ReturnConsumedCapacity != types.ReturnConsumedCapacityTotal {
+			t.Errorf("Could not match return consumed capacity.\nexpect: %s\nactual: %s", types.ReturnConsumedCapacityTotal, req.ReturnConsumedCapacity)
+		}
+	})
+	t.Run("Page beyond the burst", func(t *testing.T) {
+		client := newTestClient(t)
+		newScanTable(t, client, 1)
+		d := dynamodb.NewFromClient(&consumingClient{Client: client, units: 20})
+
+		// the first page consumes 20 units. 10 units are allowed at once and 10 units per second after that
+		start := time.Now()
+		var c scanCollector
+		err := d.ParallelScan(context.Background(), 1, c.collect,
+			dynamodb.ParallelScanOptionQueryOptions(dynamodb.QueryOptionLimit(1)),
+			dynamodb.ParallelScanOptionCapacity(10),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+			t.Errorf("Could not limit capacity. elapsed: %v", elapsed)
+		}
+	})
+}
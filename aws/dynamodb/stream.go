@@ -0,0 +1,596 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams"
+	streamtypes "github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+)
+
+// StreamRecord is a change of item read from the stream
+type StreamRecord struct {
+	ShardID string
+	EventID string
+	// EventName is INSERT, MODIFY or REMOVE
+	EventName               string
+	SequenceNumber          string
+	ApproximateCreationTime time.Time
+	Keys                    map[string]types.AttributeValue
+	// NewImage and OldImage are set according to StreamViewType of the table
+	NewImage map[string]types.AttributeValue
+	OldImage map[string]types.AttributeValue
+}
+
+// UnmarshalNewImage unmarshals the item after the change into out. ErrNotFound is returned without the image
+func (r *StreamRecord) UnmarshalNewImage(out any) error {
+	if len(r.NewImage) == 0 {
+		return ErrNotFound
+	}
+	return attributevalue.UnmarshalMap(r.NewImage, out)
+}
+
+// UnmarshalOldImage unmarshals the item before the change into out. ErrNotFound is returned without the image
+func (r *StreamRecord) UnmarshalOldImage(out any) error {
+	if len(r.OldImage) == 0 {
+		return ErrNotFound
+	}
+	return attributevalue.UnmarshalMap(r.OldImage, out)
+}
+
+func newStreamRecord(shardID string, r streamtypes.Record) (*StreamRecord, error) {
+	ret := &StreamRecord{
+		ShardID:   shardID,
+		EventID:   aws.ToString(r.EventID),
+		EventName: string(r.EventName),
+	}
+	if r.Dynamodb == nil {
+		return ret, nil
+	}
+
+	ret.SequenceNumber = aws.ToString(r.Dynamodb.SequenceNumber)
+	ret.ApproximateCreationTime = aws.ToTime(r.Dynamodb.ApproximateCreationDateTime)
+
+	var err error
+	for _, image := range []struct {
+		from map[string]streamtypes.AttributeValue
+		to   *map[string]types.AttributeValue
+	}{
+		{r.Dynamodb.Keys, &ret.Keys},
+		{r.Dynamodb.NewImage, &ret.NewImage},
+		{r.Dynamodb.OldImage, &ret.OldImage},
+	} {
+		if image.from == nil {
+			continue
+		}
+		*image.to, err = attributevalue.FromDynamoDBStreamsMap(image.from)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ret, nil
+}
+
+type streamConfig struct {
+	streamARN     string
+	startPosition streamtypes.ShardIteratorType
+	pollInterval  time.Duration
+	batchSize     int32
+	lockOpts      []LockOption
+}
+
+func newStreamConfig() *streamConfig {
+	return &streamConfig{
+		streamARN:     "",
+		startPosition: streamtypes.ShardIteratorTypeTrimHorizon,
+		pollInterval:  time.Second,
+		batchSize:     0,
+		lockOpts:      nil,
+	}
+}
+
+func createStreamConfig(streamOpts ...StreamOption) (*streamConfig, error) {
+	sc := newStreamConfig()
+
+	for _, opt := range streamOpts {
+		err := opt(sc)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return sc, nil
+}
+
+// StreamOption is functional option pattern option for ConsumeStream
+type StreamOption func(*streamConfig) error
+
+// StreamOptionARN returns StreamOption instance reading stream of arn instead of the latest stream of the table
+func StreamOptionARN(arn string) func(c *streamConfig) error {
+	return func(c *streamConfig) error {
+		if arn == "" {
+			return errors.New("stream arn is empty")
+		}
+		c.streamARN = arn
+		return nil
+	}
+}
+
+// StreamOptionStartLatest returns StreamOption instance reading shards without checkpoint from the latest record
+// instead of the oldest record. It applies to shards in the stream when the consumer starts.
+// Shards created by splits after that, and children of consumed shards, are read from the oldest record not to lose records
+func StreamOptionStartLatest() func(c *streamConfig) error {
+	return func(c *streamConfig) error {
+		c.startPosition = streamtypes.ShardIteratorTypeLatest
+		return nil
+	}
+}
+
+// StreamOptionPollInterval returns StreamOption instance with interval of shard discovery and of reading shard without new records.
+// 1 second by default
+func StreamOptionPollInterval(d time.Duration) func(c *streamConfig) error {
+	return func(c *streamConfig) error {
+		if d <= 0 {
+			return errors.New("poll interval must be positive")
+		}
+		c.pollInterval = d
+		return nil
+	}
+}
+
+// StreamOptionBatchSize returns StreamOption instance with the maximum number of records of GetRecords request
+func StreamOptionBatchSize(n int) func(c *streamConfig) error {
+	return func(c *streamConfig) error {
+		if n < 1 || n > 1000 {
+			return errors.New("batch size must be between 1 and 1000")
+		}
+		c.batchSize = int32(n)
+		return nil
+	}
+}
+
+// StreamOptionLockOptions returns StreamOption instance applying LockOption to shard leases.
+// e.g. LockOptionLeaseDuration and LockOptionOwner. LockOptionTable and LockOptionKeyName are ignored
+func StreamOptionLockOptions(lockOpts ...LockOption) func(c *streamConfig) error {
+	return func(c *streamConfig) error {
+		c.lockOpts = append(c.lockOpts, lockOpts...)
+		return nil
+	}
+}
+
+// EnableStream enables stream of existing table with viewType
+func (d *DynamoDB) EnableStream(tableName string, viewType types.StreamViewType) error {
+	return d.EnableStreamWithContext(context.Background(), tableName, viewType)
+}
+
+// EnableStreamWithContext is EnableStream with context and request options
+func (d *DynamoDB) EnableStreamWithContext(ctx context.Context, tableName string, viewType types.StreamViewType, optFns ...func(*dynamodb.Options)) error {
+	_, err := d.DynamoDB.UpdateTable(ctx, &dynamodb.UpdateTableInput{
+		TableName: aws.String(tableName),
+		StreamSpecification: &types.StreamSpecification{
+			StreamEnabled:  aws.Bool(true),
+			StreamViewType: viewType,
+		},
+	}, optFns...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ConsumeStream reads the stream of the table and calls fn for every record until ctx is canceled or fn returns error.
+// Shards are leased through LockClient on leaseTableName, which must have partition key DefaultKeyName,
+// so consumers sharing leaseTableName process every shard by only one of them.
+// Child shards are read after their parent is finished. Records of a shard are passed to fn in order
+// and fn is called concurrently for different shards.
+// The checkpoint of the shard is saved in leaseTableName after fn succeeds for the records of each GetRecords response,
+// so records are delivered at least once: records after the checkpoint are delivered again after failure.
+// The checkpoint is saved only while the lease is owned, and reading the shard stops when it was taken over
+func (d *DynamoDB) ConsumeStream(ctx context.Context, tableName, leaseTableName string, fn func(ctx context.Context, r *StreamRecord) error, streamOpts ...StreamOption) error {
+	if d.Streams == nil {
+		return errors.New("Streams client is nil")
+	}
+
+	sc, err := createStreamConfig(streamOpts...)
+	if err != nil {
+		return err
+	}
+
+	arn := sc.streamARN
+	if arn == "" {
+		res, err := d.DynamoDB.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(tableName)})
+		if err != nil {
+			return err
+		}
+		arn = aws.ToString(res.Table.LatestStreamArn)
+		if arn == "" {
+			return fmt.Errorf("stream of table %s is not enabled", tableName)
+		}
+	}
+
+	locks, err := d.NewLockClient(append(slices.Clone(sc.lockOpts), LockOptionTable(leaseTableName), LockOptionKeyName(d.DefaultKeyName))...)
+	if err != nil {
+		return err
+	}
+
+	c := &streamConsumer{
+		d:          d,
+		sc:         sc,
+		arn:        arn,
+		leaseTable: leaseTableName,
+		locks:      locks,
+		fn:         fn,
+		running:    make(map[string]bool),
+		finished:   make(map[string]bool),
+	}
+
+	return c.run(ctx)
+}
+
+type streamConsumer struct {
+	d          *DynamoDB
+	sc         *streamConfig
+	arn        string
+	leaseTable string
+	locks      *LockClient
+	fn         func(ctx context.Context, r *StreamRecord) error
+
+	// running and finished are touched only by run
+	running  map[string]bool
+	finished map[string]bool
+	// initial is shards read from the start position, which are in the stream when the consumer starts and whose parent is not consumed.
+	// Other shards are read from the trim horizon not to lose records written before they are reached. It is set by the first startShards
+	initial map[string]bool
+}
+
+// streamCheckpoint is progress of shard saved in the lease table
+type streamCheckpoint struct {
+	SequenceNumber string `dynamodbav:"sequence_number,omitempty"`
+	Finished       bool   `dynamodbav:"finished"`
+}
+
+func (c *streamConsumer) run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make(chan error, 1)
+	done := make(chan string)
+
+	for {
+		err := c.startShards(ctx, &wg, errs, done)
+		if ctx.Err() != nil {
+			// requests interrupted by ctx fail with errors not wrapping it
+			return ctx.Err()
+		}
+		if err != nil {
+			return err
+		}
+
+		timer := time.NewTimer(c.sc.pollInterval)
+		for waiting := true; waiting; {
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case err := <-errs:
+				timer.Stop()
+				return err
+			case shardID := <-done:
+				delete(c.running, shardID)
+			case <-timer.C:
+				waiting = false
+			}
+		}
+	}
+}
+
+// startShards leases and starts reading shards whose parent is finished
+func (c *streamConsumer) startShards(ctx context.Context, wg *sync.WaitGroup, errs chan<- error, done chan<- string) error {
+	shards, err := c.shards(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = c.loadFinished(ctx, shards)
+	if err != nil {
+		return err
+	}
+
+	exists := make(map[string]bool, len(shards))
+	for _, shard := range shards {
+		exists[aws.ToString(shard.ShardId)] = true
+	}
+	if c.initial == nil {
+		c.initial = make(map[string]bool, len(shards))
+		for _, shard := range shards {
+			parentID := aws.ToString(shard.ParentShardId)
+			c.initial[aws.ToString(shard.ShardId)] = parentID == "" || !exists[parentID] || !c.finished[parentID]
+		}
+	}
+
+	for _, shard := range shards {
+		shardID := aws.ToString(shard.ShardId)
+		parentID := aws.ToString(shard.ParentShardId)
+		if c.running[shardID] || c.finished[shardID] {
+			continue
+		}
+		// parent no longer in the stream is trimmed
+		if parentID != "" && exists[parentID] && !c.finished[parentID] {
+			continue
+		}
+
+		l, err := c.locks.TryLock(ctx, c.leaseName(shardID))
+		if errors.Is(err, ErrLockHeld) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		c.running[shardID] = true
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := c.readShard(ctx, shardID, l)
+			if err != nil {
+				select {
+				case errs <- fmt.Errorf("shard %s: %w", shardID, err):
+				default:
+				}
+			}
+
+			select {
+			case done <- shardID:
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	return nil
+}
+
+// shards returns all shards of the stream
+func (c *streamConsumer) shards(ctx context.Context) ([]streamtypes.Shard, error) {
+	var ret []streamtypes.Shard
+
+	in := &dynamodbstreams.DescribeStreamInput{StreamArn: aws.String(c.arn)}
+	for {
+		res, err := c.d.Streams.DescribeStream(ctx, in)
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, res.StreamDescription.Shards...)
+
+		if res.StreamDescription.LastEvaluatedShardId == nil {
+			return ret, nil
+		}
+		in.ExclusiveStartShardId = res.StreamDescription.LastEvaluatedShardId
+	}
+}
+
+// loadFinished reads checkpoints of shards not known to be finished
+func (c *streamConsumer) loadFinished(ctx context.Context, shards []streamtypes.Shard) error {
+	// shard id by checkpoint name
+	shardIDs := make(map[string]string)
+	var keys []Key
+	for _, shard := range shards {
+		shardID := aws.ToString(shard.ShardId)
+		if c.finished[shardID] || c.running[shardID] {
+			continue
+		}
+		shardIDs[c.checkpointName(shardID)] = shardID
+		keys = append(keys, c.checkpointKey(shardID))
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	res, err := c.d.BatchGet(ctx, keys, BatchOptionTable(c.leaseTable), BatchOptionConsistentRead())
+	if err != nil {
+		return err
+	}
+
+	for _, item := range res.Items {
+		var cp streamCheckpoint
+		err := attributevalue.UnmarshalMap(item, &cp)
+		if err != nil {
+			return err
+		}
+
+		name, ok := item[c.d.DefaultKeyName].(*types.AttributeValueMemberS)
+		if ok && cp.Finished {
+			c.finished[shardIDs[name.Value]] = true
+		}
+	}
+
+	return nil
+}
+
+// readShard reads records of the shard until the shard is closed, the lease is lost or ctx is canceled
+func (c *streamConsumer) readShard(ctx context.Context, shardID string, l *Lock) error {
+	cp, err := c.checkpoint(ctx, shardID)
+	if err != nil {
+		l.Unlock(ctx)
+		return err
+	}
+	if cp.Finished {
+		return l.Unlock(ctx)
+	}
+
+	iterator, err := c.iterator(ctx, shardID, cp)
+	if err != nil {
+		l.Unlock(ctx)
+		return err
+	}
+
+	for {
+		select {
+		case <-l.Lost():
+			// another consumer takes over from the checkpoint
+			return nil
+		default:
+		}
+
+		res, err := c.d.Streams.GetRecords(ctx, &dynamodbstreams.GetRecordsInput{
+			ShardIterator: iterator,
+			Limit:         c.limit(),
+		})
+		var expired *streamtypes.ExpiredIteratorException
+		if errors.As(err, &expired) {
+			iterator, err = c.iterator(ctx, shardID, cp)
+			if err != nil {
+				l.Unlock(ctx)
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			l.Unlock(ctx)
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		for _, r := range res.Records {
+			record, err := newStreamRecord(shardID, r)
+			if err != nil {
+				l.Unlock(ctx)
+				return err
+			}
+
+			err = c.fn(ctx, record)
+			if err != nil {
+				l.Unlock(ctx)
+				return err
+			}
+			cp.SequenceNumber = record.SequenceNumber
+		}
+
+		if res.NextShardIterator == nil {
+			cp.Finished = true
+		}
+		if len(res.Records) > 0 || cp.Finished {
+			err := c.saveCheckpoint(ctx, shardID, l, cp)
+			if errors.Is(err, ErrConditionFailed) {
+				// the lease is taken over, so the new owner goes on from the checkpoint
+				l.Unlock(ctx)
+				return nil
+			}
+			if err != nil {
+				l.Unlock(ctx)
+				return err
+			}
+		}
+		if cp.Finished {
+			return l.Unlock(ctx)
+		}
+
+		iterator = res.NextShardIterator
+		if len(res.Records) == 0 {
+			err := sleep(ctx, c.sc.pollInterval)
+			if err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+// iterator returns iterator of the shard after the checkpoint.
+// Without checkpoint, it is of the start position for the initial shards, and of the trim horizon for the others
+func (c *streamConsumer) iterator(ctx context.Context, shardID string, cp *streamCheckpoint) (*string, error) {
+	in := &dynamodbstreams.GetShardIteratorInput{
+		StreamArn:         aws.String(c.arn),
+		ShardId:           aws.String(shardID),
+		ShardIteratorType: streamtypes.ShardIteratorTypeTrimHorizon,
+	}
+	if c.initial[shardID] {
+		in.ShardIteratorType = c.sc.startPosition
+	}
+	if cp.SequenceNumber != "" {
+		in.ShardIteratorType = streamtypes.ShardIteratorTypeAfterSequenceNumber
+		in.SequenceNumber = aws.String(cp.SequenceNumber)
+	}
+
+	res, err := c.d.Streams.GetShardIterator(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.ShardIterator, nil
+}
+
+func (c *streamConsumer) limit() *int32 {
+	if c.sc.batchSize == 0 {
+		return nil
+	}
+	return aws.Int32(c.sc.batchSize)
+}
+
+func (c *streamConsumer) checkpoint(ctx context.Context, shardID string) (*streamCheckpoint, error) {
+	av, err := c.checkpointKey(shardID).attributeValues()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.d.DynamoDB.GetItem(ctx, &dynamodb.GetItemInput{
+		Key:            av,
+		TableName:      aws.String(c.leaseTable),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cp := &streamCheckpoint{}
+	err = attributevalue.UnmarshalMap(res.Item, cp)
+	if err != nil {
+		return nil, err
+	}
+
+	return cp, nil
+}
+
+// saveCheckpoint saves the checkpoint with condition that the lease l of the shard is held.
+// The error matches ErrConditionFailed when the lease is taken over or released, even by a consumer of the same owner name
+func (c *streamConsumer) saveCheckpoint(ctx context.Context, shardID string, l *Lock, cp *streamCheckpoint) error {
+	item := map[string]any{
+		c.d.DefaultKeyName: c.checkpointName(shardID),
+		"finished":         cp.Finished,
+	}
+	if cp.SequenceNumber != "" {
+		item["sequence_number"] = cp.SequenceNumber
+	}
+
+	return c.d.Transaction().
+		ConditionCheck(Key{c.locks.keyName(): c.leaseName(shardID)}, l.held(), WriteOptionTable(c.leaseTable)).
+		Put(item, WriteOptionTable(c.leaseTable)).
+		Commit(ctx)
+}
+
+func (c *streamConsumer) checkpointKey(shardID string) Key {
+	return Key{c.d.DefaultKeyName: c.checkpointName(shardID)}
+}
+
+func (c *streamConsumer) checkpointName(shardID string) string {
+	return "checkpoint/" + c.arn + "/" + shardID
+}
+
+func (c *streamConsumer) leaseName(shardID string) string {
+	return "lease/" + c.arn + "/" + shardID
+}
@@ -0,0 +1,344 @@
+package dynamodb_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/rssh-jp/data-access-library/aws/dynamodb"
+)
+
+func TestStream(t *testing.T) {
+	newDynamoDB := func(t *testing.T) (*testClient, *dynamodb.DynamoDB) {
+		t.Helper()
+
+		c := newTestClient(t)
+		d := c.newDynamoDB()
+		if err := d.CreateDefaultTable(); err != nil {
+			t.Fatal(err)
+		}
+		if err := d.EnableStream(d.DefaultTableName, types.StreamViewTypeNewAndOldImages); err != nil {
+			t.Fatal(err)
+		}
+		err := d.CreateTableFromDefinition(&dynamodb.TableDefinition{
+			Name:         "leases",
+			PartitionKey: dynamodb.KeyAttribute{Name: d.DefaultKeyName, Type: dynamodb.AttributeTypeString},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c, d
+	}
+	// consume returns keys of records until n records are read
+	consume := func(t *testing.T, d *dynamodb.DynamoDB, n int, streamOpts ...dynamodb.StreamOption) []string {
+		t.Helper()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		var mu sync.Mutex
+		var keys []string
+		err := d.ConsumeStream(ctx, d.DefaultTableName, "leases", func(ctx context.Context, r *dynamodb.StreamRecord) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			keys = append(keys, r.Keys[d.DefaultKeyName].(*types.AttributeValueMemberS).Value)
+			if len(keys) == n {
+				cancel()
+			}
+			return nil
+		}, append([]dynamodb.StreamOption{dynamodb.StreamOptionPollInterval(10 * time.Millisecond)}, streamOpts...)...)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", context.Canceled, err)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		return keys
+	}
+
+	t.Run("Records", func(t *testing.T) {
+		_, d := newDynamoDB(t)
+
+		ctx := context.Background()
+		if err := dynamodb.PutItem(ctx, d, &ticket{ID: "t1", Assignee: "alice", Priority: 1}); err != nil {
+			t.Fatal(err)
+		}
+		if err := d.Update(ctx, d.Key("t1"), dynamodb.NewUpdate().Set("assignee", "bob")); err != nil {
+			t.Fatal(err)
+		}
+		if err := d.Delete("t1"); err != nil {
+			t.Fatal(err)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+
+		var records []*dynamodb.StreamRecord
+		err := d.ConsumeStream(ctx, d.DefaultTableName, "leases", func(ctx context.Context, r *dynamodb.StreamRecord) error {
+			records = append(records, r)
+			if len(records) == 3 {
+				cancel()
+			}
+			return nil
+		}, dynamodb.StreamOptionPollInterval(10*time.Millisecond))
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("Could not match error.\nexpect: %v\nactual: %v", context.Canceled, err)
+		}
+
+		events := make([]string, 0, len(records))
+		for _, r := range records {
+			events = append(events, r.EventName)
+		}
+		if expect := []string{"INSERT", "MODIFY", "REMOVE"}; !reflect.DeepEqual(events, expect) {
+			t.Fatalf("Could not match events.\nexpect: %v\nactual: %v", expect, events)
+		}
+
+		var oldImage, newImage ticket
+		if err := records[1].UnmarshalOldImage(&oldImage); err != nil {
+			t.Fatal(err)
+		}
+		if err := records[1].UnmarshalNewImage(&newImage); err != nil {
+			t.Fatal(err)
+		}
+		if expect := (ticket{ID: "t1", Assignee: "alice", Priority: 1}); oldImage != expect {
+			t.Errorf("Could not match old image.\nexpect: %v\nactual: %v", expect, oldImage)
+		}
+		if expect := (ticket{ID: "t1", Assignee: "bob", Priority: 1}); newImage != expect {
+			t.Errorf("Could not match new image.\nexpect: %v\nactual: %v", expect, newImage)
+		}
+
+		err = records[2].UnmarshalNewImage(&newImage)
+		if !errors.Is(err, dynamodb.ErrNotFound) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrNotFound, err)
+		}
+	})
+	t.Run("Shard lineage", func(t *testing.T) {
+		c, d := newDynamoDB(t)
+
+		for _, key := range []string{"a", "b", "c"} {
+			if err := d.Set(key, "v"); err != nil {
+				t.Fatal(err)
+			}
+			if err := c.SplitShard(d.DefaultTableName); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := d.Set("d", "v"); err != nil {
+			t.Fatal(err)
+		}
+
+		actual := consume(t, d, 4)
+		if expect := []string{"a", "b", "c", "d"}; !reflect.DeepEqual(actual, expect) {
+			t.Errorf("Could not match keys.\nexpect: %v\nactual: %v", expect, actual)
+		}
+	})
+	t.Run("Split while reading latest", func(t *testing.T) {
+		c, d := newDynamoDB(t)
+
+		if err := d.Set("old", "v"); err != nil {
+			t.Fatal(err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		received := make(chan string, 100)
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- d.ConsumeStream(ctx, d.DefaultTableName, "leases", func(ctx context.Context, r *dynamodb.StreamRecord) error {
+				received <- r.Keys[d.DefaultKeyName].(*types.AttributeValueMemberS).Value
+				return nil
+			}, dynamodb.StreamOptionStartLatest(), dynamodb.StreamOptionPollInterval(50*time.Millisecond))
+		}()
+
+		// records are written until the consumer reads the open shard from the latest position
+		var keys []string
+		for i := 0; len(keys) == 0; i++ {
+			if err := d.Set("a", strconv.Itoa(i)); err != nil {
+				t.Fatal(err)
+			}
+			select {
+			case key := <-received:
+				keys = append(keys, key)
+			case <-time.After(20 * time.Millisecond):
+			case <-ctx.Done():
+				t.Fatal(ctx.Err())
+			}
+		}
+
+		// the record written to the child before the consumer reaches it is delivered
+		if err := c.SplitShard(d.DefaultTableName); err != nil {
+			t.Fatal(err)
+		}
+		if err := d.Set("b", "v"); err != nil {
+			t.Fatal(err)
+		}
+		for keys[len(keys)-1] != "b" {
+			select {
+			case key := <-received:
+				keys = append(keys, key)
+			case <-ctx.Done():
+				t.Fatalf("Bug. Child shard should be read from the start. But %v", keys)
+			}
+		}
+		cancel()
+		<-errCh
+
+		if slices.Contains(keys, "old") {
+			t.Errorf("Bug. Record before the start should be skipped. But %v", keys)
+		}
+	})
+	t.Run("Checkpoint", func(t *testing.T) {
+		_, d := newDynamoDB(t)
+
+		for _, key := range []string{"a", "b", "c"} {
+			if err := d.Set(key, "v"); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		errHandler := errors.New("handler error")
+		var keys []string
+		err := d.ConsumeStream(context.Background(), d.DefaultTableName, "leases", func(ctx context.Context, r *dynamodb.StreamRecord) error {
+			key := r.Keys[d.DefaultKeyName].(*types.AttributeValueMemberS).Value
+			if key == "b" {
+				return errHandler
+			}
+			keys = append(keys, key)
+			return nil
+		}, dynamodb.StreamOptionPollInterval(10*time.Millisecond), dynamodb.StreamOptionBatchSize(1))
+		if !errors.Is(err, errHandler) {
+			t.Fatalf("Could not match error.\nexpect: %v\nactual: %v", errHandler, err)
+		}
+		if expect := []string{"a"}; !reflect.DeepEqual(keys, expect) {
+			t.Fatalf("Could not match keys.\nexpect: %v\nactual: %v", expect, keys)
+		}
+
+		// the failed record is delivered again
+		actual := consume(t, d, 2)
+		if expect := []string{"b", "c"}; !reflect.DeepEqual(actual, expect) {
+			t.Errorf("Could not match keys.\nexpect: %v\nactual: %v", expect, actual)
+		}
+	})
+	t.Run("Lease taken over", func(t *testing.T) {
+		// attribute changed by takeover: owner name of another consumer, or lease token of a consumer of the same owner name
+		for name, attr := range map[string]string{"Another owner": "lock_owner", "Same owner": "lock_token"} {
+			t.Run(name, func(t *testing.T) {
+				_, d := newDynamoDB(t)
+
+				for _, key := range []string{"a", "b", "c"} {
+					if err := d.Set(key, "v"); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				// takeOver changes every lease and its record version number as another consumer took over them
+				takeOver := func(ctx context.Context) error {
+					for item, err := range d.ScanAll(ctx, dynamodb.QueryOptionTable("leases")) {
+						if err != nil {
+							return err
+						}
+						name := item[d.DefaultKeyName].(*types.AttributeValueMemberS).Value
+						if !strings.HasPrefix(name, "lease/") {
+							continue
+						}
+						err := d.Update(ctx, dynamodb.Key{d.DefaultKeyName: name}, dynamodb.NewUpdate().Set(attr, "other").Set("lock_rvn", "other"), dynamodb.WriteOptionTable("leases"))
+						if err != nil {
+							return err
+						}
+					}
+					return nil
+				}
+
+				ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+				defer cancel()
+
+				var keys []string
+				err := d.ConsumeStream(ctx, d.DefaultTableName, "leases", func(ctx context.Context, r *dynamodb.StreamRecord) error {
+					keys = append(keys, r.Keys[d.DefaultKeyName].(*types.AttributeValueMemberS).Value)
+					return takeOver(ctx)
+				}, dynamodb.StreamOptionPollInterval(10*time.Millisecond), dynamodb.StreamOptionBatchSize(1))
+				if !errors.Is(err, context.DeadlineExceeded) {
+					t.Fatalf("Could not match error.\nexpect: %v\nactual: %v", context.DeadlineExceeded, err)
+				}
+				if expect := []string{"a"}; !reflect.DeepEqual(keys, expect) {
+					t.Errorf("Bug. Reading the shard should stop when the lease is taken over. But %v", keys)
+				}
+
+				for item, err := range d.ScanAll(context.Background(), dynamodb.QueryOptionTable("leases")) {
+					if err != nil {
+						t.Fatal(err)
+					}
+					if name := item[d.DefaultKeyName].(*types.AttributeValueMemberS).Value; strings.HasPrefix(name, "checkpoint/") {
+						t.Errorf("Bug. Checkpoint should not be saved after the lease is taken over. But %s", name)
+					}
+				}
+			})
+		}
+	})
+	t.Run("Shared leases", func(t *testing.T) {
+		c, d := newDynamoDB(t)
+
+		expect := map[string]bool{}
+		for _, key := range []string{"a", "b", "c", "d", "e", "f"} {
+			if err := d.Set(key, "v"); err != nil {
+				t.Fatal(err)
+			}
+			if err := c.SplitShard(d.DefaultTableName); err != nil {
+				t.Fatal(err)
+			}
+			expect[key] = true
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		var mu sync.Mutex
+		actual := map[string]int{}
+		var wg sync.WaitGroup
+		for _, owner := range []string{"a", "b"} {
+			wg.Go(func() {
+				d.ConsumeStream(ctx, d.DefaultTableName, "leases", func(ctx context.Context, r *dynamodb.StreamRecord) error {
+					mu.Lock()
+					defer mu.Unlock()
+
+					actual[r.Keys[d.DefaultKeyName].(*types.AttributeValueMemberS).Value]++
+					if len(actual) == len(expect) {
+						cancel()
+					}
+					return nil
+				}, dynamodb.StreamOptionPollInterval(10*time.Millisecond), dynamodb.StreamOptionLockOptions(dynamodb.LockOptionOwner(owner)))
+			})
+		}
+		wg.Wait()
+
+		for key := range expect {
+			if actual[key] != 1 {
+				t.Errorf("Could not match delivery count of %s.\nexpect: %d\nactual: %d", key, 1, actual[key])
+			}
+		}
+	})
+	t.Run("Not enabled", func(t *testing.T) {
+		c := newTestClient(t)
+		d := c.newDynamoDB()
+		if err := d.CreateDefaultTable(); err != nil {
+			t.Fatal(err)
+		}
+
+		err := d.ConsumeStream(context.Background(), d.DefaultTableName, "leases", func(ctx context.Context, r *dynamodb.StreamRecord) error {
+			return nil
+		})
+		if err == nil {
+			t.Error("Bug. Stream is not enabled. But no error")
+		}
+	})
+}
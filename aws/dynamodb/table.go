@@ -0,0 +1,490 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// tableActiveTimeout is the maximum wait for new table to become ACTIVE before TTL is enabled
+const tableActiveTimeout = 5 * time.Minute
+
+// AttributeType values of key attribute
+const (
+	AttributeTypeString = types.ScalarAttributeTypeS
+	AttributeTypeNumber = types.ScalarAttributeTypeN
+	AttributeTypeBinary = types.ScalarAttributeTypeB
+)
+
+// KeyAttribute is name and type of key attribute
+type KeyAttribute struct {
+	Name string
+	Type types.ScalarAttributeType
+}
+
+// Billing modes of table
+const (
+	BillingModeProvisioned   = types.BillingModeProvisioned
+	BillingModePayPerRequest = types.BillingModePayPerRequest
+)
+
+// Throughput is provisioned read and write capacity units
+type Throughput struct {
+	Read  int64
+	Write int64
+}
+
+// SSE is server side encryption setting. empty KMSKeyID uses AWS managed key
+type SSE struct {
+	KMSKeyID string
+}
+
+// TableDefinition is definition of table with partition key and optional sort key
+type TableDefinition struct {
+	Name         string
+	PartitionKey KeyAttribute
+	// SortKey is nil for table without sort key
+	SortKey *KeyAttribute
+
+	// BillingMode is BillingModeProvisioned when empty
+	BillingMode types.BillingMode
+	// Throughput is used with BillingModeProvisioned. 5 read and 5 write units when nil
+	Throughput *Throughput
+	// SSE enables encryption by KMS. nil uses the default encryption owned by DynamoDB
+	SSE *SSE
+	// TableClass is STANDARD when empty
+	TableClass         types.TableClass
+	Tags               map[string]string
+	DeletionProtection bool
+	// TTLAttribute is attribute of expiration time in epoch seconds. TTL is enabled after the table becomes ACTIVE when it is set
+	TTLAttribute string
+	// StreamViewType enables stream of the table with the images written to records. e.g. types.StreamViewTypeNewAndOldImages
+	StreamViewType types.StreamViewType
+
+	// GlobalIndexes is global secondary indexes
+	GlobalIndexes []*IndexDefinition
+	// LocalIndexes is local secondary indexes. PartitionKey of the index is ignored and the partition key of the table is used
+	LocalIndexes []*IndexDefinition
+}
+
+// Key returns Key of the table. sortKey is ignored when the table has no sort key
+func (def *TableDefinition) Key(partitionKey, sortKey any) Key {
+	key := Key{def.PartitionKey.Name: partitionKey}
+	if def.SortKey != nil {
+		key[def.SortKey.Name] = sortKey
+	}
+	return key
+}
+
+func (def *TableDefinition) validate() error {
+	if def.Name == "" {
+		return errors.New("table name is empty")
+	}
+	if def.PartitionKey.Name == "" {
+		return errors.New("partition key name is empty")
+	}
+	if def.SortKey != nil && def.SortKey.Name == "" {
+		return errors.New("sort key name is empty")
+	}
+	if def.BillingMode == BillingModePayPerRequest && def.Throughput != nil {
+		return errors.New("throughput is not allowed with pay per request billing")
+	}
+
+	names := make(map[string]bool)
+	for _, index := range def.GlobalIndexes {
+		err := index.validate(false, def.BillingMode)
+		if err != nil {
+			return err
+		}
+		if names[index.Name] {
+			return fmt.Errorf("index %s is duplicated", index.Name)
+		}
+		names[index.Name] = true
+	}
+	for _, index := range def.LocalIndexes {
+		err := index.validate(true, def.BillingMode)
+		if err != nil {
+			return err
+		}
+		if names[index.Name] {
+			return fmt.Errorf("index %s is duplicated", index.Name)
+		}
+		names[index.Name] = true
+	}
+
+	_, err := def.attributeDefinitions()
+	return err
+}
+
+// createTableInput returns CreateTableInput built from the definition
+func (def *TableDefinition) createTableInput() *dynamodb.CreateTableInput {
+	attrs, _ := def.attributeDefinitions()
+
+	in := &dynamodb.CreateTableInput{
+		AttributeDefinitions: attrs,
+		KeySchema:            keySchema(def.PartitionKey, def.SortKey),
+		TableName:            aws.String(def.Name),
+	}
+
+	for _, index := range def.GlobalIndexes {
+		in.GlobalSecondaryIndexes = append(in.GlobalSecondaryIndexes, index.globalSecondaryIndex(def.BillingMode, def.Throughput))
+	}
+	for _, index := range def.LocalIndexes {
+		in.LocalSecondaryIndexes = append(in.LocalSecondaryIndexes, types.LocalSecondaryIndex{
+			IndexName:  aws.String(index.Name),
+			KeySchema:  keySchema(def.PartitionKey, index.SortKey),
+			Projection: index.projection(),
+		})
+	}
+
+	switch def.BillingMode {
+	case BillingModePayPerRequest:
+		in.BillingMode = BillingModePayPerRequest
+	default:
+		throughput := Throughput{Read: 5, Write: 5}
+		if def.Throughput != nil {
+			throughput = *def.Throughput
+		}
+		in.BillingMode = BillingModeProvisioned
+		in.ProvisionedThroughput = &types.ProvisionedThroughput{
+			ReadCapacityUnits:  aws.Int64(throughput.Read),
+			WriteCapacityUnits: aws.Int64(throughput.Write),
+		}
+	}
+
+	if def.SSE != nil {
+		in.SSESpecification = &types.SSESpecification{
+			Enabled: aws.Bool(true),
+			SSEType: types.SSETypeKms,
+		}
+		if def.SSE.KMSKeyID != "" {
+			in.SSESpecification.KMSMasterKeyId = aws.String(def.SSE.KMSKeyID)
+		}
+	}
+
+	if def.DeletionProtection {
+		in.DeletionProtectionEnabled = aws.Bool(true)
+	}
+
+	if def.TableClass != "" {
+		in.TableClass = def.TableClass
+	}
+
+	if def.StreamViewType != "" {
+		in.StreamSpecification = &types.StreamSpecification{
+			StreamEnabled:  aws.Bool(true),
+			StreamViewType: def.StreamViewType,
+		}
+	}
+
+	if len(def.Tags) > 0 {
+		keys := make([]string, 0, len(def.Tags))
+		for k := range def.Tags {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			in.Tags = append(in.Tags, types.Tag{Key: aws.String(k), Value: aws.String(def.Tags[k])})
+		}
+	}
+
+	return in
+}
+
+// attributeDefinitions returns definitions of key attributes of the table and indexes.
+// error is returned when an attribute is defined with different types
+func (def *TableDefinition) attributeDefinitions() ([]types.AttributeDefinition, error) {
+	keys := []*KeyAttribute{&def.PartitionKey, def.SortKey}
+	for _, index := range def.GlobalIndexes {
+		keys = append(keys, &index.PartitionKey, index.SortKey)
+	}
+	for _, index := range def.LocalIndexes {
+		keys = append(keys, index.SortKey)
+	}
+
+	return attributeDefinitions(keys...)
+}
+
+// attributeDefinitions returns definitions of keys in order without duplication. nil key is skipped
+func attributeDefinitions(keys ...*KeyAttribute) ([]types.AttributeDefinition, error) {
+	attrs := make([]types.AttributeDefinition, 0, len(keys))
+	defined := make(map[string]types.ScalarAttributeType)
+	for _, k := range keys {
+		if k == nil {
+			continue
+		}
+
+		t := attributeType(k.Type)
+		if defined, ok := defined[k.Name]; ok {
+			if defined != t {
+				return nil, fmt.Errorf("attribute %s is defined as %s and %s", k.Name, defined, t)
+			}
+			continue
+		}
+		defined[k.Name] = t
+
+		attrs = append(attrs, types.AttributeDefinition{AttributeName: aws.String(k.Name), AttributeType: t})
+	}
+
+	return attrs, nil
+}
+
+// keySchema returns key schema of partition key and optional sort key
+func keySchema(partitionKey KeyAttribute, sortKey *KeyAttribute) []types.KeySchemaElement {
+	schema := []types.KeySchemaElement{
+		{AttributeName: aws.String(partitionKey.Name), KeyType: types.KeyTypeHash},
+	}
+	if sortKey != nil {
+		schema = append(schema, types.KeySchemaElement{AttributeName: aws.String(sortKey.Name), KeyType: types.KeyTypeRange})
+	}
+	return schema
+}
+
+// attributeType returns t. string is used when t is empty
+func attributeType(t types.ScalarAttributeType) types.ScalarAttributeType {
+	if t == "" {
+		return AttributeTypeString
+	}
+	return t
+}
+
+// Key is primary key of item. map key is attribute name and value is marshaled by attributevalue.
+// string, number and []byte values are stored as S, N and B. types.AttributeValue value is used as is
+type Key map[string]any
+
+// Key returns Key of the default table. sortKey is used only when DefaultSortKeyName is set
+func (d *DynamoDB) Key(partitionKey any, sortKey ...any) Key {
+	key := Key{d.DefaultKeyName: partitionKey}
+	if d.DefaultSortKeyName != "" && len(sortKey) > 0 {
+		key[d.DefaultSortKeyName] = sortKey[0]
+	}
+	return key
+}
+
+func (k Key) attributeValues() (map[string]types.AttributeValue, error) {
+	if len(k) == 0 {
+		return nil, errors.New("key is empty")
+	}
+
+	ret := make(map[string]types.AttributeValue, len(k))
+	for name, v := range k {
+		if av, ok := v.(types.AttributeValue); ok {
+			ret[name] = av
+			continue
+		}
+
+		av, err := attributevalue.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		ret[name] = av
+	}
+
+	return ret, nil
+}
+
+// CreateTableFromDefinition creates table by definition
+func (d *DynamoDB) CreateTableFromDefinition(def *TableDefinition) error {
+	return d.CreateTableFromDefinitionWithContext(context.Background(), def)
+}
+
+// CreateTableFromDefinitionWithContext is CreateTableFromDefinition with context and request options.
+// With TTLAttribute it waits until the table becomes ACTIVE up to tableActiveTimeout to enable TTL
+func (d *DynamoDB) CreateTableFromDefinitionWithContext(ctx context.Context, def *TableDefinition, optFns ...func(*dynamodb.Options)) error {
+	err := d.createTable(ctx, def, optFns...)
+	if err != nil {
+		return err
+	}
+
+	if def.TTLAttribute == "" {
+		return nil
+	}
+
+	err = d.WaitUntilActiveWithContext(ctx, def.Name, tableActiveTimeout, optFns...)
+	if err != nil {
+		return err
+	}
+
+	return d.EnableTTLWithContext(ctx, def.Name, def.TTLAttribute, optFns...)
+}
+
+// createTable creates table without waiting
+func (d *DynamoDB) createTable(ctx context.Context, def *TableDefinition, optFns ...func(*dynamodb.Options)) error {
+	err := def.validate()
+	if err != nil {
+		return err
+	}
+
+	_, err = d.DynamoDB.CreateTable(ctx, def.createTableInput(), optFns...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// WaitUntilActive waits until the table becomes ACTIVE up to maxWait
+func (d *DynamoDB) WaitUntilActive(tableName string, maxWait time.Duration) error {
+	return d.WaitUntilActiveWithContext(context.Background(), tableName, maxWait)
+}
+
+// WaitUntilActiveWithContext is WaitUntilActive with context and request options
+func (d *DynamoDB) WaitUntilActiveWithContext(ctx context.Context, tableName string, maxWait time.Duration, optFns ...func(*dynamodb.Options)) error {
+	w := dynamodb.NewTableExistsWaiter(d.DynamoDB, func(o *dynamodb.TableExistsWaiterOptions) {
+		o.MinDelay = time.Second
+		o.ClientOptions = append(o.ClientOptions, optFns...)
+	})
+
+	return w.Wait(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(tableName)}, maxWait)
+}
+
+// EnsureTable creates table by definition unless it exists and waits until it becomes ACTIVE up to maxWait.
+// Existing table is not updated even when it differs from the definition, except that TTL of TTLAttribute is enabled
+func (d *DynamoDB) EnsureTable(def *TableDefinition, maxWait time.Duration) error {
+	return d.EnsureTableWithContext(context.Background(), def, maxWait)
+}
+
+// EnsureTableWithContext is EnsureTable with context and request options
+func (d *DynamoDB) EnsureTableWithContext(ctx context.Context, def *TableDefinition, maxWait time.Duration, optFns ...func(*dynamodb.Options)) error {
+	err := d.createTable(ctx, def, optFns...)
+	if err != nil {
+		var inUse *types.ResourceInUseException
+		if !errors.As(err, &inUse) {
+			return err
+		}
+	}
+
+	err = d.WaitUntilActiveWithContext(ctx, def.Name, maxWait, optFns...)
+	if err != nil {
+		return err
+	}
+
+	if def.TTLAttribute == "" {
+		return nil
+	}
+
+	return d.EnableTTLWithContext(ctx, def.Name, def.TTLAttribute, optFns...)
+}
+
+// DeleteTable deletes table. Use WaitUntilDeleted to wait until the table is gone
+func (d *DynamoDB) DeleteTable(tableName string) error {
+	return d.DeleteTableWithContext(context.Background(), tableName)
+}
+
+// DeleteTableWithContext is DeleteTable with context and request options
+func (d *DynamoDB) DeleteTableWithContext(ctx context.Context, tableName string, optFns ...func(*dynamodb.Options)) error {
+	_, err := d.DynamoDB.DeleteTable(ctx, &dynamodb.DeleteTableInput{TableName: aws.String(tableName)}, optFns...)
+	if err != nil {
+		return err
+	}
+	// the table may be created again with another key schema
+	d.keySchemas.Delete(tableName)
+
+	return nil
+}
+
+// WaitUntilDeleted waits until the table is gone up to maxWait
+func (d *DynamoDB) WaitUntilDeleted(tableName string, maxWait time.Duration) error {
+	return d.WaitUntilDeletedWithContext(context.Background(), tableName, maxWait)
+}
+
+// WaitUntilDeletedWithContext is WaitUntilDeleted with context and request options
+func (d *DynamoDB) WaitUntilDeletedWithContext(ctx context.Context, tableName string, maxWait time.Duration, optFns ...func(*dynamodb.Options)) error {
+	w := dynamodb.NewTableNotExistsWaiter(d.DynamoDB, func(o *dynamodb.TableNotExistsWaiterOptions) {
+		o.MinDelay = time.Second
+		o.ClientOptions = append(o.ClientOptions, optFns...)
+	})
+
+	return w.Wait(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(tableName)}, maxWait)
+}
+
+// keyNames returns key attribute names of the table read by DescribeTable once and cached
+func (d *DynamoDB) keyNames(ctx context.Context, tableName string, optFns ...func(*dynamodb.Options)) ([]string, error) {
+	if v, ok := d.keySchemas.Load(tableName); ok {
+		return v.([]string), nil
+	}
+
+	res, err := d.DynamoDB.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(tableName)}, optFns...)
+	if err != nil {
+		return nil, err
+	}
+
+	keyNames := make([]string, 0, len(res.Table.KeySchema))
+	for _, k := range res.Table.KeySchema {
+		keyNames = append(keyNames, aws.ToString(k.AttributeName))
+	}
+	d.keySchemas.Store(tableName, keyNames)
+	return keyNames, nil
+}
+
+// Truncate deletes all items of the table, the default table when tableName is empty, and returns the number of deleted items.
+// The keys are read by parallel scan of totalSegments and deleted by BatchWrite with batchOpts.
+// It consumes capacity of every item, so it is meant for resetting test and staging tables
+func (d *DynamoDB) Truncate(ctx context.Context, tableName string, totalSegments int, batchOpts ...BatchOption) (int, error) {
+	if totalSegments < 1 {
+		return 0, errors.New("total segments must be positive")
+	}
+	if tableName == "" {
+		tableName = d.DefaultTableName
+	}
+
+	keyNames, err := d.keyNames(ctx, tableName)
+	if err != nil {
+		return 0, err
+	}
+
+	batchOpts = append(slices.Clone(batchOpts), BatchOptionTable(tableName))
+
+	var deleted atomic.Int64
+	// requests of each segment are touched only by the worker of the segment
+	requests := make([][]*WriteRequest, totalSegments)
+	flush := func(ctx context.Context, segment int) error {
+		if len(requests[segment]) == 0 {
+			return nil
+		}
+
+		res, err := d.BatchWrite(ctx, requests[segment], batchOpts...)
+		if res != nil {
+			deleted.Add(int64(len(requests[segment]) - len(res.Errors)))
+		}
+		requests[segment] = nil
+
+		return err
+	}
+
+	err = d.ParallelScan(ctx, totalSegments, func(ctx context.Context, item *ScanItem) error {
+		key := make(Key, len(keyNames))
+		for _, name := range keyNames {
+			key[name] = item.Item[name]
+		}
+
+		requests[item.Segment] = append(requests[item.Segment], DeleteRequest(key))
+		if len(requests[item.Segment]) < batchWriteSize {
+			return nil
+		}
+
+		return flush(ctx, item.Segment)
+	}, ParallelScanOptionQueryOptions(QueryOptionTable(tableName), QueryOptionProjection(keyNames...)))
+	if err != nil {
+		return int(deleted.Load()), err
+	}
+
+	for segment := range requests {
+		err := flush(ctx, segment)
+		if err != nil {
+			return int(deleted.Load()), err
+		}
+	}
+
+	return int(deleted.Load()), nil
+}
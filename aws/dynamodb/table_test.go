@@ -0,0 +1,243 @@
+package dynamodb_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsdynamodb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/rssh-jp/data-access-library/aws/dynamodb"
+)
+
+func TestCompositeKey(t *testing.T) {
+	t.Run("Default table", func(t *testing.T) {
+		c := newTestClient(t)
+		d := c.newDynamoDB()
+		d.DefaultSortKeyName = "sort"
+
+		err := d.CreateDefaultTable()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, sk := range []string{"a", "b"} {
+			err := d.SetByKey(d.Key("user1", sk), "value_"+sk)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		for _, sk := range []string{"a", "b"} {
+			actual, err := d.GetByKey(d.Key("user1", sk))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual != "value_"+sk {
+				t.Errorf("Could not match value.\nexpect: %s\nactual: %s", "value_"+sk, actual)
+			}
+		}
+
+		_, err = d.GetByKey(d.Key("user1", "c"))
+		if !errors.Is(err, dynamodb.ErrNotFound) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrNotFound, err)
+		}
+	})
+	t.Run("Number sort key", func(t *testing.T) {
+		type order struct {
+			UserID  string `dynamodbav:"user_id"`
+			OrderID int    `dynamodbav:"order_id"`
+			Item    string `dynamodbav:"item"`
+		}
+
+		c := newTestClient(t)
+		d := c.newDynamoDB()
+
+		def := &dynamodb.TableDefinition{
+			Name:         "orders",
+			PartitionKey: dynamodb.KeyAttribute{Name: "user_id", Type: dynamodb.AttributeTypeString},
+			SortKey:      &dynamodb.KeyAttribute{Name: "order_id", Type: dynamodb.AttributeTypeNumber},
+		}
+		err := d.CreateTableFromDefinition(def)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d.DefaultTableName = def.Name
+
+		expect := order{UserID: "user1", OrderID: 2, Item: "book"}
+		err = dynamodb.PutItem(context.Background(), d, expect)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual, err := dynamodb.GetItem[order](context.Background(), d, def.Key("user1", 2))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *actual != expect {
+			t.Errorf("Could not match item.\nexpect: %+v\nactual: %+v", expect, *actual)
+		}
+	})
+	t.Run("Invalid definition", func(t *testing.T) {
+		c := newTestClient(t)
+		d := c.newDynamoDB()
+
+		err := d.CreateTableFromDefinition(&dynamodb.TableDefinition{Name: "t"})
+		if err == nil {
+			t.Error("Bug. Partition key is empty. But no error")
+		}
+	})
+}
+
+func TestTableDefinition(t *testing.T) {
+	key := dynamodb.KeyAttribute{Name: "key", Type: dynamodb.AttributeTypeString}
+
+	t.Run("Default throughput", func(t *testing.T) {
+		c := newTestClient(t)
+		d := c.newDynamoDB()
+
+		err := d.CreateDefaultTable()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		in := lastRequest[awsdynamodb.CreateTableInput](t, c)
+		expect := &types.ProvisionedThroughput{ReadCapacityUnits: aws.Int64(5), WriteCapacityUnits: aws.Int64(5)}
+		if !reflect.DeepEqual(expect, in.ProvisionedThroughput) {
+			t.Errorf("Could not match throughput.\nexpect: %v\nactual: %v", expect, in.ProvisionedThroughput)
+		}
+	})
+	t.Run("Options", func(t *testing.T) {
+		c := newTestClient(t)
+		d := c.newDynamoDB()
+
+		err := d.CreateTableFromDefinition(&dynamodb.TableDefinition{
+			Name:               "t",
+			PartitionKey:       key,
+			BillingMode:        dynamodb.BillingModePayPerRequest,
+			SSE:                &dynamodb.SSE{KMSKeyID: "alias/test"},
+			TableClass:         "STANDARD_INFREQUENT_ACCESS",
+			Tags:               map[string]string{"team": "data", "env": "dev"},
+			DeletionProtection: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		in := lastRequest[awsdynamodb.CreateTableInput](t, c)
+		expect := &awsdynamodb.CreateTableInput{
+			TableName:                 aws.String("t"),
+			AttributeDefinitions:      in.AttributeDefinitions,
+			KeySchema:                 in.KeySchema,
+			BillingMode:               types.BillingModePayPerRequest,
+			SSESpecification:          &types.SSESpecification{Enabled: aws.Bool(true), SSEType: types.SSETypeKms, KMSMasterKeyId: aws.String("alias/test")},
+			TableClass:                types.TableClassStandardInfrequentAccess,
+			DeletionProtectionEnabled: aws.Bool(true),
+			Tags: []types.Tag{
+				{Key: aws.String("env"), Value: aws.String("dev")},
+				{Key: aws.String("team"), Value: aws.String("data")},
+			},
+		}
+		if !reflect.DeepEqual(expect, in) {
+			t.Errorf("Could not match input.\nexpect: %+v\nactual: %+v", expect, in)
+		}
+
+		err = d.CreateTableFromDefinition(&dynamodb.TableDefinition{
+			Name:         "t2",
+			PartitionKey: key,
+			BillingMode:  dynamodb.BillingModePayPerRequest,
+			Throughput:   &dynamodb.Throughput{Read: 1, Write: 1},
+		})
+		if err == nil {
+			t.Error("Bug. Throughput with pay per request is invalid. But no error")
+		}
+	})
+	t.Run("EnsureTable", func(t *testing.T) {
+		c := newTestClient(t)
+		c.creatingDescribes = 1
+		d := c.newDynamoDB()
+
+		def := &dynamodb.TableDefinition{Name: "t", PartitionKey: key}
+
+		for i := 0; i < 2; i++ {
+			err := d.EnsureTable(def, 10*time.Second)
+			if err != nil {
+				t.Fatalf("Could not ensure table on call %d: %v", i+1, err)
+			}
+		}
+
+		err := d.CreateTableFromDefinition(def)
+		if err == nil {
+			t.Error("Bug. Table exists. But created again")
+		}
+	})
+	t.Run("WaitUntilActive timeout", func(t *testing.T) {
+		c := newTestClient(t)
+		d := c.newDynamoDB()
+
+		err := d.WaitUntilActive("unknown", time.Second)
+		if err == nil {
+			t.Error("Bug. Table does not exist. But no error")
+		}
+	})
+	t.Run("DeleteTable", func(t *testing.T) {
+		c := newTestClient(t)
+		d := c.newDynamoDB()
+
+		err := d.CreateDefaultTable()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = d.DeleteTable(d.DefaultTableName)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = d.WaitUntilDeleted(d.DefaultTableName, 10*time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		names, err := d.TableNames()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(names) != 0 {
+			t.Errorf("Bug. Table is deleted. But found: %v", names)
+		}
+	})
+	t.Run("Truncate", func(t *testing.T) {
+		c := newTestClient(t)
+		d := newOrderTable(t, c)
+
+		requests := make([]*dynamodb.WriteRequest, 0, 60)
+		for i := 10; i < 70; i++ {
+			requests = append(requests, dynamodb.PutRequest(order{UserID: "u3", OrderID: i, Status: "open"}))
+		}
+		_, err := d.BatchWrite(context.Background(), requests)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual, err := d.Truncate(context.Background(), "", 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != 66 {
+			t.Errorf("Could not match deleted count.\nexpect: %v\nactual: %v", 66, actual)
+		}
+
+		page, err := d.Scan(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Items) != 0 {
+			t.Errorf("Bug. Table is truncated. But %d items found", len(page.Items))
+		}
+	})
+}
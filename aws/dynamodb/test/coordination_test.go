@@ -0,0 +1,290 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/rssh-jp/data-access-library/aws/dynamodb"
+)
+
+func TestLock(t *testing.T) {
+	newLockClient := func(t *testing.T, d *dynamodb.DynamoDB, owner string) *dynamodb.LockClient {
+		t.Helper()
+
+		c, err := d.NewLockClient(
+			dynamodb.LockOptionOwner(owner),
+			dynamodb.LockOptionLeaseDuration(5*time.Second),
+			dynamodb.LockOptionHeartbeatInterval(time.Second),
+			dynamodb.LockOptionRetryInterval(50*time.Millisecond),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Owner() != owner {
+			t.Errorf("Could not match owner.\nexpect: %s\nactual: %s", owner, c.Owner())
+		}
+		return c
+	}
+
+	t.Run("TryLock, Lock and Unlock", func(t *testing.T) {
+		d := newDynamoDB(t)
+		createDefaultTable(t, d)
+		a := newLockClient(t, d, "a")
+		b := newLockClient(t, d, "b")
+
+		l, err := a.TryLock(context.Background(), "leader")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if l.Name() != "leader" {
+			t.Errorf("Could not match name.\nexpect: %s\nactual: %s", "leader", l.Name())
+		}
+
+		_, err = b.TryLock(context.Background(), "leader")
+		if !errors.Is(err, dynamodb.ErrLockHeld) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrLockHeld, err)
+		}
+
+		go func() {
+			time.Sleep(200 * time.Millisecond)
+			l.Unlock(context.Background())
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), maxWait)
+		defer cancel()
+		next, err := b.Lock(ctx, "leader")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case <-next.Lost():
+			t.Error("Bug. lock should be held. But lost")
+		default:
+		}
+
+		err = next.Unlock(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-next.Lost():
+		case <-time.After(time.Second):
+			t.Error("Bug. Lost should be closed after Unlock. But not closed")
+		}
+	})
+	t.Run("LockOptionTable", func(t *testing.T) {
+		d := newDynamoDB(t)
+		name := createTable(t, d, &dynamodb.TableDefinition{
+			Name:         "locks",
+			PartitionKey: dynamodb.KeyAttribute{Name: d.DefaultKeyName, Type: dynamodb.AttributeTypeString},
+		})
+
+		c, err := d.NewLockClient(dynamodb.LockOptionTable(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		l, err := c.TryLock(context.Background(), "job")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = l.Unlock(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestStream(t *testing.T) {
+	t.Run("ConsumeStream", func(t *testing.T) {
+		d := newDynamoDB(t)
+		createDefaultTable(t, d)
+		leases := createTable(t, d, &dynamodb.TableDefinition{
+			Name:         "leases",
+			PartitionKey: dynamodb.KeyAttribute{Name: d.DefaultKeyName, Type: dynamodb.AttributeTypeString},
+		})
+
+		err := d.EnableStream(d.DefaultTableName, types.StreamViewTypeNewAndOldImages)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = d.Set("k", "v1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = d.Set("k", "v2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = d.Delete("k")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		type value struct {
+			Value string `dynamodbav:"value"`
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), maxWait)
+		defer cancel()
+
+		var mu sync.Mutex
+		var actual []string
+		err = d.ConsumeStream(ctx, d.DefaultTableName, leases, func(ctx context.Context, r *dynamodb.StreamRecord) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			var newImage, oldImage value
+			err := r.UnmarshalNewImage(&newImage)
+			if err != nil && !errors.Is(err, dynamodb.ErrNotFound) {
+				return err
+			}
+			err = r.UnmarshalOldImage(&oldImage)
+			if err != nil && !errors.Is(err, dynamodb.ErrNotFound) {
+				return err
+			}
+
+			actual = append(actual, r.EventName+":"+oldImage.Value+"->"+newImage.Value)
+			if len(actual) == 3 {
+				cancel()
+			}
+			return nil
+		},
+			dynamodb.StreamOptionPollInterval(100*time.Millisecond),
+			dynamodb.StreamOptionBatchSize(2),
+			dynamodb.StreamOptionLockOptions(dynamodb.LockOptionOwner("consumer")),
+		)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", context.Canceled, err)
+		}
+
+		expect := []string{"INSERT:->v1", "MODIFY:v1->v2", "REMOVE:v2->"}
+		if !reflect.DeepEqual(expect, actual) {
+			t.Errorf("Could not match records.\nexpect: %v\nactual: %v", expect, actual)
+		}
+	})
+}
+
+type account struct {
+	ID   string `dynamodbav:"id"`
+	Name string `dynamodbav:"name"`
+}
+
+type purchase struct {
+	AccountID string `dynamodbav:"account_id"`
+	ID        int    `dynamodbav:"id"`
+	Date      string `dynamodbav:"date"`
+	Status    string `dynamodbav:"status,omitempty"`
+}
+
+func TestRegistry(t *testing.T) {
+	t.Run("Single table", func(t *testing.T) {
+		d := newDynamoDB(t)
+		name := createTable(t, d, &dynamodb.TableDefinition{
+			Name:         "app",
+			PartitionKey: dynamodb.KeyAttribute{Name: "pk"},
+			SortKey:      &dynamodb.KeyAttribute{Name: "sk"},
+			GlobalIndexes: []*dynamodb.IndexDefinition{
+				{Name: "gsi1", PartitionKey: dynamodb.KeyAttribute{Name: "gsi1pk"}, SortKey: &dynamodb.KeyAttribute{Name: "gsi1sk"}},
+			},
+		})
+
+		r, err := d.NewRegistry(name,
+			dynamodb.RegistryOptionKeyNames("pk", "sk"),
+			dynamodb.RegistryOptionIndex("gsi1", "gsi1pk", "gsi1sk"),
+			dynamodb.RegistryOptionTypeName("entity"),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = dynamodb.Register[account](r, &dynamodb.EntityDefinition{
+			Keys: dynamodb.EntityKeys{PartitionKey: "ACCOUNT#{id}", SortKey: "PROFILE"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = dynamodb.Register[purchase](r, &dynamodb.EntityDefinition{
+			Keys: dynamodb.EntityKeys{PartitionKey: "ACCOUNT#{account_id}", SortKey: "PURCHASE#{date}#{id}"},
+			Indexes: map[string]dynamodb.EntityKeys{
+				"gsi1": {PartitionKey: "STATUS#{status}", SortKey: "{date}"},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ctx := context.Background()
+		err = dynamodb.PutEntity(ctx, r, &account{ID: "a1", Name: "alice"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range []*purchase{
+			{AccountID: "a1", ID: 2, Date: "2024-02-01", Status: "open"},
+			{AccountID: "a1", ID: 1, Date: "2024-01-01"},
+			{AccountID: "a2", ID: 3, Date: "2024-03-01", Status: "open"},
+		} {
+			err := dynamodb.PutEntity(ctx, r, p)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		key, err := r.Key(&purchase{AccountID: "a1", ID: 1, Date: "2024-01-01"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectKey := dynamodb.Key{"pk": "ACCOUNT#a1", "sk": "PURCHASE#2024-01-01#1"}
+		if !reflect.DeepEqual(expectKey, key) {
+			t.Errorf("Could not match key.\nexpect: %v\nactual: %v", expectKey, key)
+		}
+
+		a, err := dynamodb.GetEntity(ctx, r, &account{ID: "a1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expect := (account{ID: "a1", Name: "alice"}); *a != expect {
+			t.Errorf("Could not match account.\nexpect: %v\nactual: %v", expect, *a)
+		}
+
+		items, _, err := r.Query(ctx, "ACCOUNT#a1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect := []any{
+			&account{ID: "a1", Name: "alice"},
+			&purchase{AccountID: "a1", ID: 1, Date: "2024-01-01"},
+			&purchase{AccountID: "a1", ID: 2, Date: "2024-02-01", Status: "open"},
+		}
+		if !reflect.DeepEqual(expect, items) {
+			t.Errorf("Could not match entities.\nexpect: %v\nactual: %v", expect, items)
+		}
+
+		purchases, _, err := dynamodb.QueryEntity[purchase](ctx, r, "STATUS#open", dynamodb.QueryOptionIndex("gsi1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectPurchases := []purchase{
+			{AccountID: "a1", ID: 2, Date: "2024-02-01", Status: "open"},
+			{AccountID: "a2", ID: 3, Date: "2024-03-01", Status: "open"},
+		}
+		if !reflect.DeepEqual(expectPurchases, purchases) {
+			t.Errorf("Could not match purchases.\nexpect: %v\nactual: %v", expectPurchases, purchases)
+		}
+
+		err = dynamodb.DeleteEntity(ctx, r, &purchase{AccountID: "a1", ID: 1, Date: "2024-01-01"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = dynamodb.GetEntity(ctx, r, &purchase{AccountID: "a1", ID: 1, Date: "2024-01-01"})
+		if !errors.Is(err, dynamodb.ErrNotFound) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrNotFound, err)
+		}
+	})
+}
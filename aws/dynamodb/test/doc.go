@@ -0,0 +1,7 @@
+// Package test is integration test suite of package dynamodb against dynamodb-local.
+// Every test creates tables of unique names and deletes them after the test.
+// The tests are skipped unless DYNAMODB_LOCAL_ENDPOINT is set to the endpoint of dynamodb-local. e.g.
+//
+//	docker run -d -p 8000:8000 amazon/dynamodb-local
+//	DYNAMODB_LOCAL_ENDPOINT=http://localhost:8000 go test ./aws/dynamodb/test/
+package test
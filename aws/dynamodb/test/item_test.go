@@ -0,0 +1,311 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/rssh-jp/data-access-library/aws/dynamodb"
+)
+
+type item struct {
+	Key     string   `dynamodbav:"key"`
+	Name    string   `dynamodbav:"name,omitempty"`
+	Count   int      `dynamodbav:"count"`
+	History []string `dynamodbav:"history,omitempty"`
+	Tags    []string `dynamodbav:"tags,omitempty,stringset"`
+	Version int64    `dynamodbav:"version,omitempty"`
+}
+
+func TestItem(t *testing.T) {
+	t.Run("Set, Get and Delete", func(t *testing.T) {
+		d := newDynamoDB(t)
+		createDefaultTable(t, d)
+
+		err := d.Set("k", "v1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = d.SetWithContext(context.Background(), "k", "v2")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual, err := d.Get("k")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != "v2" {
+			t.Errorf("Could not match value.\nexpect: %s\nactual: %s", "v2", actual)
+		}
+
+		err = d.Delete("k")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = d.GetWithContext(context.Background(), "k")
+		if !errors.Is(err, dynamodb.ErrNotFound) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrNotFound, err)
+		}
+
+		// deleting absent item succeeds
+		err = d.DeleteWithContext(context.Background(), "k")
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("SetByKey, GetByKey and DeleteByKey", func(t *testing.T) {
+		d := newDynamoDB(t)
+		d.DefaultSortKeyName = "sort"
+		createDefaultTable(t, d)
+
+		err := d.SetByKey(d.Key("k", "a"), "va")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = d.SetByKeyWithContext(context.Background(), d.Key("k", "b"), "vb")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = d.DeleteByKey(d.Key("k", "a"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = d.GetByKey(d.Key("k", "a"))
+		if !errors.Is(err, dynamodb.ErrNotFound) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrNotFound, err)
+		}
+
+		actual, err := d.GetByKeyWithContext(context.Background(), d.Key("k", "b"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != "vb" {
+			t.Errorf("Could not match value.\nexpect: %s\nactual: %s", "vb", actual)
+		}
+
+		err = d.DeleteByKeyWithContext(context.Background(), d.Key("k", "b"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("PutItem, GetItem and DeleteItem", func(t *testing.T) {
+		d := newDynamoDB(t)
+		createDefaultTable(t, d)
+
+		expect := &item{Key: "k", Name: "name", Count: 1, Tags: []string{"a"}}
+		err := dynamodb.PutItem(context.Background(), d, expect)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual, err := dynamodb.GetItem[item](context.Background(), d, d.Key("k"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(expect, actual) {
+			t.Errorf("Could not match item.\nexpect: %+v\nactual: %+v", expect, actual)
+		}
+
+		old, err := dynamodb.DeleteItemReturningOld[item](context.Background(), d, d.Key("k"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(expect, old) {
+			t.Errorf("Could not match old item.\nexpect: %+v\nactual: %+v", expect, old)
+		}
+
+		_, err = dynamodb.GetItem[item](context.Background(), d, d.Key("k"))
+		if !errors.Is(err, dynamodb.ErrNotFound) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrNotFound, err)
+		}
+		_, err = dynamodb.DeleteItemReturningOld[item](context.Background(), d, d.Key("k"))
+		if !errors.Is(err, dynamodb.ErrNotFound) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrNotFound, err)
+		}
+		err = dynamodb.DeleteItem(context.Background(), d, d.Key("k"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("WriteOptionCondition and WriteOptionTable", func(t *testing.T) {
+		d := newDynamoDB(t)
+		name := createTable(t, d, &dynamodb.TableDefinition{
+			Name:         "condition",
+			PartitionKey: dynamodb.KeyAttribute{Name: "key", Type: dynamodb.AttributeTypeString},
+		})
+		notExists := dynamodb.WriteOptionCondition(expression.AttributeNotExists(expression.Name("key")))
+
+		err := dynamodb.PutItem(context.Background(), d, &item{Key: "k", Count: 1}, dynamodb.WriteOptionTable(name), notExists)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = dynamodb.PutItem(context.Background(), d, &item{Key: "k", Count: 2}, dynamodb.WriteOptionTable(name), notExists)
+		var condErr *dynamodb.ConditionFailedError
+		if !errors.As(err, &condErr) || !errors.Is(err, dynamodb.ErrConditionFailed) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrConditionFailed, err)
+		}
+
+		err = dynamodb.DeleteItem(context.Background(), d, dynamodb.Key{"key": "k"},
+			dynamodb.WriteOptionTable(name),
+			dynamodb.WriteOptionCondition(expression.Name("count").Equal(expression.Value(2))),
+		)
+		if !errors.Is(err, dynamodb.ErrConditionFailed) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrConditionFailed, err)
+		}
+	})
+	t.Run("WriteOptionVersion", func(t *testing.T) {
+		d := newDynamoDB(t)
+		createDefaultTable(t, d)
+		version := dynamodb.WriteOptionVersion("version")
+
+		v := &item{Key: "k", Count: 1}
+		err := dynamodb.PutItem(context.Background(), d, v, version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v.Version != 1 {
+			t.Errorf("Could not match version.\nexpect: %d\nactual: %d", 1, v.Version)
+		}
+
+		stale := *v
+		v.Count = 2
+		err = dynamodb.PutItem(context.Background(), d, v, version)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = dynamodb.PutItem(context.Background(), d, &stale, version)
+		if !errors.Is(err, dynamodb.ErrConditionFailed) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrConditionFailed, err)
+		}
+		err = dynamodb.DeleteItem(context.Background(), d, dynamodb.Key{"key": "k", "version": stale.Version}, version)
+		if !errors.Is(err, dynamodb.ErrConditionFailed) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrConditionFailed, err)
+		}
+
+		err = dynamodb.DeleteItem(context.Background(), d, dynamodb.Key{"key": "k", "version": v.Version}, version)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("Update", func(t *testing.T) {
+		d := newDynamoDB(t)
+		createDefaultTable(t, d)
+
+		err := d.Update(context.Background(), d.Key("k"), dynamodb.NewUpdate().
+			Set("name", "first").
+			SetIfNotExists("count", 10).
+			Append("history", []string{"b"}).
+			Add("tags", dynamodb.StringSet{"a", "b", "c"}),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual, err := dynamodb.UpdateItem[item](context.Background(), d, d.Key("k"), dynamodb.NewUpdate().
+			Remove("name").
+			SetIfNotExists("count", 20).
+			Prepend("history", []string{"a"}).
+			Delete("tags", dynamodb.StringSet{"b"}),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect := &item{Key: "k", Count: 10, History: []string{"a", "b"}, Tags: []string{"a", "c"}}
+		if !reflect.DeepEqual(expect, actual) {
+			t.Errorf("Could not match item.\nexpect: %+v\nactual: %+v", expect, actual)
+		}
+
+		old, err := dynamodb.UpdateItem[item](context.Background(), d, d.Key("k"),
+			dynamodb.NewUpdate().Increment("count", 5),
+			dynamodb.WriteOptionReturnValues(types.ReturnValueAllOld),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if old.Count != 10 {
+			t.Errorf("Could not match old count.\nexpect: %d\nactual: %d", 10, old.Count)
+		}
+
+		actual, err = dynamodb.UpdateItem[item](context.Background(), d, d.Key("k"), dynamodb.NewUpdate().Decrement("count", 3))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual.Count != 12 {
+			t.Errorf("Could not match count.\nexpect: %d\nactual: %d", 12, actual.Count)
+		}
+
+		err = d.Update(context.Background(), d.Key("k"), dynamodb.NewUpdate().Set("name", "second"),
+			dynamodb.WriteOptionCondition(expression.Name("count").GreaterThan(expression.Value(100))),
+		)
+		if !errors.Is(err, dynamodb.ErrConditionFailed) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrConditionFailed, err)
+		}
+	})
+	t.Run("TTL", func(t *testing.T) {
+		d := newDynamoDB(t)
+		d.DefaultTTLName = "expires_at"
+		createDefaultTable(t, d)
+
+		// TTL is enabled by CreateDefaultTable, so EnableTTL does nothing
+		err := d.EnableTTL(d.DefaultTableName, d.DefaultTTLName)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = d.SetWithTTL("short", "v", time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = d.SetByKeyWithTTL(d.Key("long"), "v", time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual, err := d.Get("short")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != "v" {
+			t.Errorf("Could not match value.\nexpect: %s\nactual: %s", "v", actual)
+		}
+
+		// expiration time is in seconds
+		time.Sleep(2 * time.Second)
+
+		_, err = d.Get("short")
+		if !errors.Is(err, dynamodb.ErrNotFound) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrNotFound, err)
+		}
+		_, err = d.Get("long")
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("EnableTTL", func(t *testing.T) {
+		d := newDynamoDB(t)
+		name := createTable(t, d, &dynamodb.TableDefinition{
+			Name:         "ttl",
+			PartitionKey: dynamodb.KeyAttribute{Name: "id", Type: dynamodb.AttributeTypeString},
+		})
+
+		err := d.EnableTTL(name, "expires_at")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// enabling again does nothing
+		err = d.EnableTTLWithContext(context.Background(), name, "expires_at")
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+}
@@ -0,0 +1,129 @@
+package test
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/rssh-jp/data-access-library/aws/dynamodb"
+)
+
+const (
+	// endpointEnv is the environment variable of dynamodb-local endpoint. The tests are skipped when it is empty
+	endpointEnv = "DYNAMODB_LOCAL_ENDPOINT"
+
+	// maxWait is the maximum duration waiting for tables and indexes
+	maxWait = time.Minute
+)
+
+// endpoint returns dynamodb-local endpoint. The test is skipped when it is not set
+func endpoint(t *testing.T) string {
+	t.Helper()
+
+	url := os.Getenv(endpointEnv)
+	if url == "" {
+		t.Skipf("%s is not set", endpointEnv)
+	}
+	return url
+}
+
+// newDynamoDB returns DynamoDB instance of dynamodb-local whose DefaultTableName is unique to the test.
+// The default table is not created
+func newDynamoDB(t *testing.T) *dynamodb.DynamoDB {
+	t.Helper()
+
+	d, err := dynamodb.New(dynamodb.WithLocalEndpoint(endpoint(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.DefaultTableName = uniqueName(t, "default")
+
+	return d
+}
+
+// uniqueName returns table name of base unique to the test
+func uniqueName(t *testing.T, base string) string {
+	t.Helper()
+
+	b := make([]byte, 4)
+	_, err := rand.Read(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testName := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		}
+		return '_'
+	}, t.Name())
+
+	// table name is up to 255 characters
+	name := base + "." + hex.EncodeToString(b)
+	if len(testName) > 255-len(name)-1 {
+		testName = testName[:255-len(name)-1]
+	}
+
+	return testName + "." + name
+}
+
+// createDefaultTable creates the default table, waits until it becomes ACTIVE and deletes it after the test
+func createDefaultTable(t *testing.T, d *dynamodb.DynamoDB) {
+	t.Helper()
+
+	cleanupTable(t, d, d.DefaultTableName)
+
+	err := d.CreateDefaultTable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = d.WaitUntilActive(d.DefaultTableName, maxWait)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// createTable creates table of def with unique name, waits until it becomes ACTIVE and deletes it after the test.
+// def.Name is the base of the name and replaced with the unique name, which is returned
+func createTable(t *testing.T, d *dynamodb.DynamoDB, def *dynamodb.TableDefinition) string {
+	t.Helper()
+
+	def.Name = uniqueName(t, def.Name)
+	cleanupTable(t, d, def.Name)
+
+	err := d.EnsureTable(def, maxWait)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return def.Name
+}
+
+// cleanupTable deletes the table after the test. The table may be already deleted by the test
+func cleanupTable(t *testing.T, d *dynamodb.DynamoDB, name string) {
+	t.Helper()
+
+	t.Cleanup(func() {
+		err := d.DeleteTable(name)
+		var notFound *types.ResourceNotFoundException
+		if errors.As(err, &notFound) {
+			return
+		}
+		if err != nil {
+			t.Errorf("Could not delete table %s: %v", name, err)
+			return
+		}
+
+		err = d.WaitUntilDeleted(name, maxWait)
+		if err != nil {
+			t.Errorf("Could not delete table %s: %v", name, err)
+		}
+	})
+}
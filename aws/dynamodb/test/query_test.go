@@ -0,0 +1,331 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+
+	"github.com/rssh-jp/data-access-library/aws/dynamodb"
+)
+
+type order struct {
+	Customer string `dynamodbav:"key"`
+	ID       string `dynamodbav:"sort"`
+	Status   string `dynamodbav:"status,omitempty"`
+	Amount   int    `dynamodbav:"amount,omitempty"`
+}
+
+// newOrders returns DynamoDB instance whose default table has sort key and orders of customers c1 and c2.
+// c1 has orders 001 to 005 and order 005 is closed. c2 has orders 001 and 002
+func newOrders(t *testing.T) *dynamodb.DynamoDB {
+	t.Helper()
+
+	d := newDynamoDB(t)
+	d.DefaultSortKeyName = "sort"
+	createDefaultTable(t, d)
+
+	for _, o := range orders() {
+		err := dynamodb.PutItem(context.Background(), d, o)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return d
+}
+
+// orders returns orders stored by newOrders
+func orders() []order {
+	var orders []order
+	for i := 1; i <= 5; i++ {
+		o := order{Customer: "c1", ID: fmt.Sprintf("%03d", i), Status: "open", Amount: i * 100}
+		if i == 5 {
+			o.Status = "closed"
+		}
+		orders = append(orders, o)
+	}
+	orders = append(orders,
+		order{Customer: "c2", ID: "001", Status: "open", Amount: 10},
+		order{Customer: "c2", ID: "002", Status: "closed", Amount: 20},
+	)
+	return orders
+}
+
+// idsOf returns ID of orders
+func idsOf(orders []order) []string {
+	ids := make([]string, 0, len(orders))
+	for _, o := range orders {
+		ids = append(ids, o.Customer+"/"+o.ID)
+	}
+	return ids
+}
+
+func TestQuery(t *testing.T) {
+	c1 := expression.Key("key").Equal(expression.Value("c1"))
+
+	t.Run("Query pages", func(t *testing.T) {
+		d := newOrders(t)
+
+		var actual []order
+		token := ""
+		pages := 0
+		for {
+			page, err := d.Query(context.Background(), c1,
+				dynamodb.QueryOptionLimit(2),
+				dynamodb.QueryOptionConsistentRead(),
+				dynamodb.QueryOptionStartToken(token),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var items []order
+			err = page.Unmarshal(&items)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual = append(actual, items...)
+			pages++
+
+			token = page.NextToken
+			if token == "" {
+				break
+			}
+		}
+
+		expect := idsOf(orders()[:5])
+		if !reflect.DeepEqual(expect, idsOf(actual)) {
+			t.Errorf("Could not match orders.\nexpect: %v\nactual: %v", expect, idsOf(actual))
+		}
+		if pages < 3 {
+			t.Errorf("Bug. 5 orders should be read in 3 pages at least. But %d pages", pages)
+		}
+	})
+	t.Run("Query options", func(t *testing.T) {
+		d := newOrders(t)
+
+		page, err := d.Query(context.Background(), c1,
+			dynamodb.QueryOptionDescending(),
+			dynamodb.QueryOptionFilter(expression.Name("status").Equal(expression.Value("open"))),
+			dynamodb.QueryOptionProjection("key", "sort"),
+			dynamodb.QueryOptionReturnConsumedCapacity(),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var actual []order
+		err = page.Unmarshal(&actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect := []order{{Customer: "c1", ID: "004"}, {Customer: "c1", ID: "003"}, {Customer: "c1", ID: "002"}, {Customer: "c1", ID: "001"}}
+		if !reflect.DeepEqual(expect, actual) {
+			t.Errorf("Could not match orders.\nexpect: %+v\nactual: %+v", expect, actual)
+		}
+		if page.ScannedCount != 5 {
+			t.Errorf("Could not match scanned count.\nexpect: %d\nactual: %d", 5, page.ScannedCount)
+		}
+		if page.ConsumedCapacity <= 0 {
+			t.Errorf("Bug. consumed capacity should be returned. But %v", page.ConsumedCapacity)
+		}
+	})
+	t.Run("QueryAll", func(t *testing.T) {
+		d := newOrders(t)
+
+		var actual []order
+		for av, err := range d.QueryAll(context.Background(), c1, dynamodb.QueryOptionLimit(2)) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			var o order
+			err = attributevalue.UnmarshalMap(av, &o)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual = append(actual, o)
+		}
+
+		expect := orders()[:5]
+		if !reflect.DeepEqual(expect, actual) {
+			t.Errorf("Could not match orders.\nexpect: %+v\nactual: %+v", expect, actual)
+		}
+	})
+	t.Run("Scan and ScanAll", func(t *testing.T) {
+		d := newOrders(t)
+
+		page, err := d.Scan(context.Background(), dynamodb.QueryOptionLimit(3))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Items) != 3 || page.NextToken == "" {
+			t.Errorf("Bug. first page should have 3 items and next token. But %d items and %q", len(page.Items), page.NextToken)
+		}
+
+		var actual []order
+		for av, err := range d.ScanAll(context.Background(), dynamodb.QueryOptionLimit(3)) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			var o order
+			err = attributevalue.UnmarshalMap(av, &o)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual = append(actual, o)
+		}
+
+		expect := idsOf(orders())
+		ids := idsOf(actual)
+		slices.Sort(ids)
+		if !reflect.DeepEqual(expect, ids) {
+			t.Errorf("Could not match orders.\nexpect: %v\nactual: %v", expect, ids)
+		}
+	})
+	t.Run("ParallelScan", func(t *testing.T) {
+		d := newOrders(t)
+
+		checkpoint, err := dynamodb.NewFileScanCheckpoint(filepath.Join(t.TempDir(), "checkpoint.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var mu sync.Mutex
+		var ids []string
+		err = d.ParallelScan(context.Background(), 3, func(ctx context.Context, item *dynamodb.ScanItem) error {
+			var o order
+			err := attributevalue.UnmarshalMap(item.Item, &o)
+			if err != nil {
+				return err
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			ids = append(ids, o.Customer+"/"+o.ID)
+			return nil
+		},
+			dynamodb.ParallelScanOptionCheckpoint(checkpoint),
+			dynamodb.ParallelScanOptionCapacity(100),
+			dynamodb.ParallelScanOptionQueryOptions(dynamodb.QueryOptionLimit(2)),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expect := idsOf(orders())
+		slices.Sort(ids)
+		if !reflect.DeepEqual(expect, ids) {
+			t.Errorf("Could not match orders.\nexpect: %v\nactual: %v", expect, ids)
+		}
+
+		// finished segments are skipped by the checkpoint
+		err = d.ParallelScan(context.Background(), 3, func(ctx context.Context, item *dynamodb.ScanItem) error {
+			t.Errorf("Bug. finished segment should be skipped. But %v", item.Item)
+			return nil
+		}, dynamodb.ParallelScanOptionCheckpoint(checkpoint))
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("ParallelScanToChannel", func(t *testing.T) {
+		d := newOrders(t)
+
+		ch := make(chan *dynamodb.ScanItem)
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- d.ParallelScanToChannel(context.Background(), 2, ch,
+				dynamodb.ParallelScanOptionQueryOptions(dynamodb.QueryOptionFilter(expression.Name("status").Equal(expression.Value("closed")))),
+			)
+		}()
+
+		var ids []string
+		for item := range ch {
+			var o order
+			err := attributevalue.UnmarshalMap(item.Item, &o)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, o.Customer+"/"+o.ID)
+		}
+		err := <-errCh
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expect := []string{"c1/005", "c2/002"}
+		slices.Sort(ids)
+		if !reflect.DeepEqual(expect, ids) {
+			t.Errorf("Could not match orders.\nexpect: %v\nactual: %v", expect, ids)
+		}
+	})
+	t.Run("Global index", func(t *testing.T) {
+		d := newOrders(t)
+		index := &dynamodb.IndexDefinition{
+			Name:           "by_status",
+			PartitionKey:   dynamodb.KeyAttribute{Name: "status", Type: dynamodb.AttributeTypeString},
+			SortKey:        &dynamodb.KeyAttribute{Name: "amount", Type: dynamodb.AttributeTypeNumber},
+			ProjectionType: dynamodb.ProjectionAll,
+		}
+
+		err := d.CreateGlobalIndex(d.DefaultTableName, index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = d.WaitUntilIndexActive(d.DefaultTableName, index.Name, maxWait)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		status, err := d.GlobalIndexStatus(d.DefaultTableName, index.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !status.Active() {
+			t.Errorf("Bug. index should be active. But %+v", status)
+		}
+
+		closed := expression.Key("status").Equal(expression.Value("closed"))
+		actual, token, err := dynamodb.QueryIndex[order](context.Background(), d, index.Name, closed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect := []order{orders()[6], orders()[4]}
+		if !reflect.DeepEqual(expect, actual) || token != "" {
+			t.Errorf("Could not match orders.\nexpect: %+v\nactual: %+v %q", expect, actual, token)
+		}
+
+		var all []order
+		open := expression.Key("status").Equal(expression.Value("open")).And(expression.Key("amount").GreaterThanEqual(expression.Value(100)))
+		for o, err := range dynamodb.QueryIndexAll[order](context.Background(), d, index.Name, open, dynamodb.QueryOptionLimit(1), dynamodb.QueryOptionDescending()) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			all = append(all, *o)
+		}
+		expectAll := []order{orders()[3], orders()[2], orders()[1], orders()[0]}
+		if !reflect.DeepEqual(expectAll, all) {
+			t.Errorf("Could not match orders.\nexpect: %+v\nactual: %+v", expectAll, all)
+		}
+
+		err = d.DeleteGlobalIndex(d.DefaultTableName, index.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = d.WaitUntilIndexDeleted(d.DefaultTableName, index.Name, maxWait)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = d.GlobalIndexStatus(d.DefaultTableName, index.Name)
+		if !errors.Is(err, dynamodb.ErrNotFound) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrNotFound, err)
+		}
+	})
+}
@@ -0,0 +1,202 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/rssh-jp/data-access-library/aws/dynamodb"
+)
+
+func TestTable(t *testing.T) {
+	t.Run("NewFromConfig with endpoint", func(t *testing.T) {
+		url := endpoint(t)
+
+		cfg, err := config.LoadDefaultConfig(context.Background(),
+			dynamodb.WithEndpoint(url),
+			config.WithRegion("ap-northeast-1"),
+			config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider("dummy", "dummy", "dummy")),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d := dynamodb.NewFromConfig(cfg)
+		d.DefaultTableName = uniqueName(t, "default")
+		createDefaultTable(t, d)
+
+		err = d.Set("k", "v")
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, err := d.Get("k")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != "v" {
+			t.Errorf("Could not match value.\nexpect: %s\nactual: %s", "v", actual)
+		}
+	})
+	t.Run("CreateTable and TableNames", func(t *testing.T) {
+		d := newDynamoDB(t)
+		name := uniqueName(t, "table")
+		cleanupTable(t, d, name)
+
+		err := d.CreateTable(name, "id")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = d.WaitUntilActive(name, maxWait)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		names, err := d.TableNames()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Contains(names, name) {
+			t.Errorf("Bug. %s should be listed. But %v", name, names)
+		}
+
+		var inUse *types.ResourceInUseException
+		err = d.CreateTable(name, "id")
+		if !errors.As(err, &inUse) {
+			t.Errorf("Could not match error.\nexpect: %T\nactual: %v", inUse, err)
+		}
+	})
+	t.Run("CreateTableFromDefinition and EnsureTable", func(t *testing.T) {
+		d := newDynamoDB(t)
+		def := &dynamodb.TableDefinition{
+			Name:         "definition",
+			PartitionKey: dynamodb.KeyAttribute{Name: "pk", Type: dynamodb.AttributeTypeString},
+			SortKey:      &dynamodb.KeyAttribute{Name: "sk", Type: dynamodb.AttributeTypeNumber},
+			BillingMode:  dynamodb.BillingModeProvisioned,
+			Throughput:   &dynamodb.Throughput{Read: 5, Write: 5},
+			TTLAttribute: "expires_at",
+			GlobalIndexes: []*dynamodb.IndexDefinition{
+				{
+					Name:           "by_status",
+					PartitionKey:   dynamodb.KeyAttribute{Name: "status", Type: dynamodb.AttributeTypeString},
+					ProjectionType: dynamodb.ProjectionKeysOnly,
+					Throughput:     &dynamodb.Throughput{Read: 5, Write: 5},
+				},
+			},
+			LocalIndexes: []*dynamodb.IndexDefinition{
+				{
+					Name:             "by_name",
+					PartitionKey:     dynamodb.KeyAttribute{Name: "pk", Type: dynamodb.AttributeTypeString},
+					SortKey:          &dynamodb.KeyAttribute{Name: "name", Type: dynamodb.AttributeTypeString},
+					ProjectionType:   dynamodb.ProjectionInclude,
+					NonKeyAttributes: []string{"status"},
+				},
+			},
+		}
+		name := uniqueName(t, def.Name)
+		def.Name = name
+		cleanupTable(t, d, name)
+
+		err := d.CreateTableFromDefinition(def)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// EnsureTable waits for the existing table
+		err = d.EnsureTable(def, maxWait)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		status, err := d.GlobalIndexStatus(name, "by_status")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !status.Active() {
+			t.Errorf("Bug. index should be active. But %+v", status)
+		}
+
+		expect := dynamodb.Key{"pk": "a", "sk": 1}
+		actual := def.Key("a", 1)
+		if !reflect.DeepEqual(expect, actual) {
+			t.Errorf("Could not match key.\nexpect: %v\nactual: %v", expect, actual)
+		}
+	})
+	t.Run("CreateDefaultTable with sort key", func(t *testing.T) {
+		d := newDynamoDB(t)
+		d.DefaultSortKeyName = "sort"
+		createDefaultTable(t, d)
+
+		err := d.SetByKey(d.Key("k", "1"), "v1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = d.SetByKey(d.Key("k", "2"), "v2")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual, err := d.GetByKey(d.Key("k", "2"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != "v2" {
+			t.Errorf("Could not match value.\nexpect: %s\nactual: %s", "v2", actual)
+		}
+	})
+	t.Run("DeleteTable and WaitUntilDeleted", func(t *testing.T) {
+		d := newDynamoDB(t)
+		name := createTable(t, d, &dynamodb.TableDefinition{
+			Name:         "deleted",
+			PartitionKey: dynamodb.KeyAttribute{Name: "id", Type: dynamodb.AttributeTypeString},
+		})
+
+		err := d.DeleteTable(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = d.WaitUntilDeleted(name, maxWait)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		names, err := d.TableNames()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if slices.Contains(names, name) {
+			t.Errorf("Bug. %s should not be listed. But %v", name, names)
+		}
+	})
+	t.Run("Truncate", func(t *testing.T) {
+		d := newDynamoDB(t)
+		createDefaultTable(t, d)
+
+		for _, key := range []string{"a", "b", "c"} {
+			err := d.Set(key, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		n, err := d.Truncate(context.Background(), "", 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 3 {
+			t.Errorf("Could not match count.\nexpect: %d\nactual: %d", 3, n)
+		}
+
+		page, err := d.Scan(context.Background(), dynamodb.QueryOptionConsistentRead())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Items) != 0 {
+			t.Errorf("Bug. table should be empty. But %v", page.Items)
+		}
+	})
+}
@@ -0,0 +1,226 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+
+	"github.com/rssh-jp/data-access-library/aws/dynamodb"
+)
+
+type wallet struct {
+	ID      string `dynamodbav:"id"`
+	Balance int    `dynamodbav:"balance"`
+}
+
+func TestBatch(t *testing.T) {
+	t.Run("BatchWrite and BatchGet", func(t *testing.T) {
+		d := newDynamoDB(t)
+		name := createTable(t, d, &dynamodb.TableDefinition{
+			Name:         "wallets",
+			PartitionKey: dynamodb.KeyAttribute{Name: "id", Type: dynamodb.AttributeTypeString},
+		})
+		table := dynamodb.BatchOptionTable(name)
+
+		// more than 25 requests are split into chunks
+		var requests []*dynamodb.WriteRequest
+		var keys []dynamodb.Key
+		for i := range 30 {
+			id := fmt.Sprintf("w%02d", i)
+			requests = append(requests, dynamodb.PutRequest(&wallet{ID: id, Balance: i}))
+			keys = append(keys, dynamodb.Key{"id": id})
+		}
+		res, err := d.BatchWrite(context.Background(), requests, table,
+			dynamodb.BatchOptionConcurrency(2),
+			dynamodb.BatchOptionRetry(3, 10*time.Millisecond, 100*time.Millisecond),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Errors) != 0 {
+			t.Errorf("Bug. there should be no error. But %v", res.Errors)
+		}
+
+		got, err := d.BatchGet(context.Background(), append(keys, dynamodb.Key{"id": "absent"}), table, dynamodb.BatchOptionConsistentRead())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var wallets []wallet
+		err = got.Unmarshal(&wallets)
+		if err != nil {
+			t.Fatal(err)
+		}
+		slices.SortFunc(wallets, func(a, b wallet) int { return a.Balance - b.Balance })
+		if len(wallets) != 30 || wallets[29] != (wallet{ID: "w29", Balance: 29}) {
+			t.Errorf("Bug. 30 wallets should be read. But %+v", wallets)
+		}
+
+		deletes := make([]*dynamodb.WriteRequest, 0, len(keys))
+		for _, key := range keys {
+			deletes = append(deletes, dynamodb.DeleteRequest(key))
+		}
+		_, err = d.BatchWrite(context.Background(), deletes, table)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err = d.BatchGet(context.Background(), keys, table, dynamodb.BatchOptionConsistentRead())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Items) != 0 {
+			t.Errorf("Bug. wallets should be deleted. But %v", got.Items)
+		}
+	})
+	t.Run("EnableRateLimit", func(t *testing.T) {
+		d := newDynamoDB(t)
+		createDefaultTable(t, d)
+
+		l, err := d.EnableRateLimit(dynamodb.RateLimitOptionCapacity(d.DefaultTableName, dynamodb.Throughput{Read: 100, Write: 100}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var requests []*dynamodb.WriteRequest
+		for i := range 30 {
+			requests = append(requests, dynamodb.PutRequest(map[string]any{d.DefaultKeyName: fmt.Sprintf("k%02d", i)}))
+		}
+		_, err = d.BatchWrite(context.Background(), requests)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual := l.Usage(d.DefaultTableName)
+		if actual.Write < 30 || actual.WriteLimit != 100 {
+			t.Errorf("Bug. 30 units should be consumed under 100 units per second. But %+v", actual)
+		}
+	})
+}
+
+func TestTransaction(t *testing.T) {
+	newWallets := func(t *testing.T) (*dynamodb.DynamoDB, string) {
+		t.Helper()
+
+		d := newDynamoDB(t)
+		name := createTable(t, d, &dynamodb.TableDefinition{
+			Name:         "wallets",
+			PartitionKey: dynamodb.KeyAttribute{Name: "id", Type: dynamodb.AttributeTypeString},
+		})
+
+		err := d.Transaction().
+			Put(&wallet{ID: "a", Balance: 100}, dynamodb.WriteOptionTable(name)).
+			Put(&wallet{ID: "b", Balance: 0}, dynamodb.WriteOptionTable(name)).
+			Commit(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return d, name
+	}
+	transfer := func(d *dynamodb.DynamoDB, name, from, to string, amount int) *dynamodb.Transaction {
+		table := dynamodb.WriteOptionTable(name)
+		return d.Transaction().
+			Update(dynamodb.Key{"id": from}, dynamodb.NewUpdate().Decrement("balance", amount), table,
+				dynamodb.WriteOptionCondition(expression.Name("balance").GreaterThanEqual(expression.Value(amount)))).
+			Update(dynamodb.Key{"id": to}, dynamodb.NewUpdate().Increment("balance", amount), table)
+	}
+	balances := func(t *testing.T, d *dynamodb.DynamoDB, name string, ids ...string) []int {
+		t.Helper()
+
+		requests := make([]*dynamodb.GetRequest, 0, len(ids))
+		for _, id := range ids {
+			requests = append(requests, &dynamodb.GetRequest{TableName: name, Key: dynamodb.Key{"id": id}})
+		}
+
+		res, err := d.TransactGet(context.Background(), requests)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ret := make([]int, 0, len(ids))
+		for i := range ids {
+			var w wallet
+			err := res.Unmarshal(i, &w)
+			if errors.Is(err, dynamodb.ErrNotFound) {
+				ret = append(ret, -1)
+				continue
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			ret = append(ret, w.Balance)
+		}
+		return ret
+	}
+
+	t.Run("Commit", func(t *testing.T) {
+		d, name := newWallets(t)
+
+		tx := transfer(d, name, "a", "b", 30)
+		if tx.Len() != 2 {
+			t.Errorf("Could not match length.\nexpect: %d\nactual: %d", 2, tx.Len())
+		}
+		err := tx.Commit(context.Background(), dynamodb.TransactOptionClientRequestToken("transfer-1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// retry with the same token is not applied twice
+		err = transfer(d, name, "a", "b", 30).Commit(context.Background(), dynamodb.TransactOptionClientRequestToken("transfer-1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expect := []int{70, 30}
+		actual := balances(t, d, name, "a", "b")
+		if !reflect.DeepEqual(expect, actual) {
+			t.Errorf("Could not match balances.\nexpect: %v\nactual: %v", expect, actual)
+		}
+	})
+	t.Run("Canceled", func(t *testing.T) {
+		d, name := newWallets(t)
+
+		err := transfer(d, name, "b", "a", 30).Commit(context.Background())
+		var canceled *dynamodb.TransactionCanceledError
+		if !errors.As(err, &canceled) {
+			t.Fatalf("Could not match error.\nexpect: %T\nactual: %v", canceled, err)
+		}
+		if !errors.Is(err, dynamodb.ErrTransactionCanceled) || !errors.Is(err, dynamodb.ErrConditionFailed) {
+			t.Errorf("Bug. error should match ErrTransactionCanceled and ErrConditionFailed. But %v", err)
+		}
+		if len(canceled.Reasons) != 1 || canceled.Reasons[0].Index != 0 {
+			t.Errorf("Bug. the first operation should cause the cancellation. But %v", err)
+		}
+
+		expect := []int{100, 0}
+		actual := balances(t, d, name, "a", "b")
+		if !reflect.DeepEqual(expect, actual) {
+			t.Errorf("Could not match balances.\nexpect: %v\nactual: %v", expect, actual)
+		}
+	})
+	t.Run("Delete and ConditionCheck", func(t *testing.T) {
+		d, name := newWallets(t)
+		table := dynamodb.WriteOptionTable(name)
+
+		err := d.Transaction().
+			ConditionCheck(dynamodb.Key{"id": "a"}, expression.Name("balance").GreaterThan(expression.Value(0)), table).
+			Delete(dynamodb.Key{"id": "b"}, table).
+			Put(&wallet{ID: "c", Balance: 1}, table).
+			Commit(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expect := []int{100, -1, 1}
+		actual := balances(t, d, name, "a", "b", "c")
+		if !reflect.DeepEqual(expect, actual) {
+			t.Errorf("Could not match balances.\nexpect: %v\nactual: %v", expect, actual)
+		}
+	})
+}
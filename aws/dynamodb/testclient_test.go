@@ -0,0 +1,257 @@
+package dynamodb_test
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsdynamodb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/rssh-jp/data-access-library/aws/dynamodb"
+	"github.com/rssh-jp/data-access-library/aws/dynamodb/memory"
+)
+
+// testClient is the in-memory client recording the last request of every operation.
+// It also reproduces states which the in-memory client skips, such as unprocessed batches and tables being created
+type testClient struct {
+	*memory.Client
+
+	mu sync.Mutex
+	// requests is the last request by type of the input
+	requests map[string]any
+	// unprocessedBatches is the number of batch responses leaving the last key or request unprocessed
+	unprocessedBatches int
+	// creatingDescribes is the number of DescribeTable responses with CREATING status after table is created
+	creatingDescribes int
+	// creatingIndexDescribes is the number of DescribeTable responses with backfilling index after the index is created by UpdateTable
+	creatingIndexDescribes int
+
+	// creating is the remaining CREATING responses by table name, or by table and index name
+	creating map[string]int
+}
+
+func newTestClient(t *testing.T) *testClient {
+	t.Helper()
+
+	c, err := memory.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testClient{
+		Client:   c,
+		requests: make(map[string]any),
+		creating: make(map[string]int),
+	}
+}
+
+// newDynamoDB returns DynamoDB instance sending requests and stream requests to the client
+func (c *testClient) newDynamoDB() *dynamodb.DynamoDB {
+	d := dynamodb.NewFromClient(c)
+	d.Streams = c.Client
+
+	return d
+}
+
+func (c *testClient) record(params any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.requests[fmt.Sprintf("%T", params)] = params
+}
+
+// lastRequest returns the last request of the input type T
+func lastRequest[T any](t *testing.T, c *testClient) *T {
+	t.Helper()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	req, ok := c.requests[fmt.Sprintf("%T", (*T)(nil))].(*T)
+	if !ok {
+		t.Fatalf("Could not find request of %T", (*T)(nil))
+	}
+	return req
+}
+
+// ttlAttribute returns the attribute of TTL of the table. empty when TTL is disabled
+func (c *testClient) ttlAttribute(t *testing.T, tableName string) string {
+	t.Helper()
+
+	res, err := c.Client.DescribeTimeToLive(context.Background(), &awsdynamodb.DescribeTimeToLiveInput{TableName: aws.String(tableName)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.TimeToLiveDescription.TimeToLiveStatus != types.TimeToLiveStatusEnabled {
+		return ""
+	}
+	return aws.ToString(res.TimeToLiveDescription.AttributeName)
+}
+
+// takeUnprocessed reports whether the batch leaves the last key or request unprocessed
+func (c *testClient) takeUnprocessed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.unprocessedBatches == 0 {
+		return false
+	}
+	c.unprocessedBatches--
+	return true
+}
+
+func (c *testClient) CreateTable(ctx context.Context, params *awsdynamodb.CreateTableInput, optFns ...func(*awsdynamodb.Options)) (*awsdynamodb.CreateTableOutput, error) {
+	c.record(params)
+
+	res, err := c.Client.CreateTable(ctx, params, optFns...)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.creating[aws.ToString(params.TableName)] = c.creatingDescribes
+	res.TableDescription.TableStatus = types.TableStatusCreating
+
+	return res, nil
+}
+
+func (c *testClient) DescribeTable(ctx context.Context, params *awsdynamodb.DescribeTableInput, optFns ...func(*awsdynamodb.Options)) (*awsdynamodb.DescribeTableOutput, error) {
+	res, err := c.Client.DescribeTable(ctx, params, optFns...)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	name := aws.ToString(params.TableName)
+	if c.creating[name] > 0 {
+		c.creating[name]--
+		res.Table.TableStatus = types.TableStatusCreating
+	}
+	for i, index := range res.Table.GlobalSecondaryIndexes {
+		key := name + "/" + aws.ToString(index.IndexName)
+		if c.creating[key] > 0 {
+			c.creating[key]--
+			res.Table.GlobalSecondaryIndexes[i].IndexStatus = types.IndexStatusCreating
+			res.Table.GlobalSecondaryIndexes[i].Backfilling = aws.Bool(true)
+		}
+	}
+
+	return res, nil
+}
+
+func (c *testClient) UpdateTable(ctx context.Context, params *awsdynamodb.UpdateTableInput, optFns ...func(*awsdynamodb.Options)) (*awsdynamodb.UpdateTableOutput, error) {
+	c.record(params)
+
+	res, err := c.Client.UpdateTable(ctx, params, optFns...)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, u := range params.GlobalSecondaryIndexUpdates {
+		if u.Create != nil {
+			c.creating[aws.ToString(params.TableName)+"/"+aws.ToString(u.Create.IndexName)] = c.creatingIndexDescribes
+		}
+	}
+
+	return res, nil
+}
+
+func (c *testClient) Query(ctx context.Context, params *awsdynamodb.QueryInput, optFns ...func(*awsdynamodb.Options)) (*awsdynamodb.QueryOutput, error) {
+	c.record(params)
+	return c.Client.Query(ctx, params, optFns...)
+}
+
+func (c *testClient) Scan(ctx context.Context, params *awsdynamodb.ScanInput, optFns ...func(*awsdynamodb.Options)) (*awsdynamodb.ScanOutput, error) {
+	c.record(params)
+	return c.Client.Scan(ctx, params, optFns...)
+}
+
+func (c *testClient) TransactWriteItems(ctx context.Context, params *awsdynamodb.TransactWriteItemsInput, optFns ...func(*awsdynamodb.Options)) (*awsdynamodb.TransactWriteItemsOutput, error) {
+	c.record(params)
+	return c.Client.TransactWriteItems(ctx, params, optFns...)
+}
+
+func (c *testClient) BatchGetItem(ctx context.Context, params *awsdynamodb.BatchGetItemInput, optFns ...func(*awsdynamodb.Options)) (*awsdynamodb.BatchGetItemOutput, error) {
+	c.record(params)
+
+	in := *params
+	in.RequestItems = maps.Clone(params.RequestItems)
+	unprocessed := make(map[string]types.KeysAndAttributes)
+	for _, name := range slices.Sorted(maps.Keys(in.RequestItems)) {
+		ka := in.RequestItems[name]
+		if !c.takeUnprocessed() {
+			continue
+		}
+
+		left := ka
+		left.Keys = ka.Keys[len(ka.Keys)-1:]
+		unprocessed[name] = left
+		ka.Keys = ka.Keys[:len(ka.Keys)-1]
+		if len(ka.Keys) == 0 {
+			delete(in.RequestItems, name)
+			continue
+		}
+		in.RequestItems[name] = ka
+	}
+
+	res := &awsdynamodb.BatchGetItemOutput{Responses: make(map[string][]map[string]types.AttributeValue)}
+	if len(in.RequestItems) > 0 {
+		var err error
+		res, err = c.Client.BatchGetItem(ctx, &in, optFns...)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(unprocessed) > 0 {
+		res.UnprocessedKeys = unprocessed
+	}
+
+	return res, nil
+}
+
+func (c *testClient) BatchWriteItem(ctx context.Context, params *awsdynamodb.BatchWriteItemInput, optFns ...func(*awsdynamodb.Options)) (*awsdynamodb.BatchWriteItemOutput, error) {
+	c.record(params)
+
+	in := *params
+	in.RequestItems = maps.Clone(params.RequestItems)
+	unprocessed := make(map[string][]types.WriteRequest)
+	for _, name := range slices.Sorted(maps.Keys(in.RequestItems)) {
+		writes := in.RequestItems[name]
+		if !c.takeUnprocessed() {
+			continue
+		}
+
+		unprocessed[name] = writes[len(writes)-1:]
+		if len(writes) == 1 {
+			delete(in.RequestItems, name)
+			continue
+		}
+		in.RequestItems[name] = writes[:len(writes)-1]
+	}
+
+	res := &awsdynamodb.BatchWriteItemOutput{}
+	if len(in.RequestItems) > 0 {
+		var err error
+		res, err = c.Client.BatchWriteItem(ctx, &in, optFns...)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(unprocessed) > 0 {
+		res.UnprocessedItems = unprocessed
+	}
+
+	return res, nil
+}
@@ -0,0 +1,406 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// transactSize is the maximum number of operations of TransactWriteItems and TransactGetItems request
+	transactSize = 100
+
+	// cancellationNone is the code of CancellationReason of the operation which did not cause the cancellation
+	cancellationNone = "None"
+	// cancellationConditionalCheckFailed is the code of CancellationReason of the operation whose condition failed
+	cancellationConditionalCheckFailed = "ConditionalCheckFailed"
+)
+
+var (
+	// ErrTransactionCanceled is matched by errors.Is when the transaction is canceled
+	ErrTransactionCanceled = errors.New("transaction canceled")
+)
+
+// CancellationReason is the reason why an operation of the transaction canceled it
+type CancellationReason struct {
+	// Index is the position of the operation in the transaction
+	Index int
+	// Code is e.g. "ConditionalCheckFailed", "TransactionConflict", "ValidationError"
+	Code    string
+	Message string
+	// Item is the stored item when the condition failed. nil when the item does not exist
+	Item map[string]types.AttributeValue
+}
+
+// TransactionCanceledError is returned when the transaction is canceled.
+// It matches ErrTransactionCanceled, and also ErrConditionFailed when any condition failed
+type TransactionCanceledError struct {
+	// Reasons is the reasons of the operations which caused the cancellation in order of Index
+	Reasons []*CancellationReason
+	Err     error
+}
+
+// Error returns the error message with reasons
+func (e *TransactionCanceledError) Error() string {
+	reasons := make([]string, 0, len(e.Reasons))
+	for _, r := range e.Reasons {
+		reasons = append(reasons, fmt.Sprintf("operation %d: %s", r.Index, r.Code))
+	}
+	return fmt.Sprintf("%v [%s]: %v", ErrTransactionCanceled, strings.Join(reasons, ", "), e.Err)
+}
+
+// Is reports whether target is ErrTransactionCanceled or ErrConditionFailed of failed condition
+func (e *TransactionCanceledError) Is(target error) bool {
+	switch target {
+	case ErrTransactionCanceled:
+		return true
+	case ErrConditionFailed:
+		for _, r := range e.Reasons {
+			if r.Code == cancellationConditionalCheckFailed {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Unwrap returns the cause
+func (e *TransactionCanceledError) Unwrap() error {
+	return e.Err
+}
+
+// transactionError returns TransactionCanceledError when err is TransactionCanceledException
+func transactionError(err error) error {
+	var tce *types.TransactionCanceledException
+	if !errors.As(err, &tce) {
+		return err
+	}
+
+	ret := &TransactionCanceledError{Err: err}
+	for i, r := range tce.CancellationReasons {
+		code := aws.ToString(r.Code)
+		if code == "" || code == cancellationNone {
+			continue
+		}
+		ret.Reasons = append(ret.Reasons, &CancellationReason{
+			Index:   i,
+			Code:    code,
+			Message: aws.ToString(r.Message),
+			Item:    r.Item,
+		})
+	}
+
+	return ret
+}
+
+type transactConfig struct {
+	clientRequestToken string
+	clientOpts         []func(*dynamodb.Options)
+}
+
+func newTransactConfig() *transactConfig {
+	return &transactConfig{
+		clientRequestToken: "",
+		clientOpts:         nil,
+	}
+}
+
+func createTransactConfig(txOpts ...TransactOption) (*transactConfig, error) {
+	tc := newTransactConfig()
+
+	for _, opt := range txOpts {
+		err := opt(tc)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return tc, nil
+}
+
+// TransactOption is functional option pattern option for Transaction.Commit and TransactGet
+type TransactOption func(*transactConfig) error
+
+// TransactOptionClientRequestToken returns TransactOption instance making Commit idempotent by token.
+// Commit retried with the same token within 10 minutes succeeds without writing again.
+// The token is generated per Commit by default. It is ignored by TransactGet
+func TransactOptionClientRequestToken(token string) func(c *transactConfig) error {
+	return func(c *transactConfig) error {
+		if token == "" {
+			return errors.New("client request token is empty")
+		}
+		c.clientRequestToken = token
+		return nil
+	}
+}
+
+// TransactOptionClientOptions returns TransactOption instance with request options
+func TransactOptionClientOptions(optFns ...func(*dynamodb.Options)) func(c *transactConfig) error {
+	return func(c *transactConfig) error {
+		c.clientOpts = append(c.clientOpts, optFns...)
+		return nil
+	}
+}
+
+// Transaction is builder of operations written atomically by Commit.
+// Operations accept WriteOption, so every operation can have its own table, condition and version.
+// WriteOptionClientOptions and WriteOptionReturnValues of the operations are ignored
+type Transaction struct {
+	d     *DynamoDB
+	items []types.TransactWriteItem
+	// versioned is called after Commit succeeds to update items passed by pointer to the new version
+	versioned []func() error
+	err       error
+}
+
+// Transaction returns empty Transaction
+func (d *DynamoDB) Transaction() *Transaction {
+	return &Transaction{d: d}
+}
+
+// Len returns the number of operations
+func (t *Transaction) Len() int {
+	return len(t.items)
+}
+
+// Put adds operation putting item. The same as PutItem, item passed by pointer is updated to the new version after Commit
+func (t *Transaction) Put(item any, writeOpts ...WriteOption) *Transaction {
+	t.add(func() (types.TransactWriteItem, error) {
+		wc, err := createWriteConfig(writeOpts...)
+		if err != nil {
+			return types.TransactWriteItem{}, err
+		}
+
+		in, av, err := t.d.putInput(wc, item)
+		if err != nil {
+			return types.TransactWriteItem{}, err
+		}
+
+		if wc.versionName != "" && reflect.ValueOf(item).Kind() == reflect.Pointer {
+			t.versioned = append(t.versioned, func() error {
+				return attributevalue.UnmarshalMap(av, item)
+			})
+		}
+
+		return types.TransactWriteItem{Put: &types.Put{
+			Item:                                in.Item,
+			TableName:                           in.TableName,
+			ConditionExpression:                 in.ConditionExpression,
+			ExpressionAttributeNames:            in.ExpressionAttributeNames,
+			ExpressionAttributeValues:           in.ExpressionAttributeValues,
+			ReturnValuesOnConditionCheckFailure: in.ReturnValuesOnConditionCheckFailure,
+		}}, nil
+	})
+	return t
+}
+
+// Update adds operation updating the item with key by u
+func (t *Transaction) Update(key Key, u *Update, writeOpts ...WriteOption) *Transaction {
+	t.add(func() (types.TransactWriteItem, error) {
+		wc, err := createWriteConfig(writeOpts...)
+		if err != nil {
+			return types.TransactWriteItem{}, err
+		}
+
+		in, err := t.d.updateInput(wc, key, u)
+		if err != nil {
+			return types.TransactWriteItem{}, err
+		}
+
+		return types.TransactWriteItem{Update: &types.Update{
+			Key:                                 in.Key,
+			TableName:                           in.TableName,
+			UpdateExpression:                    in.UpdateExpression,
+			ConditionExpression:                 in.ConditionExpression,
+			ExpressionAttributeNames:            in.ExpressionAttributeNames,
+			ExpressionAttributeValues:           in.ExpressionAttributeValues,
+			ReturnValuesOnConditionCheckFailure: in.ReturnValuesOnConditionCheckFailure,
+		}}, nil
+	})
+	return t
+}
+
+// Delete adds operation deleting the item with key
+func (t *Transaction) Delete(key Key, writeOpts ...WriteOption) *Transaction {
+	t.add(func() (types.TransactWriteItem, error) {
+		wc, err := createWriteConfig(writeOpts...)
+		if err != nil {
+			return types.TransactWriteItem{}, err
+		}
+
+		in, err := t.d.deleteInput(wc, key)
+		if err != nil {
+			return types.TransactWriteItem{}, err
+		}
+
+		return types.TransactWriteItem{Delete: &types.Delete{
+			Key:                                 in.Key,
+			TableName:                           in.TableName,
+			ConditionExpression:                 in.ConditionExpression,
+			ExpressionAttributeNames:            in.ExpressionAttributeNames,
+			ExpressionAttributeValues:           in.ExpressionAttributeValues,
+			ReturnValuesOnConditionCheckFailure: in.ReturnValuesOnConditionCheckFailure,
+		}}, nil
+	})
+	return t
+}
+
+// ConditionCheck adds operation checking cond against the item with key without writing it
+func (t *Transaction) ConditionCheck(key Key, cond expression.ConditionBuilder, writeOpts ...WriteOption) *Transaction {
+	t.add(func() (types.TransactWriteItem, error) {
+		wc, err := createWriteConfig(append(slices.Clone(writeOpts), WriteOptionCondition(cond))...)
+		if err != nil {
+			return types.TransactWriteItem{}, err
+		}
+
+		in, err := t.d.deleteInput(wc, key)
+		if err != nil {
+			return types.TransactWriteItem{}, err
+		}
+
+		return types.TransactWriteItem{ConditionCheck: &types.ConditionCheck{
+			Key:                                 in.Key,
+			TableName:                           in.TableName,
+			ConditionExpression:                 in.ConditionExpression,
+			ExpressionAttributeNames:            in.ExpressionAttributeNames,
+			ExpressionAttributeValues:           in.ExpressionAttributeValues,
+			ReturnValuesOnConditionCheckFailure: in.ReturnValuesOnConditionCheckFailure,
+		}}, nil
+	})
+	return t
+}
+
+// add adds the operation built by fn. The first error is kept and returned by Commit
+func (t *Transaction) add(fn func() (types.TransactWriteItem, error)) {
+	if t.err != nil {
+		return
+	}
+
+	item, err := fn()
+	if err != nil {
+		t.err = fmt.Errorf("operation %d: %w", len(t.items), err)
+		return
+	}
+
+	t.items = append(t.items, item)
+}
+
+// Commit writes all operations atomically. Nothing is written when any operation fails.
+// The error matches ErrTransactionCanceled with TransactionCanceledError when the transaction is canceled
+func (t *Transaction) Commit(ctx context.Context, txOpts ...TransactOption) error {
+	if t.err != nil {
+		return t.err
+	}
+	if len(t.items) == 0 {
+		return errors.New("transaction has no operation")
+	}
+	if len(t.items) > transactSize {
+		return fmt.Errorf("transaction has %d operations. the limit is %d", len(t.items), transactSize)
+	}
+
+	tc, err := createTransactConfig(txOpts...)
+	if err != nil {
+		return err
+	}
+
+	in := &dynamodb.TransactWriteItemsInput{
+		TransactItems: t.items,
+	}
+	if tc.clientRequestToken != "" {
+		in.ClientRequestToken = aws.String(tc.clientRequestToken)
+	}
+
+	_, err = t.d.DynamoDB.TransactWriteItems(ctx, in, tc.clientOpts...)
+	if err != nil {
+		return transactionError(err)
+	}
+
+	for _, fn := range t.versioned {
+		err := fn()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetRequest is item read by TransactGet
+type GetRequest struct {
+	// TableName is the table of the item. DefaultTableName is used when empty
+	TableName string
+	Key       Key
+}
+
+// TransactGetResult is result of TransactGet
+type TransactGetResult struct {
+	// Items is in the same order as requests. The item is nil when it does not exist
+	Items []map[string]types.AttributeValue
+}
+
+// Unmarshal unmarshals the item of index i into out. ErrNotFound is returned when the item does not exist
+func (r *TransactGetResult) Unmarshal(i int, out any) error {
+	if i < 0 || i >= len(r.Items) {
+		return fmt.Errorf("index %d is out of range", i)
+	}
+	if r.Items[i] == nil {
+		return ErrNotFound
+	}
+	return attributevalue.UnmarshalMap(r.Items[i], out)
+}
+
+// TransactGet reads the items of requests as a consistent snapshot across tables
+func (d *DynamoDB) TransactGet(ctx context.Context, requests []*GetRequest, txOpts ...TransactOption) (*TransactGetResult, error) {
+	if len(requests) == 0 {
+		return nil, errors.New("no request")
+	}
+	if len(requests) > transactSize {
+		return nil, fmt.Errorf("%d requests. the limit is %d", len(requests), transactSize)
+	}
+
+	tc, err := createTransactConfig(txOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	gets := make([]types.TransactGetItem, 0, len(requests))
+	for i, r := range requests {
+		key, err := r.Key.attributeValues()
+		if err != nil {
+			return nil, fmt.Errorf("request %d: %w", i, err)
+		}
+
+		tableName := r.TableName
+		if tableName == "" {
+			tableName = d.DefaultTableName
+		}
+
+		gets = append(gets, types.TransactGetItem{Get: &types.Get{
+			Key:       key,
+			TableName: aws.String(tableName),
+		}})
+	}
+
+	res, err := d.DynamoDB.TransactGetItems(ctx, &dynamodb.TransactGetItemsInput{TransactItems: gets}, tc.clientOpts...)
+	if err != nil {
+		return nil, transactionError(err)
+	}
+
+	ret := &TransactGetResult{Items: make([]map[string]types.AttributeValue, len(requests))}
+	for i, r := range res.Responses {
+		if len(r.Item) != 0 {
+			ret.Items[i] = r.Item
+		}
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,209 @@
+package dynamodb_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	awsdynamodb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+
+	"github.com/rssh-jp/data-access-library/aws/dynamodb"
+)
+
+type wallet struct {
+	ID      string `dynamodbav:"id"`
+	Balance int    `dynamodbav:"balance"`
+}
+
+func TestTransaction(t *testing.T) {
+	newDynamoDB := func(t *testing.T) (*testClient, *dynamodb.DynamoDB) {
+		c := newTestClient(t)
+		d := c.newDynamoDB()
+		if err := d.CreateDefaultTable(); err != nil {
+			t.Fatal(err)
+		}
+		if err := d.CreateTable("wallets", "id"); err != nil {
+			t.Fatal(err)
+		}
+		return c, d
+	}
+
+	walletTable := dynamodb.WriteOptionTable("wallets")
+	transfer := func(d *dynamodb.DynamoDB, from, to string, amount int) *dynamodb.Transaction {
+		return d.Transaction().
+			Update(dynamodb.Key{"id": from}, dynamodb.NewUpdate().Decrement("balance", amount), walletTable,
+				dynamodb.WriteOptionCondition(expression.Name("balance").GreaterThanEqual(expression.Value(amount)))).
+			Update(dynamodb.Key{"id": to}, dynamodb.NewUpdate().Increment("balance", amount), walletTable)
+	}
+	balances := func(t *testing.T, d *dynamodb.DynamoDB, ids ...string) []int {
+		t.Helper()
+
+		requests := make([]*dynamodb.GetRequest, 0, len(ids))
+		for _, id := range ids {
+			requests = append(requests, &dynamodb.GetRequest{TableName: "wallets", Key: dynamodb.Key{"id": id}})
+		}
+
+		res, err := d.TransactGet(context.Background(), requests)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ret := make([]int, 0, len(ids))
+		for i := range ids {
+			var w wallet
+			err := res.Unmarshal(i, &w)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ret = append(ret, w.Balance)
+		}
+		return ret
+	}
+
+	t.Run("Commit", func(t *testing.T) {
+		_, d := newDynamoDB(t)
+
+		err := d.Transaction().
+			Put(wallet{ID: "a", Balance: 100}, walletTable).
+			Put(wallet{ID: "b", Balance: 0}, walletTable).
+			Put(batchItem{Key: "log", Value: "opened"}).
+			Commit(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = transfer(d, "a", "b", 30).Commit(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual := balances(t, d, "a", "b")
+		if actual[0] != 70 || actual[1] != 30 {
+			t.Errorf("Could not match balances.\nexpect: %v\nactual: %v", []int{70, 30}, actual)
+		}
+	})
+	t.Run("Canceled", func(t *testing.T) {
+		_, d := newDynamoDB(t)
+
+		err := d.Transaction().
+			Put(wallet{ID: "a", Balance: 10}, walletTable).
+			Put(wallet{ID: "b", Balance: 0}, walletTable).
+			Commit(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = transfer(d, "a", "b", 30).Commit(context.Background())
+		if !errors.Is(err, dynamodb.ErrTransactionCanceled) {
+			t.Fatalf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrTransactionCanceled, err)
+		}
+		if !errors.Is(err, dynamodb.ErrConditionFailed) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrConditionFailed, err)
+		}
+
+		var tce *dynamodb.TransactionCanceledError
+		if !errors.As(err, &tce) {
+			t.Fatalf("Could not match error type. actual: %T", err)
+		}
+		if len(tce.Reasons) != 1 || tce.Reasons[0].Index != 0 || tce.Reasons[0].Code != "ConditionalCheckFailed" {
+			t.Errorf("Could not match reasons. actual: %+v", tce.Reasons)
+		}
+		if tce.Reasons[0].Item == nil {
+			t.Error("Bug. Stored item of failed condition is not returned")
+		}
+
+		actual := balances(t, d, "a", "b")
+		if actual[0] != 10 || actual[1] != 0 {
+			t.Errorf("Could not match balances.\nexpect: %v\nactual: %v", []int{10, 0}, actual)
+		}
+	})
+	t.Run("Condition check", func(t *testing.T) {
+		_, d := newDynamoDB(t)
+
+		err := d.Transaction().
+			ConditionCheck(d.Key("lock"), expression.AttributeExists(expression.Name("key"))).
+			Put(wallet{ID: "a", Balance: 10}, walletTable).
+			Commit(context.Background())
+		if !errors.Is(err, dynamodb.ErrConditionFailed) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrConditionFailed, err)
+		}
+
+		res, err := d.TransactGet(context.Background(), []*dynamodb.GetRequest{{TableName: "wallets", Key: dynamodb.Key{"id": "a"}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = res.Unmarshal(0, &wallet{})
+		if !errors.Is(err, dynamodb.ErrNotFound) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrNotFound, err)
+		}
+	})
+	t.Run("Client request token", func(t *testing.T) {
+		c, d := newDynamoDB(t)
+
+		err := d.Transaction().Put(wallet{ID: "a", Balance: 10}, walletTable).Commit(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// retry of the same transaction is not applied twice
+		for range 2 {
+			err := transfer(d, "a", "b", 10).Commit(context.Background(), dynamodb.TransactOptionClientRequestToken("transfer-1"))
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if actual := aws.ToString(lastRequest[awsdynamodb.TransactWriteItemsInput](t, c).ClientRequestToken); actual != "transfer-1" {
+			t.Errorf("Could not match token.\nexpect: %v\nactual: %v", "transfer-1", actual)
+		}
+
+		actual := balances(t, d, "a", "b")
+		if actual[0] != 0 || actual[1] != 10 {
+			t.Errorf("Could not match balances.\nexpect: %v\nactual: %v", []int{0, 10}, actual)
+		}
+	})
+	t.Run("Version", func(t *testing.T) {
+		_, d := newDynamoDB(t)
+
+		item := &versionedItem{Key: "k", Value: "v"}
+		err := d.Transaction().Put(item, dynamodb.WriteOptionVersion("version")).Commit(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if item.Version != 1 {
+			t.Errorf("Could not match version.\nexpect: %v\nactual: %v", 1, item.Version)
+		}
+
+		stale := &versionedItem{Key: "k", Value: "stale"}
+		err = d.Transaction().Put(stale, dynamodb.WriteOptionVersion("version")).Commit(context.Background())
+		if !errors.Is(err, dynamodb.ErrConditionFailed) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrConditionFailed, err)
+		}
+		if stale.Version != 0 {
+			t.Errorf("Bug. Version of failed transaction is updated: %v", stale.Version)
+		}
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		_, d := newDynamoDB(t)
+
+		err := d.Transaction().Commit(context.Background())
+		if err == nil {
+			t.Error("Bug. Empty transaction must fail")
+		}
+
+		tx := d.Transaction()
+		for i := range 101 {
+			tx.Put(wallet{ID: string(rune('a' + i%26)), Balance: i}, walletTable)
+		}
+		err = tx.Commit(context.Background())
+		if err == nil {
+			t.Error("Bug. Transaction over the limit must fail")
+		}
+
+		err = d.Transaction().Update(d.Key("k"), dynamodb.NewUpdate()).Put(wallet{ID: "a"}, walletTable).Commit(context.Background())
+		if err == nil {
+			t.Error("Bug. Error of operation must be returned")
+		}
+	})
+}
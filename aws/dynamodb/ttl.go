@@ -0,0 +1,100 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// EnableTTL enables TTL of the table on attributeName. It does nothing when TTL is already enabled on attributeName
+func (d *DynamoDB) EnableTTL(tableName, attributeName string) error {
+	return d.EnableTTLWithContext(context.Background(), tableName, attributeName)
+}
+
+// EnableTTLWithContext is EnableTTL with context and request options
+func (d *DynamoDB) EnableTTLWithContext(ctx context.Context, tableName, attributeName string, optFns ...func(*dynamodb.Options)) error {
+	if attributeName == "" {
+		return errors.New("ttl attribute name is empty")
+	}
+
+	res, err := d.DynamoDB.DescribeTimeToLive(ctx, &dynamodb.DescribeTimeToLiveInput{TableName: aws.String(tableName)}, optFns...)
+	if err != nil {
+		return err
+	}
+
+	if desc := res.TimeToLiveDescription; desc != nil {
+		switch desc.TimeToLiveStatus {
+		case types.TimeToLiveStatusEnabled, types.TimeToLiveStatusEnabling:
+			if aws.ToString(desc.AttributeName) == attributeName {
+				return nil
+			}
+			return fmt.Errorf("ttl of table %s is already enabled on %s", tableName, aws.ToString(desc.AttributeName))
+		}
+	}
+
+	_, err = d.DynamoDB.UpdateTimeToLive(ctx, &dynamodb.UpdateTimeToLiveInput{
+		TableName: aws.String(tableName),
+		TimeToLiveSpecification: &types.TimeToLiveSpecification{
+			AttributeName: aws.String(attributeName),
+			Enabled:       aws.Bool(true),
+		},
+	}, optFns...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SetWithTTL sets the value which expires after ttl. Get returns ErrNotFound for expired value before DynamoDB deletes it
+func (d *DynamoDB) SetWithTTL(key, value string, ttl time.Duration) error {
+	return d.SetWithTTLWithContext(context.Background(), key, value, ttl)
+}
+
+// SetWithTTLWithContext is SetWithTTL with context and request options
+func (d *DynamoDB) SetWithTTLWithContext(ctx context.Context, key, value string, ttl time.Duration, optFns ...func(*dynamodb.Options)) error {
+	return d.SetByKeyWithTTLWithContext(ctx, Key{d.DefaultKeyName: key}, value, ttl, optFns...)
+}
+
+// SetByKeyWithTTL sets the value of item with composite key which expires after ttl
+func (d *DynamoDB) SetByKeyWithTTL(key Key, value string, ttl time.Duration) error {
+	return d.SetByKeyWithTTLWithContext(context.Background(), key, value, ttl)
+}
+
+// SetByKeyWithTTLWithContext is SetByKeyWithTTL with context and request options
+func (d *DynamoDB) SetByKeyWithTTLWithContext(ctx context.Context, key Key, value string, ttl time.Duration, optFns ...func(*dynamodb.Options)) error {
+	if d.DefaultTTLName == "" {
+		return errors.New("DefaultTTLName is empty")
+	}
+	if ttl <= 0 {
+		return errors.New("ttl must be positive")
+	}
+
+	expiresAt := time.Now().Add(ttl)
+	return d.setByKey(ctx, key, value, &expiresAt, optFns...)
+}
+
+// expired reports whether expiration time of DefaultTTLName of item has passed. Item without valid expiration time never expires
+func (d *DynamoDB) expired(item map[string]types.AttributeValue) bool {
+	if d.DefaultTTLName == "" {
+		return false
+	}
+
+	av, ok := item[d.DefaultTTLName].(*types.AttributeValueMemberN)
+	if !ok {
+		return false
+	}
+
+	expiresAt, err := strconv.ParseInt(av.Value, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	return time.Now().Unix() >= expiresAt
+}
@@ -0,0 +1,154 @@
+package dynamodb_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/rssh-jp/data-access-library/aws/dynamodb"
+)
+
+func TestTTL(t *testing.T) {
+	newDynamoDB := func(t *testing.T) (*testClient, *dynamodb.DynamoDB) {
+		c := newTestClient(t)
+		d := c.newDynamoDB()
+		d.DefaultTTLName = "expires_at"
+		if err := d.CreateDefaultTable(); err != nil {
+			t.Fatal(err)
+		}
+		return c, d
+	}
+
+	t.Run("Enabled on creation", func(t *testing.T) {
+		c, d := newDynamoDB(t)
+
+		if actual := c.ttlAttribute(t, d.DefaultTableName); actual != d.DefaultTTLName {
+			t.Errorf("Could not match ttl attribute.\nexpect: %s\nactual: %s", d.DefaultTTLName, actual)
+		}
+
+		err := d.EnableTTL(d.DefaultTableName, d.DefaultTTLName)
+		if err != nil {
+			t.Errorf("Bug. TTL is already enabled on the attribute. But error: %v", err)
+		}
+
+		err = d.EnableTTL(d.DefaultTableName, "other")
+		if err == nil {
+			t.Error("Bug. TTL is enabled on another attribute. But no error")
+		}
+	})
+	t.Run("EnsureTable", func(t *testing.T) {
+		c := newTestClient(t)
+		d := c.newDynamoDB()
+
+		def := &dynamodb.TableDefinition{Name: "sessions", PartitionKey: dynamodb.KeyAttribute{Name: "id"}, TTLAttribute: "ttl"}
+		for i := 0; i < 2; i++ {
+			err := d.EnsureTable(def, 10*time.Second)
+			if err != nil {
+				t.Fatalf("Could not ensure table on call %d: %v", i+1, err)
+			}
+		}
+
+		if actual := c.ttlAttribute(t, "sessions"); actual != "ttl" {
+			t.Errorf("Could not match ttl attribute.\nexpect: %s\nactual: %s", "ttl", actual)
+		}
+	})
+	t.Run("SetWithTTL", func(t *testing.T) {
+		_, d := newDynamoDB(t)
+
+		err := d.SetWithTTL("k", "v", time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual, err := d.Get("k")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != "v" {
+			t.Errorf("Could not match value.\nexpect: %s\nactual: %s", "v", actual)
+		}
+
+		item, err := dynamodb.GetItem[map[string]any](context.Background(), d, d.Key("k"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expiresAt, _ := (*item)[d.DefaultTTLName].(float64)
+		if expect := time.Now().Add(time.Hour).Unix(); int64(expiresAt) < expect-5 || int64(expiresAt) > expect {
+			t.Errorf("Could not match expiration time.\nexpect: %v\nactual: %v", expect, expiresAt)
+		}
+
+		err = d.SetWithTTL("k", "v", 0)
+		if err == nil {
+			t.Error("Bug. TTL must be positive. But no error")
+		}
+	})
+	t.Run("Expired", func(t *testing.T) {
+		c := newTestClient(t)
+		d := c.newDynamoDB()
+		if err := d.CreateDefaultTable(); err != nil {
+			t.Fatal(err)
+		}
+		// the item is not deleted yet by DynamoDB, as TTL of the table is not enabled
+		d.DefaultTTLName = "expires_at"
+
+		err := dynamodb.PutItem(context.Background(), d, map[string]any{
+			d.DefaultKeyName:   "expired",
+			d.DefaultValueName: "v",
+			d.DefaultTTLName:   time.Now().Add(-time.Second).Unix(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = d.Get("expired")
+		if !errors.Is(err, dynamodb.ErrNotFound) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrNotFound, err)
+		}
+		_, err = dynamodb.GetItem[map[string]any](context.Background(), d, d.Key("expired"))
+		if !errors.Is(err, dynamodb.ErrNotFound) {
+			t.Errorf("Could not match error of GetItem.\nexpect: %v\nactual: %v", dynamodb.ErrNotFound, err)
+		}
+
+		// Set without ttl never expires
+		err = d.Set("expired", "v")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = d.Get("expired")
+		if err != nil {
+			t.Error(err)
+		}
+	})
+	t.Run("Disabled by default", func(t *testing.T) {
+		c := newTestClient(t)
+		d := c.newDynamoDB()
+
+		err := d.CreateDefaultTable()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := c.ttlAttribute(t, d.DefaultTableName); actual != "" {
+			t.Errorf("Bug. TTL is disabled. But enabled on %s", actual)
+		}
+
+		err = dynamodb.PutItem(context.Background(), d, map[string]any{
+			d.DefaultKeyName:   "k",
+			d.DefaultValueName: "v",
+			"expires_at":       strconv.FormatInt(time.Now().Add(-time.Second).Unix(), 10),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = d.Get("k")
+		if err != nil {
+			t.Error(err)
+		}
+
+		err = d.SetWithTTL("k", "v", time.Hour)
+		if err == nil {
+			t.Error("Bug. TTL is disabled. But no error")
+		}
+	})
+}
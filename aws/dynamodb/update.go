@@ -0,0 +1,206 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// StringSet is []string stored as string set
+type StringSet []string
+
+// MarshalDynamoDBAttributeValue implements attributevalue.Marshaler
+func (s StringSet) MarshalDynamoDBAttributeValue() (types.AttributeValue, error) {
+	return &types.AttributeValueMemberSS{Value: s}, nil
+}
+
+// NumberSet is []float64 stored as number set
+type NumberSet []float64
+
+// MarshalDynamoDBAttributeValue implements attributevalue.Marshaler
+func (s NumberSet) MarshalDynamoDBAttributeValue() (types.AttributeValue, error) {
+	values := make([]string, 0, len(s))
+	for _, n := range s {
+		values = append(values, strconv.FormatFloat(n, 'f', -1, 64))
+	}
+	return &types.AttributeValueMemberNS{Value: values}, nil
+}
+
+// Update is builder of update actions. name is document path such as "a.b[0]".
+// value is marshaled by attributevalue
+type Update struct {
+	builder expression.UpdateBuilder
+	actions int
+}
+
+// NewUpdate returns empty Update
+func NewUpdate() *Update {
+	return &Update{}
+}
+
+// Set sets value to name
+func (u *Update) Set(name string, value any) *Update {
+	u.builder = u.builder.Set(expression.Name(name), expression.Value(value))
+	u.actions++
+	return u
+}
+
+// SetIfNotExists sets value to name only when name does not exist
+func (u *Update) SetIfNotExists(name string, value any) *Update {
+	u.builder = u.builder.Set(expression.Name(name), expression.IfNotExists(expression.Name(name), expression.Value(value)))
+	u.actions++
+	return u
+}
+
+// Increment adds n to number attribute of name atomically. Missing attribute is treated as 0
+func (u *Update) Increment(name string, n any) *Update {
+	u.builder = u.builder.Set(expression.Name(name), expression.Plus(expression.IfNotExists(expression.Name(name), expression.Value(0)), expression.Value(n)))
+	u.actions++
+	return u
+}
+
+// Decrement subtracts n from number attribute of name atomically. Missing attribute is treated as 0
+func (u *Update) Decrement(name string, n any) *Update {
+	u.builder = u.builder.Set(expression.Name(name), expression.Minus(expression.IfNotExists(expression.Name(name), expression.Value(0)), expression.Value(n)))
+	u.actions++
+	return u
+}
+
+// Append appends values to the end of list attribute of name. values must be slice. Missing attribute is treated as empty list
+func (u *Update) Append(name string, values any) *Update {
+	u.builder = u.builder.Set(expression.Name(name), expression.ListAppend(expression.IfNotExists(expression.Name(name), expression.Value([]any{})), expression.Value(values)))
+	u.actions++
+	return u
+}
+
+// Prepend prepends values to the beginning of list attribute of name. values must be slice. Missing attribute is treated as empty list
+func (u *Update) Prepend(name string, values any) *Update {
+	u.builder = u.builder.Set(expression.Name(name), expression.ListAppend(expression.Value(values), expression.IfNotExists(expression.Name(name), expression.Value([]any{}))))
+	u.actions++
+	return u
+}
+
+// Remove removes attribute of name
+func (u *Update) Remove(name string) *Update {
+	u.builder = u.builder.Remove(expression.Name(name))
+	u.actions++
+	return u
+}
+
+// Add adds number to number attribute or adds elements to set attribute by ADD action. Use StringSet or NumberSet for set
+func (u *Update) Add(name string, value any) *Update {
+	u.builder = u.builder.Add(expression.Name(name), expression.Value(value))
+	u.actions++
+	return u
+}
+
+// Delete deletes elements from set attribute by DELETE action. value must be StringSet, NumberSet or set attribute value
+func (u *Update) Delete(name string, value any) *Update {
+	u.builder = u.builder.Delete(expression.Name(name), expression.Value(value))
+	u.actions++
+	return u
+}
+
+// Update updates the item with key by update actions.
+// With WriteOptionVersion key must have the version attribute. It is checked, incremented and is not sent as key
+func (d *DynamoDB) Update(ctx context.Context, key Key, u *Update, writeOpts ...WriteOption) error {
+	wc, err := createWriteConfig(writeOpts...)
+	if err != nil {
+		return err
+	}
+
+	in, err := d.updateInput(wc, key, u)
+	if err != nil {
+		return err
+	}
+
+	_, err = d.DynamoDB.UpdateItem(ctx, in, wc.clientOpts...)
+	if err != nil {
+		return conditionError(err)
+	}
+
+	return nil
+}
+
+// UpdateItem is Update returning the item unmarshaled into T.
+// The item after the update is returned by default. Use WriteOptionReturnValues to return the item before the update or only updated attributes
+func UpdateItem[T any](ctx context.Context, d *DynamoDB, key Key, u *Update, writeOpts ...WriteOption) (*T, error) {
+	wc, err := createWriteConfig(writeOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	in, err := d.updateInput(wc, key, u)
+	if err != nil {
+		return nil, err
+	}
+	in.ReturnValues = types.ReturnValueAllNew
+	if wc.returnValues != "" {
+		in.ReturnValues = wc.returnValues
+	}
+
+	res, err := d.DynamoDB.UpdateItem(ctx, in, wc.clientOpts...)
+	if err != nil {
+		return nil, conditionError(err)
+	}
+
+	ret := new(T)
+	err = attributevalue.UnmarshalMap(res.Attributes, ret)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (d *DynamoDB) updateInput(wc *writeConfig, key Key, u *Update) (*dynamodb.UpdateItemInput, error) {
+	if u == nil || u.actions == 0 {
+		return nil, errors.New("update has no action")
+	}
+
+	av, err := key.attributeValues()
+	if err != nil {
+		return nil, err
+	}
+
+	cond, _, err := wc.conditionBuilder(av)
+	if err != nil {
+		return nil, err
+	}
+
+	update := u.builder
+	if wc.versionName != "" {
+		delete(av, wc.versionName)
+		update = update.Add(expression.Name(wc.versionName), expression.Value(1))
+	}
+
+	builder := expression.NewBuilder().WithUpdate(update)
+	if cond != nil {
+		builder = builder.WithCondition(*cond)
+	}
+
+	expr, err := builder.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	in := &dynamodb.UpdateItemInput{
+		Key:                       av,
+		TableName:                 aws.String(wc.table(d)),
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}
+	if cond != nil {
+		in.ReturnValuesOnConditionCheckFailure = types.ReturnValuesOnConditionCheckFailureAllOld
+	}
+
+	return in, nil
+}
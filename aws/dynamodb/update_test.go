@@ -0,0 +1,176 @@
+package dynamodb_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/rssh-jp/data-access-library/aws/dynamodb"
+)
+
+type counterItem struct {
+	Key     string   `dynamodbav:"key"`
+	Name    string   `dynamodbav:"name,omitempty"`
+	Count   int      `dynamodbav:"count"`
+	History []string `dynamodbav:"history,omitempty"`
+	Tags    []string `dynamodbav:"tags,omitempty,stringset"`
+	Version int64    `dynamodbav:"version,omitempty"`
+}
+
+func TestUpdate(t *testing.T) {
+	newDynamoDB := func(t *testing.T) *dynamodb.DynamoDB {
+		c := newTestClient(t)
+		d := c.newDynamoDB()
+		if err := d.CreateDefaultTable(); err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	t.Run("Set and remove", func(t *testing.T) {
+		d := newDynamoDB(t)
+
+		err := d.Update(context.Background(), d.Key("k"), dynamodb.NewUpdate().Set("name", "first").Set("count", 3))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual, err := updateItemOf(d, dynamodb.NewUpdate().Remove("name"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expect := &counterItem{Key: "k", Count: 3}
+		if !reflect.DeepEqual(expect, actual) {
+			t.Errorf("Could not match item.\nexpect: %+v\nactual: %+v", expect, actual)
+		}
+	})
+	t.Run("Atomic counter", func(t *testing.T) {
+		d := newDynamoDB(t)
+
+		for range 3 {
+			err := d.Update(context.Background(), d.Key("k"), dynamodb.NewUpdate().Increment("count", 2))
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		actual, err := updateItemOf(d, dynamodb.NewUpdate().Decrement("count", 1))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if actual.Count != 5 {
+			t.Errorf("Could not match count.\nexpect: %v\nactual: %v", 5, actual.Count)
+		}
+	})
+	t.Run("Append and prepend", func(t *testing.T) {
+		d := newDynamoDB(t)
+
+		err := d.Update(context.Background(), d.Key("k"), dynamodb.NewUpdate().Append("history", []string{"b", "c"}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual, err := updateItemOf(d, dynamodb.NewUpdate().Prepend("history", []string{"a"}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expect := []string{"a", "b", "c"}
+		if !reflect.DeepEqual(expect, actual.History) {
+			t.Errorf("Could not match history.\nexpect: %v\nactual: %v", expect, actual.History)
+		}
+	})
+	t.Run("Add and delete set", func(t *testing.T) {
+		d := newDynamoDB(t)
+
+		err := d.Update(context.Background(), d.Key("k"), dynamodb.NewUpdate().Add("tags", dynamodb.StringSet{"a", "b"}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = d.Update(context.Background(), d.Key("k"), dynamodb.NewUpdate().Add("tags", dynamodb.StringSet{"b", "c"}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual, err := updateItemOf(d, dynamodb.NewUpdate().Delete("tags", dynamodb.StringSet{"a"}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expect := []string{"b", "c"}
+		if !reflect.DeepEqual(expect, actual.Tags) {
+			t.Errorf("Could not match tags.\nexpect: %v\nactual: %v", expect, actual.Tags)
+		}
+	})
+	t.Run("Return values", func(t *testing.T) {
+		d := newDynamoDB(t)
+
+		err := d.Update(context.Background(), d.Key("k"), dynamodb.NewUpdate().Set("name", "old"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual, err := dynamodb.UpdateItem[counterItem](context.Background(), d, d.Key("k"), dynamodb.NewUpdate().Set("name", "new"), dynamodb.WriteOptionReturnValues(types.ReturnValueAllOld))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if actual.Name != "old" {
+			t.Errorf("Could not match name.\nexpect: %v\nactual: %v", "old", actual.Name)
+		}
+	})
+	t.Run("Condition", func(t *testing.T) {
+		d := newDynamoDB(t)
+
+		err := d.Update(context.Background(), d.Key("k"), dynamodb.NewUpdate().Set("name", "v"), dynamodb.WriteOptionCondition(expression.AttributeExists(expression.Name("key"))))
+		if !errors.Is(err, dynamodb.ErrConditionFailed) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrConditionFailed, err)
+		}
+	})
+	t.Run("Version", func(t *testing.T) {
+		d := newDynamoDB(t)
+		version := dynamodb.WriteOptionVersion("version")
+
+		key := d.Key("k")
+		key["version"] = 0
+		actual, err := dynamodb.UpdateItem[counterItem](context.Background(), d, key, dynamodb.NewUpdate().Set("name", "first"), version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual.Version != 1 {
+			t.Errorf("Could not match version.\nexpect: %v\nactual: %v", 1, actual.Version)
+		}
+
+		// stale version
+		err = d.Update(context.Background(), key, dynamodb.NewUpdate().Set("name", "second"), version)
+		if !errors.Is(err, dynamodb.ErrConditionFailed) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", dynamodb.ErrConditionFailed, err)
+		}
+
+		key["version"] = actual.Version
+		err = d.Update(context.Background(), key, dynamodb.NewUpdate().Set("name", "second"), version)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("Empty update", func(t *testing.T) {
+		d := newDynamoDB(t)
+
+		err := d.Update(context.Background(), d.Key("k"), dynamodb.NewUpdate())
+		if err == nil {
+			t.Error("Bug. Update without action must fail")
+		}
+	})
+}
+
+// updateItemOf applies u to the item of key "k" and returns the item after the update
+func updateItemOf(d *dynamodb.DynamoDB, u *dynamodb.Update) (*counterItem, error) {
+	return dynamodb.UpdateItem[counterItem](context.Background(), d, d.Key("k"), u)
+}
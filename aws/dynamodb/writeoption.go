@@ -0,0 +1,105 @@
+package dynamodb
+
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type writeConfig struct {
+	tableName    string
+	condition    *expression.ConditionBuilder
+	versionName  string
+	returnValues types.ReturnValue
+	clientOpts   []func(*dynamodb.Options)
+}
+
+func newWriteConfig() *writeConfig {
+	return &writeConfig{
+		tableName:    "",
+		condition:    nil,
+		versionName:  "",
+		returnValues: "",
+		clientOpts:   nil,
+	}
+}
+
+func createWriteConfig(writeOpts ...WriteOption) (*writeConfig, error) {
+	wc := newWriteConfig()
+
+	for _, opt := range writeOpts {
+		err := opt(wc)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return wc, nil
+}
+
+// WriteOption is functional option pattern option for put, update and delete
+type WriteOption func(*writeConfig) error
+
+// WriteOptionTable returns WriteOption instance writing table instead of DefaultTableName
+func WriteOptionTable(name string) func(c *writeConfig) error {
+	return func(c *writeConfig) error {
+		if name == "" {
+			return errors.New("table name is empty")
+		}
+		c.tableName = name
+		return nil
+	}
+}
+
+// WriteOptionCondition returns WriteOption instance writing only when cond is satisfied.
+// The error wrapping ErrConditionFailed is returned otherwise. Multiple conditions are combined by AND
+func WriteOptionCondition(cond expression.ConditionBuilder) func(c *writeConfig) error {
+	return func(c *writeConfig) error {
+		// cond is not overwritten so that the option can be reused
+		next := cond
+		if c.condition != nil {
+			next = c.condition.And(cond)
+		}
+		c.condition = &next
+		return nil
+	}
+}
+
+// WriteOptionVersion returns WriteOption instance with optimistic locking by number attribute of name.
+// The write succeeds only when the stored version equals the version of the item, and the version is incremented.
+// Version 0 or missing attribute means the item must not exist yet
+func WriteOptionVersion(name string) func(c *writeConfig) error {
+	return func(c *writeConfig) error {
+		if name == "" {
+			return errors.New("version attribute name is empty")
+		}
+		c.versionName = name
+		return nil
+	}
+}
+
+// WriteOptionReturnValues returns WriteOption instance selecting the attributes returned by UpdateItem.
+// e.g. types.ReturnValueAllOld returns the item before the update
+func WriteOptionReturnValues(rv types.ReturnValue) func(c *writeConfig) error {
+	return func(c *writeConfig) error {
+		c.returnValues = rv
+		return nil
+	}
+}
+
+// WriteOptionClientOptions returns WriteOption instance with request options
+func WriteOptionClientOptions(optFns ...func(*dynamodb.Options)) func(c *writeConfig) error {
+	return func(c *writeConfig) error {
+		c.clientOpts = append(c.clientOpts, optFns...)
+		return nil
+	}
+}
+
+func (wc *writeConfig) table(d *DynamoDB) string {
+	if wc.tableName != "" {
+		return wc.tableName
+	}
+	return d.DefaultTableName
+}
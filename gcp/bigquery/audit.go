@@ -0,0 +1,319 @@
+package bigquery
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+)
+
+const defaultAuditBufferSize = 1024
+
+// AuditRecord is structured audit log record of single query
+type AuditRecord struct {
+	Time       time.Time        `json:"time"`
+	Operation  string           `json:"operation"`
+	Principal  string           `json:"principal,omitempty"`
+	ProjectID  string           `json:"project_id"`
+	SQLHash    string           `json:"sql_hash"`
+	SQL        string           `json:"sql"`
+	Parameters []AuditParameter `json:"parameters,omitempty"`
+	DryRun     bool             `json:"dry_run"`
+	JobID      string           `json:"job_id,omitempty"`
+	Location   string           `json:"location,omitempty"`
+	Duration   time.Duration    `json:"duration"`
+
+	StatementType       string `json:"statement_type,omitempty"`
+	TotalBytesProcessed int64  `json:"total_bytes_processed"`
+	TotalBytesBilled    int64  `json:"total_bytes_billed"`
+	CacheHit            bool   `json:"cache_hit"`
+	SlotMillis          int64  `json:"slot_ms"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// AuditParameter is query parameter recorded on AuditRecord.
+// Value is recorded only when OptionAuditParameterValues is specified
+type AuditParameter struct {
+	Name  string `json:"name,omitempty"`
+	Type  string `json:"type"`
+	Value string `json:"value,omitempty"`
+}
+
+// AuditSink receives audit records.
+// WriteAudit is called from a single background goroutine
+type AuditSink interface {
+	WriteAudit(ctx context.Context, record *AuditRecord) error
+}
+
+// AuditSinkFunc is adapter to use ordinary function as AuditSink
+type AuditSinkFunc func(ctx context.Context, record *AuditRecord) error
+
+// WriteAudit calls f(ctx, record)
+func (f AuditSinkFunc) WriteAudit(ctx context.Context, record *AuditRecord) error {
+	return f(ctx, record)
+}
+
+type auditConfig struct {
+	sinks        []AuditSink
+	principal    func(ctx context.Context) string
+	redact       func(sql string) string
+	paramValues  bool
+	bufferSize   int
+	errorHandler func(err error)
+}
+
+func newAuditConfig() auditConfig {
+	return auditConfig{
+		sinks:        nil,
+		principal:    nil,
+		redact:       RedactSQL,
+		paramValues:  false,
+		bufferSize:   defaultAuditBufferSize,
+		errorHandler: func(err error) { log.Println("bigquery: audit:", err) },
+	}
+}
+
+// auditor delivers audit records to sinks without blocking the query path.
+// Records are dropped when the buffer is full
+type auditor struct {
+	projectID string
+	cfg       auditConfig
+
+	mu      sync.RWMutex
+	closed  bool
+	records chan *AuditRecord
+	done    chan struct{}
+	dropped uint64
+}
+
+func newAuditor(projectID string, cfg auditConfig) *auditor {
+	if len(cfg.sinks) == 0 {
+		return nil
+	}
+
+	a := &auditor{
+		projectID: projectID,
+		cfg:       cfg,
+		records:   make(chan *AuditRecord, cfg.bufferSize),
+		done:      make(chan struct{}),
+	}
+
+	go a.run()
+
+	return a
+}
+
+func (a *auditor) run() {
+	defer close(a.done)
+
+	for record := range a.records {
+		for _, sink := range a.cfg.sinks {
+			err := sink.WriteAudit(context.Background(), record)
+			if err != nil {
+				a.cfg.errorHandler(err)
+			}
+		}
+	}
+}
+
+func (a *auditor) emit(ctx context.Context, o *observation, err error) {
+	record := a.newRecord(ctx, o, err)
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		atomic.AddUint64(&a.dropped, 1)
+		return
+	}
+
+	select {
+	case a.records <- record:
+	default:
+		atomic.AddUint64(&a.dropped, 1)
+	}
+}
+
+// close waits until buffered records are delivered
+func (a *auditor) close() {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.records)
+	}
+	a.mu.Unlock()
+
+	<-a.done
+
+	if dropped := atomic.LoadUint64(&a.dropped); dropped > 0 {
+		a.cfg.errorHandler(fmt.Errorf("%d records dropped", dropped))
+	}
+}
+
+func (a *auditor) newRecord(ctx context.Context, o *observation, err error) *AuditRecord {
+	sum := sha256.Sum256([]byte(o.query))
+
+	record := &AuditRecord{
+		Time:      o.startTime,
+		Operation: o.operation,
+		ProjectID: a.projectID,
+		SQLHash:   hex.EncodeToString(sum[:]),
+		SQL:       a.cfg.redact(o.query),
+		DryRun:    o.dryRun,
+		Duration:  time.Since(o.startTime),
+	}
+
+	if a.cfg.principal != nil {
+		record.Principal = a.cfg.principal(ctx)
+	}
+
+	for _, p := range o.parameters {
+		param := AuditParameter{
+			Name: p.Name,
+			Type: fmt.Sprintf("%T", p.Value),
+		}
+		// values may be personal data not covered by the redactor
+		if a.cfg.paramValues {
+			param.Value = fmt.Sprintf("%v", p.Value)
+		}
+		record.Parameters = append(record.Parameters, param)
+	}
+
+	if o.job != nil {
+		record.JobID = o.job.ID()
+		record.Location = o.job.Location()
+		if o.job.ProjectID() != "" {
+			record.ProjectID = o.job.ProjectID()
+		}
+		if record.Principal == "" {
+			record.Principal = o.job.Email()
+		}
+	}
+
+	if o.statistics != nil {
+		record.TotalBytesProcessed = o.statistics.TotalBytesProcessed
+
+		if qs, ok := o.statistics.Details.(*bigquery.QueryStatistics); ok {
+			record.StatementType = qs.StatementType
+			record.TotalBytesProcessed = qs.TotalBytesProcessed
+			record.TotalBytesBilled = qs.TotalBytesBilled
+			record.CacheHit = qs.CacheHit
+			record.SlotMillis = qs.SlotMillis
+		}
+	}
+
+	if err != nil {
+		record.Error = err.Error()
+	}
+
+	return record
+}
+
+// RedactSQL replaces string, bytes and numeric literals of sql with "?".
+// Quoted identifiers and comments including block comments are kept as is
+func RedactSQL(sql string) string {
+	var b strings.Builder
+	b.Grow(len(sql))
+
+	for i := 0; i < len(sql); {
+		c := sql[i]
+
+		switch {
+		case c == '`':
+			end := skipQuoted(sql, i, c)
+			b.WriteString(sql[i:end])
+			i = end
+		case c == '\'' || c == '"':
+			b.WriteByte('?')
+			i = skipQuoted(sql, i, c)
+		case c == '-' && strings.HasPrefix(sql[i:], "--"), c == '#':
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				end = len(sql) - i
+			}
+			b.WriteString(sql[i : i+end])
+			i += end
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				end = len(sql)
+			} else {
+				end = i + 2 + end + 2
+			}
+			b.WriteString(sql[i:end])
+			i = end
+		case isDigit(c):
+			end := i
+			for end < len(sql) && (isIdentChar(sql[end]) || sql[end] == '.') {
+				end++
+			}
+			b.WriteByte('?')
+			i = end
+		case isIdentChar(c):
+			end := i
+			for end < len(sql) && isIdentChar(sql[end]) {
+				end++
+			}
+			// bytes and raw literals such as b'..' and r"..."
+			if end < len(sql) && (sql[end] == '\'' || sql[end] == '"') && isLiteralPrefix(sql[i:end]) {
+				b.WriteByte('?')
+				i = skipQuoted(sql, end, sql[end])
+				continue
+			}
+			b.WriteString(sql[i:end])
+			i = end
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+
+	return b.String()
+}
+
+// skipQuoted returns the index just after the quoted section beginning at start
+func skipQuoted(sql string, start int, quote byte) int {
+	// triple quoted string
+	if quote != '`' && strings.HasPrefix(sql[start:], strings.Repeat(string(quote), 3)) {
+		end := strings.Index(sql[start+3:], strings.Repeat(string(quote), 3))
+		if end < 0 {
+			return len(sql)
+		}
+		return start + 3 + end + 3
+	}
+
+	for i := start + 1; i < len(sql); i++ {
+		switch sql[i] {
+		case '\\':
+			i++
+		case quote:
+			return i + 1
+		}
+	}
+
+	return len(sql)
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || isDigit(c) || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isLiteralPrefix(s string) bool {
+	switch strings.ToLower(s) {
+	case "b", "r", "br", "rb":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,229 @@
+package bigquery_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	bq "cloud.google.com/go/bigquery"
+	awsdynamodb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+
+	"github.com/rssh-jp/data-access-library/aws/dynamodb"
+	"github.com/rssh-jp/data-access-library/gcp/bigquery"
+)
+
+// DynamoDB is the store of NewDynamoDBAuditSink
+var _ bigquery.AuditStore = (*dynamodb.DynamoDB)(nil)
+
+type mapStore struct {
+	mu    sync.Mutex
+	items map[string]string
+}
+
+func (s *mapStore) SetWithContext(ctx context.Context, key, value string, optFns ...func(*awsdynamodb.Options)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.items[key] = value
+	return nil
+}
+
+func TestAudit(t *testing.T) {
+	const query = "select id, name from `test_dataset.test_table` where name = @name and id > 10"
+
+	t.Run("Sinks", func(t *testing.T) {
+		srv := newFakeServer(t)
+
+		var jsonBuf, slogBuf bytes.Buffer
+		store := &mapStore{items: make(map[string]string)}
+
+		b := srv.newBigQuery(t,
+			bigquery.OptionAuditSinks(
+				bigquery.NewJSONAuditSink(&jsonBuf),
+				bigquery.NewSlogAuditSink(slog.New(slog.NewJSONHandler(&slogBuf, nil))),
+				bigquery.NewDynamoDBAuditSink(store),
+			),
+			bigquery.OptionAuditPrincipal(func(ctx context.Context) string { return "alice@example.com" }),
+		)
+
+		_, _, err := b.Query(context.Background(), query, bigquery.QueryOptionParameters(bq.QueryParameter{Name: "name", Value: "aa"}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = b.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var record bigquery.AuditRecord
+		if err := json.Unmarshal(jsonBuf.Bytes(), &record); err != nil {
+			t.Fatal(err)
+		}
+
+		expectSQL := "select id, name from `test_dataset.test_table` where name = @name and id > ?"
+		if record.SQL != expectSQL {
+			t.Errorf("Could not match sql.\nexpect: %s\nactual: %s", expectSQL, record.SQL)
+		}
+		if record.Principal != "alice@example.com" {
+			t.Errorf("Could not match principal.\nexpect: %s\nactual: %s", "alice@example.com", record.Principal)
+		}
+		if record.JobID != "job_1" {
+			t.Errorf("Could not match job id.\nexpect: %s\nactual: %s", "job_1", record.JobID)
+		}
+		if record.TotalBytesBilled != 10485760 {
+			t.Errorf("Could not match bytes billed.\nexpect: %d\nactual: %d", 10485760, record.TotalBytesBilled)
+		}
+		if len(record.SQLHash) != 64 {
+			t.Errorf("Could not match sql hash length. actual: %s", record.SQLHash)
+		}
+		if len(record.Parameters) != 1 || record.Parameters[0] != (bigquery.AuditParameter{Name: "name", Type: "string"}) {
+			t.Errorf("Could not match parameters. actual: %+v", record.Parameters)
+		}
+
+		if !strings.Contains(slogBuf.String(), `"sql_hash":"`+record.SQLHash+`"`) {
+			t.Errorf("Could not found sql hash on slog output. actual: %s", slogBuf.String())
+		}
+
+		if len(store.items) != 1 {
+			t.Errorf("Could not match stored items length.\nexpect: %d\nactual: %d", 1, len(store.items))
+		}
+	})
+	t.Run("Store context", func(t *testing.T) {
+		store := &mapStore{items: make(map[string]string)}
+		sink := bigquery.NewDynamoDBAuditSink(store)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := sink.WriteAudit(ctx, &bigquery.AuditRecord{Time: time.Now(), SQLHash: "hash"})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", context.Canceled, err)
+		}
+	})
+	t.Run("Parameter values", func(t *testing.T) {
+		srv := newFakeServer(t)
+
+		var buf bytes.Buffer
+		b := srv.newBigQuery(t, bigquery.OptionAuditSinks(bigquery.NewJSONAuditSink(&buf)), bigquery.OptionAuditParameterValues())
+
+		_, _, err := b.Query(context.Background(), query, bigquery.QueryOptionParameters(bq.QueryParameter{Name: "name", Value: "aa"}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b.Close()
+
+		var record bigquery.AuditRecord
+		if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+			t.Fatal(err)
+		}
+		if len(record.Parameters) != 1 || record.Parameters[0] != (bigquery.AuditParameter{Name: "name", Type: "string", Value: "aa"}) {
+			t.Errorf("Could not match parameters. actual: %+v", record.Parameters)
+		}
+	})
+	t.Run("Error", func(t *testing.T) {
+		srv := newFakeServer(t)
+
+		var buf bytes.Buffer
+		b := srv.newBigQuery(t, bigquery.OptionAuditSinks(bigquery.NewJSONAuditSink(&buf)))
+
+		err := b.Execute(context.Background(), fakeErrorQuery)
+		if err == nil {
+			t.Fatal("Bug. Query is invalid. But returns not error")
+		}
+
+		b.Close()
+
+		var record bigquery.AuditRecord
+		if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+			t.Fatal(err)
+		}
+		if record.Error == "" {
+			t.Error("Could not found error on audit record")
+		}
+	})
+	t.Run("Not blocking", func(t *testing.T) {
+		srv := newFakeServer(t)
+
+		release := make(chan struct{})
+		var dropped []error
+
+		b := srv.newBigQuery(t,
+			bigquery.OptionAuditSinks(bigquery.AuditSinkFunc(func(ctx context.Context, r *bigquery.AuditRecord) error {
+				<-release
+				return nil
+			})),
+			bigquery.OptionAuditBufferSize(1),
+			bigquery.OptionAuditErrorHandler(func(err error) { dropped = append(dropped, err) }),
+		)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 5; i++ {
+				if err := b.Execute(context.Background(), query); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Bug. Query waits for audit sink")
+		}
+
+		close(release)
+		b.Close()
+
+		if len(dropped) != 1 {
+			t.Errorf("Could not report dropped records. actual: %v", dropped)
+		}
+	})
+}
+
+func TestRedactSQL(t *testing.T) {
+	tests := []struct {
+		sql    string
+		expect string
+	}{
+		{
+			sql:    "select * from `project.dataset.table2` where name = 'aa' and age >= 32",
+			expect: "select * from `project.dataset.table2` where name = ? and age >= ?",
+		},
+		{
+			sql:    `select "it\"s", b'\x00', r"raw", 1.5e10 from t`,
+			expect: `select ?, ?, ?, ? from t`,
+		},
+		{
+			sql:    "select '''multi\n'line''' -- 'comment' 123\nfrom t",
+			expect: "select ? -- 'comment' 123\nfrom t",
+		},
+		{
+			sql:    "select /* 'comment' 123 */ a, /*+ hint */ 'b' from t /* unterminated 'c'",
+			expect: "select /* 'comment' 123 */ a, /*+ hint */ ? from t /* unterminated 'c'",
+		},
+		{
+			sql:    "select col_1, rb'x' from t where id in (1, 2)",
+			expect: "select col_1, ? from t where id in (?, ?)",
+		},
+	}
+
+	for _, tt := range tests {
+		actual := bigquery.RedactSQL(tt.sql)
+		if actual != tt.expect {
+			t.Errorf("Could not match redacted sql.\nexpect: %s\nactual: %s", tt.expect, actual)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package bigquery
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"sync"
+
+	"cloud.google.com/go/bigquery"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// NewSlogAuditSink returns AuditSink logging records with logger
+func NewSlogAuditSink(logger *slog.Logger) AuditSink {
+	return AuditSinkFunc(func(ctx context.Context, r *AuditRecord) error {
+		attrs := []slog.Attr{
+			slog.Time("time", r.Time),
+			slog.String("operation", r.Operation),
+			slog.String("principal", r.Principal),
+			slog.String("project_id", r.ProjectID),
+			slog.String("sql_hash", r.SQLHash),
+			slog.String("sql", r.SQL),
+			slog.Any("parameters", r.Parameters),
+			slog.Bool("dry_run", r.DryRun),
+			slog.String("job_id", r.JobID),
+			slog.String("location", r.Location),
+			slog.Duration("duration", r.Duration),
+			slog.String("statement_type", r.StatementType),
+			slog.Int64("total_bytes_processed", r.TotalBytesProcessed),
+			slog.Int64("total_bytes_billed", r.TotalBytesBilled),
+			slog.Bool("cache_hit", r.CacheHit),
+			slog.Int64("slot_ms", r.SlotMillis),
+		}
+
+		level := slog.LevelInfo
+		if r.Error != "" {
+			level = slog.LevelError
+			attrs = append(attrs, slog.String("error", r.Error))
+		}
+
+		logger.LogAttrs(ctx, level, "bigquery audit", attrs...)
+
+		return nil
+	})
+}
+
+// NewJSONAuditSink returns AuditSink writing records to w as JSON lines
+func NewJSONAuditSink(w io.Writer) AuditSink {
+	var mu sync.Mutex
+	enc := json.NewEncoder(w)
+
+	return AuditSinkFunc(func(ctx context.Context, r *AuditRecord) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return enc.Encode(r)
+	})
+}
+
+// AuditTableSchema is schema of the table used with NewTableAuditSink
+var AuditTableSchema = bigquery.Schema{
+	{Name: "time", Type: bigquery.TimestampFieldType, Required: true},
+	{Name: "operation", Type: bigquery.StringFieldType, Required: true},
+	{Name: "principal", Type: bigquery.StringFieldType},
+	{Name: "project_id", Type: bigquery.StringFieldType},
+	{Name: "sql_hash", Type: bigquery.StringFieldType, Required: true},
+	{Name: "sql", Type: bigquery.StringFieldType},
+	{Name: "parameters", Type: bigquery.RecordFieldType, Repeated: true, Schema: bigquery.Schema{
+		{Name: "name", Type: bigquery.StringFieldType},
+		{Name: "type", Type: bigquery.StringFieldType},
+		{Name: "value", Type: bigquery.StringFieldType},
+	}},
+	{Name: "dry_run", Type: bigquery.BooleanFieldType},
+	{Name: "job_id", Type: bigquery.StringFieldType},
+	{Name: "location", Type: bigquery.StringFieldType},
+	{Name: "duration_ms", Type: bigquery.IntegerFieldType},
+	{Name: "statement_type", Type: bigquery.StringFieldType},
+	{Name: "total_bytes_processed", Type: bigquery.IntegerFieldType},
+	{Name: "total_bytes_billed", Type: bigquery.IntegerFieldType},
+	{Name: "cache_hit", Type: bigquery.BooleanFieldType},
+	{Name: "slot_ms", Type: bigquery.IntegerFieldType},
+	{Name: "error", Type: bigquery.StringFieldType},
+}
+
+// auditRow is AuditRecord saved by streaming insert
+type auditRow AuditRecord
+
+// Save implements bigquery.ValueSaver
+func (r *auditRow) Save() (map[string]bigquery.Value, string, error) {
+	params := make([]map[string]bigquery.Value, 0, len(r.Parameters))
+	for _, p := range r.Parameters {
+		params = append(params, map[string]bigquery.Value{
+			"name":  p.Name,
+			"type":  p.Type,
+			"value": p.Value,
+		})
+	}
+
+	return map[string]bigquery.Value{
+		"time":                  r.Time,
+		"operation":             r.Operation,
+		"principal":             r.Principal,
+		"project_id":            r.ProjectID,
+		"sql_hash":              r.SQLHash,
+		"sql":                   r.SQL,
+		"parameters":            params,
+		"dry_run":               r.DryRun,
+		"job_id":                r.JobID,
+		"location":              r.Location,
+		"duration_ms":           r.Duration.Milliseconds(),
+		"statement_type":        r.StatementType,
+		"total_bytes_processed": r.TotalBytesProcessed,
+		"total_bytes_billed":    r.TotalBytesBilled,
+		"cache_hit":             r.CacheHit,
+		"slot_ms":               r.SlotMillis,
+		"error":                 r.Error,
+	}, bigquery.NoDedupeID, nil
+}
+
+// NewTableAuditSink returns AuditSink inserting records to table by streaming insert.
+// The table must be created with AuditTableSchema
+func NewTableAuditSink(table *bigquery.Table) AuditSink {
+	inserter := table.Inserter()
+
+	return AuditSinkFunc(func(ctx context.Context, r *AuditRecord) error {
+		return inserter.Put(ctx, (*auditRow)(r))
+	})
+}
+
+// AuditStore is key value store such as aws/dynamodb.DynamoDB
+type AuditStore interface {
+	SetWithContext(ctx context.Context, key, value string, optFns ...func(*dynamodb.Options)) error
+}
+
+// NewDynamoDBAuditSink returns AuditSink storing records as JSON on store.
+// The key is composed of record time and sql hash
+func NewDynamoDBAuditSink(store AuditStore) AuditSink {
+	return AuditSinkFunc(func(ctx context.Context, r *AuditRecord) error {
+		b, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+
+		return store.SetWithContext(ctx, auditKey(r), string(b))
+	})
+}
+
+func auditKey(r *AuditRecord) string {
+	key := r.Time.UTC().Format("2006-01-02T15:04:05.000000000Z") + "#" + r.SQLHash
+	if r.JobID != "" {
+		key += "#" + r.JobID
+	}
+	return key
+}
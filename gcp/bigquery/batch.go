@@ -0,0 +1,429 @@
+package bigquery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+var (
+	// ErrBatchFailed is wrapped by the error returned from RunBatch when any query did not succeed
+	ErrBatchFailed = errors.New("batch failed")
+	// ErrBatchDependencyFailed is set on BatchQueryResult of the query skipped because its dependency did not succeed
+	ErrBatchDependencyFailed = errors.New("dependency failed")
+)
+
+// BatchQuery is single query of batch
+type BatchQuery struct {
+	// Name identifies the query in the batch. It must be unique
+	Name    string
+	Query   string
+	Options []QueryOption
+	// DependsOn is names of the queries which must succeed before this query starts
+	DependsOn []string
+}
+
+// BatchState is state of query in batch
+type BatchState int
+
+// BatchState values
+const (
+	BatchPending BatchState = iota
+	BatchSucceeded
+	BatchFailed
+	BatchSkipped
+	BatchCanceled
+)
+
+// String returns name of the state
+func (s BatchState) String() string {
+	switch s {
+	case BatchPending:
+		return "PENDING"
+	case BatchSucceeded:
+		return "SUCCEEDED"
+	case BatchFailed:
+		return "FAILED"
+	case BatchSkipped:
+		return "SKIPPED"
+	case BatchCanceled:
+		return "CANCELED"
+	}
+	return "UNKNOWN"
+}
+
+// BatchQueryResult is result of single query in batch
+type BatchQueryResult struct {
+	Name      string
+	JobID     string
+	State     BatchState
+	Err       error
+	StartTime time.Time
+	EndTime   time.Time
+	// Report is nil when the job was not submitted
+	Report *QueryReport
+}
+
+// BatchResult is aggregated result of batch
+type BatchResult struct {
+	// Results is in the same order as the queries passed to RunBatch
+	Results   []*BatchQueryResult
+	StartTime time.Time
+	EndTime   time.Time
+
+	Succeeded int
+	Failed    int
+	Skipped   int
+	Canceled  int
+
+	TotalBytesProcessed int64
+	TotalBytesBilled    int64
+	SlotMillis          int64
+}
+
+// Result returns the result of the query with name
+func (r *BatchResult) Result(name string) (*BatchQueryResult, bool) {
+	for _, res := range r.Results {
+		if res.Name == name {
+			return res, true
+		}
+	}
+	return nil, false
+}
+
+// String returns human readable summary of the batch
+func (r *BatchResult) String() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%d queries in %s: %d succeeded, %d failed, %d skipped, %d canceled\n",
+		len(r.Results), r.EndTime.Sub(r.StartTime), r.Succeeded, r.Failed, r.Skipped, r.Canceled)
+	fmt.Fprintf(&b, "Bytes processed: %s, bytes billed: %s, slot time: %s\n\n",
+		formatBytes(r.TotalBytesProcessed), formatBytes(r.TotalBytesBilled), time.Duration(r.SlotMillis)*time.Millisecond)
+
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Name\tState\tJob\tDuration\tBytes billed\tSlot time\tError")
+	for _, res := range r.Results {
+		var billed, slot, errText string
+		if res.Report != nil {
+			billed = formatBytes(res.Report.TotalBytesBilled)
+			slot = (time.Duration(res.Report.SlotMillis) * time.Millisecond).String()
+		}
+		if res.Err != nil {
+			errText = res.Err.Error()
+		}
+		var d time.Duration
+		if !res.StartTime.IsZero() {
+			d = res.EndTime.Sub(res.StartTime)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", res.Name, res.State, res.JobID, d, billed, slot, errText)
+	}
+	w.Flush()
+
+	return b.String()
+}
+
+type batchConfig struct {
+	concurrency     int
+	limiter         *rate.Limiter
+	continueOnError bool
+}
+
+func newBatchConfig() *batchConfig {
+	return &batchConfig{
+		concurrency:     10,
+		limiter:         nil,
+		continueOnError: false,
+	}
+}
+
+func createBatchConfig(batchOpts ...BatchOption) (*batchConfig, error) {
+	bc := newBatchConfig()
+
+	for _, opt := range batchOpts {
+		err := opt(bc)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return bc, nil
+}
+
+// BatchOption is functional option pattern option for RunBatch
+type BatchOption func(*batchConfig) error
+
+// BatchOptionConcurrency returns BatchOption instance with the maximum number of jobs running at once. default is 10
+func BatchOptionConcurrency(n int) func(c *batchConfig) error {
+	return func(c *batchConfig) error {
+		if n < 1 {
+			return errors.New("concurrency must be positive")
+		}
+		c.concurrency = n
+		return nil
+	}
+}
+
+// BatchOptionRateLimit returns BatchOption instance limiting job submissions to r per second with burst
+func BatchOptionRateLimit(r float64, burst int) func(c *batchConfig) error {
+	return func(c *batchConfig) error {
+		if r <= 0 || burst < 1 {
+			return errors.New("rate and burst must be positive")
+		}
+		c.limiter = rate.NewLimiter(rate.Limit(r), burst)
+		return nil
+	}
+}
+
+// BatchOptionRateLimiter returns BatchOption instance limiting job submissions with limiter.
+// Share the limiter between batches to apply a single rate limit per project
+func BatchOptionRateLimiter(limiter *rate.Limiter) func(c *batchConfig) error {
+	return func(c *batchConfig) error {
+		c.limiter = limiter
+		return nil
+	}
+}
+
+// BatchOptionContinueOnError returns BatchOption instance running the remaining queries after failure.
+// By default the batch fails fast: running jobs are canceled and pending queries are not started
+func BatchOptionContinueOnError() func(c *batchConfig) error {
+	return func(c *batchConfig) error {
+		c.continueOnError = true
+		return nil
+	}
+}
+
+// RunBatch runs queries concurrently through ExecuteAsync and waits for all jobs.
+// Queries start after all of their DependsOn succeeded.
+// The result is returned even when error wrapping ErrBatchFailed is returned
+func (bq *BigQuery) RunBatch(ctx context.Context, queries []*BatchQuery, batchOpts ...BatchOption) (*BatchResult, error) {
+	bc, err := createBatchConfig(batchOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateBatch(queries)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	r := &batchRunner{
+		bq:      bq,
+		bc:      bc,
+		cancel:  cancel,
+		sem:     make(chan struct{}, bc.concurrency),
+		done:    make(map[string]chan struct{}, len(queries)),
+		results: make(map[string]*BatchQueryResult, len(queries)),
+	}
+
+	for _, q := range queries {
+		r.done[q.Name] = make(chan struct{})
+		r.results[q.Name] = &BatchQueryResult{Name: q.Name, State: BatchPending}
+	}
+
+	result := &BatchResult{
+		Results:   make([]*BatchQueryResult, 0, len(queries)),
+		StartTime: time.Now(),
+	}
+
+	var wg sync.WaitGroup
+	for _, q := range queries {
+		wg.Add(1)
+		go func(q *BatchQuery) {
+			defer wg.Done()
+			defer close(r.done[q.Name])
+			r.run(ctx, q)
+		}(q)
+	}
+	wg.Wait()
+
+	result.EndTime = time.Now()
+
+	for _, q := range queries {
+		res := r.results[q.Name]
+		result.Results = append(result.Results, res)
+
+		switch res.State {
+		case BatchSucceeded:
+			result.Succeeded++
+		case BatchFailed:
+			result.Failed++
+		case BatchSkipped:
+			result.Skipped++
+		case BatchCanceled:
+			result.Canceled++
+		}
+
+		if res.Report != nil {
+			result.TotalBytesProcessed += res.Report.TotalBytesProcessed
+			result.TotalBytesBilled += res.Report.TotalBytesBilled
+			result.SlotMillis += res.Report.SlotMillis
+		}
+	}
+
+	if result.Succeeded != len(queries) {
+		return result, fmt.Errorf("%w: %d of %d queries did not succeed", ErrBatchFailed, len(queries)-result.Succeeded, len(queries))
+	}
+
+	return result, nil
+}
+
+type batchRunner struct {
+	bq     *BigQuery
+	bc     *batchConfig
+	cancel context.CancelFunc
+	sem    chan struct{}
+
+	// done and results are written before run starts. results entries are owned by each run
+	done    map[string]chan struct{}
+	results map[string]*BatchQueryResult
+}
+
+func (r *batchRunner) run(ctx context.Context, q *BatchQuery) {
+	res := r.results[q.Name]
+
+	for _, dep := range q.DependsOn {
+		select {
+		case <-r.done[dep]:
+		case <-ctx.Done():
+			res.State, res.Err = BatchCanceled, ctx.Err()
+			return
+		}
+
+		if r.results[dep].State != BatchSucceeded {
+			res.State, res.Err = BatchSkipped, fmt.Errorf("%w: %s", ErrBatchDependencyFailed, dep)
+			return
+		}
+	}
+
+	select {
+	case r.sem <- struct{}{}:
+		defer func() { <-r.sem }()
+	case <-ctx.Done():
+		res.State, res.Err = BatchCanceled, ctx.Err()
+		return
+	}
+
+	if r.bc.limiter != nil {
+		if err := r.bc.limiter.Wait(ctx); err != nil {
+			res.State, res.Err = BatchCanceled, err
+			return
+		}
+	}
+
+	res.StartTime = time.Now()
+	defer func() { res.EndTime = time.Now() }()
+
+	err := r.execute(ctx, q, res)
+	switch {
+	case err == nil:
+		res.State = BatchSucceeded
+	case ctx.Err() != nil:
+		res.State, res.Err = BatchCanceled, err
+	default:
+		res.State, res.Err = BatchFailed, err
+		if !r.bc.continueOnError {
+			r.cancel()
+		}
+	}
+}
+
+func (r *batchRunner) execute(ctx context.Context, q *BatchQuery, res *BatchQueryResult) error {
+	qr, err := r.bq.intercept(ctx, newQueryRequest(OperationExecuteAsync, q.Query, q.Options), r.bq.executeAsync)
+	if err != nil {
+		return err
+	}
+	if qr.JobID == "" {
+		// dry run
+		return nil
+	}
+
+	res.JobID = qr.JobID
+
+	job, err := r.bq.Client.JobFromIDLocation(ctx, qr.JobID, qr.JobLocation)
+	if err != nil {
+		return err
+	}
+
+	status, err := job.Wait(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			// fail fast. the job keeps running unless canceled
+			job.Cancel(context.Background())
+		}
+		return err
+	}
+
+	res.Report = NewQueryReport(job, status.Statistics)
+
+	return status.Err()
+}
+
+// validateBatch checks that names are unique, dependencies exist and there is no cycle
+func validateBatch(queries []*BatchQuery) error {
+	deps := make(map[string][]string, len(queries))
+
+	for _, q := range queries {
+		if q == nil {
+			return errors.New("batch query is nil")
+		}
+		if q.Name == "" {
+			return errors.New("batch query name is empty")
+		}
+		if _, ok := deps[q.Name]; ok {
+			return fmt.Errorf("batch query name is duplicated: %s", q.Name)
+		}
+		deps[q.Name] = q.DependsOn
+	}
+
+	for _, q := range queries {
+		for _, dep := range q.DependsOn {
+			if _, ok := deps[dep]; !ok {
+				return fmt.Errorf("batch query %s depends on unknown query: %s", q.Name, dep)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	marks := make(map[string]int, len(queries))
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch marks[name] {
+		case visiting:
+			return fmt.Errorf("batch query dependency has cycle: %s", strings.Join(append(path, name), " -> "))
+		case visited:
+			return nil
+		}
+
+		marks[name] = visiting
+		for _, dep := range deps[name] {
+			if err := visit(dep, append(path, name)); err != nil {
+				return err
+			}
+		}
+		marks[name] = visited
+
+		return nil
+	}
+
+	for _, q := range queries {
+		if err := visit(q.Name, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
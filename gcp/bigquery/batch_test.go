@@ -0,0 +1,181 @@
+package bigquery_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/rssh-jp/data-access-library/gcp/bigquery"
+)
+
+func TestRunBatch(t *testing.T) {
+	const query = "select id, name from `test_dataset.test_table`"
+
+	t.Run("Dependencies", func(t *testing.T) {
+		srv := newFakeServer(t)
+		b := srv.newBigQuery(t)
+
+		queries := []*bigquery.BatchQuery{
+			{Name: "report", Query: "select 'report'", DependsOn: []string{"daily", "weekly"}},
+			{Name: "daily", Query: "select 'daily'", DependsOn: []string{"load"}},
+			{Name: "weekly", Query: "select 'weekly'", DependsOn: []string{"load"}},
+			{Name: "load", Query: "select 'load'"},
+		}
+
+		result, err := b.RunBatch(context.Background(), queries, bigquery.BatchOptionConcurrency(2), bigquery.BatchOptionRateLimit(100, 1))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if result.Succeeded != 4 {
+			t.Errorf("Could not match succeeded.\nexpect: %d\nactual: %d\n%s", 4, result.Succeeded, result)
+		}
+		if result.TotalBytesBilled != 4*10485760 {
+			t.Errorf("Could not match total bytes billed.\nexpect: %d\nactual: %d", 4*10485760, result.TotalBytesBilled)
+		}
+
+		submitted := srv.Queries()
+		order := make(map[string]int, len(submitted))
+		for i, q := range submitted {
+			order[q] = i
+		}
+		if order["select 'load'"] != 0 || order["select 'report'"] != 3 {
+			t.Errorf("Could not keep dependency order. actual: %v", submitted)
+		}
+
+		if res, ok := result.Result("report"); !ok || res.Report == nil || res.JobID == "" {
+			t.Errorf("Could not found report of query. actual: %+v", res)
+		}
+	})
+	t.Run("Job location", func(t *testing.T) {
+		srv := newFakeServer(t)
+		srv.regional = true
+		b := srv.newBigQuery(t)
+
+		result, err := b.RunBatch(context.Background(), []*bigquery.BatchQuery{{Name: "regional", Query: query}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		res, ok := result.Result("regional")
+		if !ok || res.State != bigquery.BatchSucceeded || res.Report == nil {
+			t.Errorf("Bug. the job should be looked up in its location. But %+v", res)
+		}
+	})
+	t.Run("Fail fast", func(t *testing.T) {
+		srv := newFakeServer(t)
+		b := srv.newBigQuery(t)
+
+		queries := []*bigquery.BatchQuery{
+			{Name: "broken", Query: fakeErrorQuery},
+			{Name: "after", Query: query, DependsOn: []string{"broken"}},
+		}
+
+		result, err := b.RunBatch(context.Background(), queries)
+		if !errors.Is(err, bigquery.ErrBatchFailed) {
+			t.Fatalf("Could not match error.\nexpect: %v\nactual: %v", bigquery.ErrBatchFailed, err)
+		}
+
+		broken, _ := result.Result("broken")
+		if broken.State != bigquery.BatchFailed {
+			t.Errorf("Could not match state.\nexpect: %v\nactual: %v", bigquery.BatchFailed, broken.State)
+		}
+		after, _ := result.Result("after")
+		if after.State != bigquery.BatchCanceled && after.State != bigquery.BatchSkipped {
+			t.Errorf("Could not match state.\nexpect: %v or %v\nactual: %v", bigquery.BatchCanceled, bigquery.BatchSkipped, after.State)
+		}
+	})
+	t.Run("Continue on error", func(t *testing.T) {
+		srv := newFakeServer(t)
+		b := srv.newBigQuery(t)
+
+		queries := []*bigquery.BatchQuery{
+			{Name: "broken", Query: fakeErrorQuery},
+			{Name: "after", Query: query, DependsOn: []string{"broken"}},
+			{Name: "independent", Query: query},
+		}
+
+		result, err := b.RunBatch(context.Background(), queries, bigquery.BatchOptionContinueOnError())
+		if !errors.Is(err, bigquery.ErrBatchFailed) {
+			t.Fatalf("Could not match error.\nexpect: %v\nactual: %v", bigquery.ErrBatchFailed, err)
+		}
+
+		if result.Failed != 1 || result.Skipped != 1 || result.Succeeded != 1 {
+			t.Errorf("Could not match result.\n%s", result)
+		}
+
+		after, _ := result.Result("after")
+		if !errors.Is(after.Err, bigquery.ErrBatchDependencyFailed) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", bigquery.ErrBatchDependencyFailed, after.Err)
+		}
+	})
+	t.Run("Concurrency", func(t *testing.T) {
+		srv := newFakeServer(t)
+
+		var mu sync.Mutex
+		var running, maxRunning int
+		b := srv.newBigQuery(t, bigquery.OptionInterceptors(
+			func(ctx context.Context, req *bigquery.QueryRequest, next bigquery.QueryHandler) (*bigquery.QueryResult, error) {
+				mu.Lock()
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				mu.Unlock()
+
+				defer func() {
+					mu.Lock()
+					running--
+					mu.Unlock()
+				}()
+
+				return next(ctx, req)
+			},
+		))
+
+		var queries []*bigquery.BatchQuery
+		for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+			queries = append(queries, &bigquery.BatchQuery{Name: name, Query: query})
+		}
+
+		_, err := b.RunBatch(context.Background(), queries, bigquery.BatchOptionConcurrency(2))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if maxRunning > 2 {
+			t.Errorf("Could not limit concurrency.\nexpect: <= %d\nactual: %d", 2, maxRunning)
+		}
+	})
+	t.Run("Validation", func(t *testing.T) {
+		srv := newFakeServer(t)
+		b := srv.newBigQuery(t)
+
+		tests := map[string][]*bigquery.BatchQuery{
+			"Duplicated": {
+				{Name: "a", Query: query},
+				{Name: "a", Query: query},
+			},
+			"Unknown dependency": {
+				{Name: "a", Query: query, DependsOn: []string{"b"}},
+			},
+			"Cycle": {
+				{Name: "a", Query: query, DependsOn: []string{"c"}},
+				{Name: "b", Query: query, DependsOn: []string{"a"}},
+				{Name: "c", Query: query, DependsOn: []string{"b"}},
+			},
+		}
+
+		for name, queries := range tests {
+			_, err := b.RunBatch(context.Background(), queries)
+			if err == nil {
+				t.Errorf("%s: Bug. Batch is invalid. But returns not error", name)
+			}
+		}
+
+		if len(srv.Queries()) != 0 {
+			t.Errorf("Bug. Batch is invalid. But submitted: %v", srv.Queries())
+		}
+	})
+}
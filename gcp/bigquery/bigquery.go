@@ -14,145 +14,267 @@ import (
 type BigQuery struct {
 	// Through Client property when wanna use base bigquery function
 	Client *bigquery.Client
+
+	telemetry    *telemetry
+	auditor      *auditor
+	interceptors []Interceptor
 }
 
 // New return BigQuery instance
 func New(projectID string, opts ...option.ClientOption) (*BigQuery, error) {
-	c, err := bigquery.NewClient(context.Background(), projectID, opts...)
+	return NewWithOptions(projectID, OptionClientOptions(opts...))
+}
+
+// NewWithOptions return BigQuery instance configured by Option such as telemetry, audit and interceptors
+func NewWithOptions(projectID string, opts ...Option) (*BigQuery, error) {
+	cfg, err := createConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := bigquery.NewClient(context.Background(), projectID, cfg.clientOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := newTelemetry(cfg.tracerProvider, cfg.meterProvider)
 	if err != nil {
 		return nil, err
 	}
 
 	return &BigQuery{
-		Client: c,
+		Client:       c,
+		telemetry:    t,
+		auditor:      newAuditor(c.Project(), cfg.audit),
+		interceptors: cfg.interceptors,
 	}, nil
 }
 
-// JobStatus returns job status by job id
-func (bq *BigQuery) JobStatus(ctx context.Context, jobID string) (*bigquery.JobStatus, error) {
-	job, err := bq.Client.JobFromID(ctx, jobID)
-	if err != nil {
-		return nil, err
+// Close flushes pending audit records and closes the client
+func (bq *BigQuery) Close() error {
+	if bq.auditor != nil {
+		bq.auditor.close()
 	}
 
-	return job.Status(ctx)
+	return bq.Client.Close()
 }
 
-// Execute is execute query. returns error
-func (bq *BigQuery) Execute(ctx context.Context, query string, queryOpts ...QueryOption) error {
-	qc, err := createQueryConfig(queryOpts...)
+// JobStatus returns job status by job id
+func (bq *BigQuery) JobStatus(ctx context.Context, jobID string) (status *bigquery.JobStatus, err error) {
+	ctx, o := bq.telemetry.start(ctx, "JobStatus", AttributeJobID.String(jobID))
+	defer func() { bq.finish(ctx, o, err) }()
+
+	job, err := bq.Client.JobFromID(ctx, jobID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	q, err := bq.createQuery(ctx, query, qc)
+	o.setJob(job)
+
+	status, err = job.Status(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if qc.isDryRun {
-		job, err := q.Run(ctx)
-		if err != nil {
-			return err
-		}
+	o.setStatus(status)
 
-		*qc.jobStatistics = *job.LastStatus().Statistics
+	return status, nil
+}
 
-		return nil
+// JobReport returns query report by job id
+func (bq *BigQuery) JobReport(ctx context.Context, jobID string) (*QueryReport, error) {
+	job, err := bq.Client.JobFromID(ctx, jobID)
+	if err != nil {
+		return nil, err
 	}
 
-	_, err = q.Read(ctx)
-	if err != nil {
-		return err
+	var js *bigquery.JobStatistics
+	if status := job.LastStatus(); status != nil {
+		js = status.Statistics
 	}
 
-	return nil
+	return NewQueryReport(job, js), nil
+}
+
+// Execute is execute query. returns error
+func (bq *BigQuery) Execute(ctx context.Context, query string, queryOpts ...QueryOption) error {
+	_, err := bq.intercept(ctx, newQueryRequest(OperationExecute, query, queryOpts), bq.execute)
+	return err
 }
 
 // ExecuteAsync is execute query asynchronous. returns error
 func (bq *BigQuery) ExecuteAsync(ctx context.Context, query string, queryOpts ...QueryOption) (string, error) {
-	qc, err := createQueryConfig(queryOpts...)
+	res, err := bq.intercept(ctx, newQueryRequest(OperationExecuteAsync, query, queryOpts), bq.executeAsync)
 	if err != nil {
 		return "", err
 	}
 
-	q, err := bq.createQuery(ctx, query, qc)
+	return res.JobID, nil
+}
+
+// Query is execute query. returns columns, contents, error
+func (bq *BigQuery) Query(ctx context.Context, query string, queryOpts ...QueryOption) (columns []string, contents [][]string, err error) {
+	res, err := bq.intercept(ctx, newQueryRequest(OperationQuery, query, queryOpts), bq.query)
 	if err != nil {
-		return "", err
+		return nil, nil, err
 	}
 
-	if qc.isDryRun {
+	return res.Columns, res.Contents, nil
+}
+
+func (bq *BigQuery) execute(ctx context.Context, req *QueryRequest) (*QueryResult, error) {
+	return bq.observeQuery(ctx, req, true, func(ctx context.Context, q *bigquery.Query) (*bigquery.Job, *QueryResult, error) {
+		it, err := q.Read(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		job := it.SourceJob()
+
+		return job, newQueryResult(job), nil
+	})
+}
+
+func (bq *BigQuery) executeAsync(ctx context.Context, req *QueryRequest) (*QueryResult, error) {
+	return bq.observeQuery(ctx, req, false, func(ctx context.Context, q *bigquery.Query) (*bigquery.Job, *QueryResult, error) {
 		job, err := q.Run(ctx)
 		if err != nil {
-			return "", err
+			return nil, nil, err
 		}
 
-		*qc.jobStatistics = *job.LastStatus().Statistics
+		return job, newQueryResult(job), nil
+	})
+}
 
-		return "", nil
-	}
+func (bq *BigQuery) query(ctx context.Context, req *QueryRequest) (*QueryResult, error) {
+	return bq.observeQuery(ctx, req, true, func(ctx context.Context, q *bigquery.Query) (*bigquery.Job, *QueryResult, error) {
+		it, err := q.Read(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
 
-	job, err := q.Run(ctx)
-	if err != nil {
-		return "", err
-	}
+		job := it.SourceJob()
+
+		res := newQueryResult(job)
+
+		res.Columns = make([]string, 0, len(it.Schema))
+		for _, item := range it.Schema {
+			res.Columns = append(res.Columns, item.Name)
+		}
+
+		res.Contents = make([][]string, 0, int(it.TotalRows))
+		for {
+			var row []bigquery.Value
+			err := it.Next(&row)
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+
+			content := make([]string, 0, len(it.Schema))
+			for index := range row {
+				content = append(content, parseToString(it.Schema[index].Type, row[index]))
+			}
+
+			res.Contents = append(res.Contents, content)
+		}
 
-	return job.ID(), nil
+		return job, res, nil
+	})
 }
 
-// Query is execute query. returns columns, contents, error
-func (bq *BigQuery) Query(ctx context.Context, query string, queryOpts ...QueryOption) (columns []string, contents [][]string, err error) {
-	qc, err := createQueryConfig(queryOpts...)
+// observeQuery runs the query of req by run under telemetry and audit log.
+// Dry run is handled here without calling run. finished reports whether run waits for the job, so that final statistics can be fetched
+func (bq *BigQuery) observeQuery(ctx context.Context, req *QueryRequest, finished bool, run func(ctx context.Context, q *bigquery.Query) (*bigquery.Job, *QueryResult, error)) (res *QueryResult, err error) {
+	qc, err := createQueryConfig(req.Options...)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	q, err := bq.createQuery(ctx, query, qc)
+	ctx, o := bq.telemetry.start(ctx, req.Operation, AttributeDryRun.Bool(qc.isDryRun))
+	o.setQuery(req.Query, qc)
+	defer func() { bq.finish(ctx, o, err) }()
+
+	q, err := bq.createQuery(ctx, req.Query, qc)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	if qc.isDryRun {
 		job, err := q.Run(ctx)
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 
-		*qc.jobStatistics = *job.LastStatus().Statistics
+		o.setJob(job)
+
+		setStatisticsReferences(qc, o)
 
-		return nil, nil, nil
+		return &QueryResult{}, nil
 	}
 
-	it, err := q.Read(ctx)
+	job, res, err := run(ctx, q)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	columns = make([]string, 0, len(it.Schema))
-	for _, item := range it.Schema {
-		columns = append(columns, item.Name)
+	if finished {
+		bq.observeJob(ctx, o, qc, job)
+	} else {
+		o.setJob(job)
 	}
 
-	contents = make([][]string, 0, int(it.TotalRows))
-	for {
-		var row []bigquery.Value
-		err := it.Next(&row)
-		if err == iterator.Done {
-			break
-		}
-		if err != nil {
-			return nil, nil, err
-		}
+	setStatisticsReferences(qc, o)
 
-		content := make([]string, 0, len(it.Schema))
-		for index := range row {
-			content = append(content, parseToString(it.Schema[index].Type, row[index]))
-		}
+	return res, nil
+}
+
+// finish ends the observation of the call
+func (bq *BigQuery) finish(ctx context.Context, o *observation, err error) {
+	bq.telemetry.end(ctx, o, err)
+
+	if bq.auditor != nil && o.query != "" {
+		bq.auditor.emit(ctx, o, err)
+	}
+}
+
+// observeJob records the job backing a finished query.
+// Final statistics are fetched only when telemetry, audit log or statistics references need them
+func (bq *BigQuery) observeJob(ctx context.Context, o *observation, qc *queryConfig, job *bigquery.Job) {
+	if job == nil {
+		return
+	}
+
+	o.setJob(job)
+
+	if !bq.telemetry.enabled && bq.auditor == nil && !qc.needsStatistics() {
+		return
+	}
 
-		contents = append(contents, content)
+	status, err := job.Status(ctx)
+	if err != nil {
+		// statistics are best effort. the query itself has succeeded
+		return
 	}
 
-	return columns, contents, nil
+	o.setStatus(status)
+}
+
+// setStatisticsReferences copies the observed statistics to the references specified by query options
+func setStatisticsReferences(qc *queryConfig, o *observation) {
+	if o.statistics == nil {
+		return
+	}
+
+	if qc.jobStatistics != nil {
+		*qc.jobStatistics = *o.statistics
+	}
+
+	if qc.queryReport != nil {
+		*qc.queryReport = *NewQueryReport(o.job, o.statistics)
+	}
 }
 
 func (bq *BigQuery) createQuery(ctx context.Context, query string, qc *queryConfig) (*bigquery.Query, error) {
@@ -180,6 +302,14 @@ func (bq *BigQuery) createQuery(ctx context.Context, query string, qc *queryConf
 		q.WriteDisposition = *qc.writeDisposition
 	}
 
+	if len(qc.parameters) > 0 {
+		q.Parameters = qc.parameters
+	}
+
+	if len(qc.labels) > 0 {
+		q.Labels = qc.labels
+	}
+
 	return q, nil
 }
 
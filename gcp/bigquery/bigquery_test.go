@@ -16,21 +16,34 @@ const (
 	subProjectID = "test-project01-289306"
 )
 
+// errLive is error preparing the live projects. Tests using them are skipped when it is not nil,
+// so that tests on fake server run without credentials
+var errLive error
+
 func TestMain(m *testing.M) {
-	err := preprocess()
-	if err != nil {
-		log.Fatal(err)
+	errLive = preprocess()
+	if errLive != nil {
+		log.Println("skip tests using live projects:", errLive)
+	} else {
+		defer func() {
+			err := postprocess()
+			if err != nil {
+				log.Fatal(err)
+			}
+		}()
 	}
 
-	defer func() {
-		err := postprocess()
-		if err != nil {
-			log.Fatal(err)
-		}
-	}()
-
 	m.Run()
 }
+
+func skipWithoutLive(t *testing.T) {
+	t.Helper()
+
+	if errLive != nil {
+		t.Skip("live projects are not available:", errLive)
+	}
+}
+
 func preprocess() error {
 	mainBQ, err := bigquery.New(projectID)
 	if err != nil {
@@ -105,6 +118,8 @@ func postprocess() error {
 }
 
 func TestInsert(t *testing.T) {
+	skipWithoutLive(t)
+
 	bq, err := bigquery.New(projectID)
 	if err != nil {
 		t.Fatal(err)
@@ -119,6 +134,8 @@ func TestInsert(t *testing.T) {
 }
 
 func TestSuccess(t *testing.T) {
+	skipWithoutLive(t)
+
 	const selectQuery = "select id, name, age from `test_dataset2.test_table` order by id"
 
 	var mainBQ, subBQ *bigquery.BigQuery
@@ -0,0 +1,289 @@
+package bigquery_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	bqv2 "google.golang.org/api/bigquery/v2"
+	"google.golang.org/api/option"
+
+	"github.com/rssh-jp/data-access-library/gcp/bigquery"
+)
+
+const (
+	fakeProjectID = "fake-project"
+	fakeLocation  = "US"
+
+	// fakeErrorQuery makes fakeServer respond with invalidQuery error
+	fakeErrorQuery = "select error"
+)
+
+// fakeServer is BigQuery REST API stub.
+// Every query finishes immediately and returns fakeRows with fakeStatistics
+type fakeServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	seq     int
+	jobs    map[string]*bqv2.Job
+	queries []string
+	labels  []map[string]string
+
+	// regional makes jobs.get require the job location like a job outside the US and EU multi-regions
+	regional bool
+}
+
+var (
+	fakeSchema = &bqv2.TableSchema{
+		Fields: []*bqv2.TableFieldSchema{
+			{Name: "id", Type: "INTEGER"},
+			{Name: "name", Type: "STRING"},
+		},
+	}
+
+	fakeRows = []*bqv2.TableRow{
+		{F: []*bqv2.TableCell{{V: "1"}, {V: "aa"}}},
+		{F: []*bqv2.TableCell{{V: "2"}, {V: "bb"}}},
+	}
+)
+
+func fakeStatistics(dryRun bool) *bqv2.JobStatistics {
+	js := &bqv2.JobStatistics{
+		CreationTime:        1600000000000,
+		StartTime:           1600000001000,
+		EndTime:             1600000003000,
+		TotalBytesProcessed: 60,
+		Query: &bqv2.JobStatistics2{
+			StatementType:       "SELECT",
+			TotalBytesProcessed: 60,
+			TotalBytesBilled:    10485760,
+			CacheHit:            false,
+			TotalSlotMs:         42,
+			QueryPlan: []*bqv2.ExplainQueryStage{
+				{
+					Id:                 0,
+					Name:               "S00: Input",
+					Status:             "COMPLETE",
+					RecordsRead:        3,
+					RecordsWritten:     3,
+					ShuffleOutputBytes: 48,
+					WaitMsAvg:          1,
+					ReadMsAvg:          2,
+					ComputeMsAvg:       3,
+					WriteMsAvg:         4,
+					Steps: []*bqv2.ExplainQueryStep{
+						{Kind: "READ", Substeps: []string{"$1:id, $2:name", "FROM test_dataset.test_table"}},
+					},
+				},
+				{
+					Id:                 1,
+					Name:               "S01: Output",
+					Status:             "COMPLETE",
+					RecordsRead:        3,
+					RecordsWritten:     2,
+					ShuffleOutputBytes: 32,
+					InputStages:        []int64{0},
+				},
+			},
+			Timeline: []*bqv2.QueryTimelineSample{
+				{ElapsedMs: 500, ActiveUnits: 1, PendingUnits: 1, TotalSlotMs: 20},
+				{ElapsedMs: 1000, CompletedUnits: 2, TotalSlotMs: 42},
+			},
+			ReferencedTables: []*bqv2.TableReference{
+				{ProjectId: fakeProjectID, DatasetId: "test_dataset", TableId: "test_table"},
+			},
+		},
+	}
+
+	if dryRun {
+		js.StartTime = 0
+		js.EndTime = 0
+		js.Query.TotalBytesBilled = 0
+		js.Query.TotalSlotMs = 0
+		js.Query.QueryPlan = nil
+		js.Query.Timeline = nil
+	}
+
+	return js
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	t.Helper()
+
+	s := &fakeServer{
+		jobs: make(map[string]*bqv2.Job),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// newBigQuery returns BigQuery instance connected to the fake server
+func (s *fakeServer) newBigQuery(t *testing.T, opts ...bigquery.Option) *bigquery.BigQuery {
+	t.Helper()
+
+	opts = append([]bigquery.Option{
+		bigquery.OptionClientOptions(
+			option.WithEndpoint(s.URL+"/"),
+			option.WithoutAuthentication(),
+		),
+	}, opts...)
+
+	bq, err := bigquery.NewWithOptions(fakeProjectID, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return bq
+}
+
+// Queries returns the sql text of every submitted query
+func (s *fakeServer) Queries() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.queries...)
+}
+
+// Labels returns the job labels of every submitted query
+func (s *fakeServer) Labels() []map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]map[string]string(nil), s.labels...)
+}
+
+func (s *fakeServer) handle(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 3 || parts[0] != "projects" {
+		writeError(w, http.StatusNotFound, "notFound", r.URL.Path)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPost && len(parts) == 3 && parts[2] == "queries":
+		var req bqv2.QueryRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid", err.Error())
+			return
+		}
+		if req.Query == fakeErrorQuery {
+			writeError(w, http.StatusBadRequest, "invalidQuery", "Syntax error")
+			return
+		}
+		job := s.insert(req.Query, req.Labels, false)
+		writeJSON(w, &bqv2.QueryResponse{
+			JobReference: job.JobReference,
+			JobComplete:  true,
+			Schema:       fakeSchema,
+			Rows:         fakeRows,
+			TotalRows:    uint64(len(fakeRows)),
+		})
+	case r.Method == http.MethodPost && len(parts) == 3 && parts[2] == "jobs":
+		var req bqv2.Job
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid", err.Error())
+			return
+		}
+		if req.Configuration == nil || req.Configuration.Query == nil {
+			writeError(w, http.StatusBadRequest, "invalid", "query configuration is required")
+			return
+		}
+		if req.Configuration.Query.Query == fakeErrorQuery {
+			writeError(w, http.StatusBadRequest, "invalidQuery", "Syntax error")
+			return
+		}
+		writeJSON(w, s.insert(req.Configuration.Query.Query, req.Configuration.Labels, req.Configuration.DryRun))
+	case r.Method == http.MethodGet && len(parts) == 4 && parts[2] == "jobs":
+		job, ok := s.job(parts[3])
+		if ok && s.regional && r.URL.Query().Get("location") != job.JobReference.Location {
+			ok = false
+		}
+		if !ok {
+			writeError(w, http.StatusNotFound, "notFound", "Not found: Job "+parts[3])
+			return
+		}
+		writeJSON(w, job)
+	case r.Method == http.MethodGet && len(parts) == 4 && parts[2] == "queries":
+		job, ok := s.job(parts[3])
+		if !ok {
+			writeError(w, http.StatusNotFound, "notFound", "Not found: Job "+parts[3])
+			return
+		}
+		writeJSON(w, &bqv2.GetQueryResultsResponse{
+			JobReference: job.JobReference,
+			JobComplete:  true,
+			Schema:       fakeSchema,
+			Rows:         fakeRows,
+			TotalRows:    uint64(len(fakeRows)),
+		})
+	default:
+		writeError(w, http.StatusNotFound, "notFound", r.URL.Path)
+	}
+}
+
+func (s *fakeServer) insert(query string, labels map[string]string, dryRun bool) *bqv2.Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+	s.queries = append(s.queries, query)
+	s.labels = append(s.labels, labels)
+
+	job := &bqv2.Job{
+		JobReference: &bqv2.JobReference{
+			ProjectId: fakeProjectID,
+			JobId:     fmt.Sprintf("job_%d", s.seq),
+			Location:  fakeLocation,
+		},
+		Configuration: &bqv2.JobConfiguration{
+			DryRun: dryRun,
+			Query: &bqv2.JobConfigurationQuery{
+				Query: query,
+			},
+		},
+		Status: &bqv2.JobStatus{
+			State: "DONE",
+		},
+		Statistics: fakeStatistics(dryRun),
+	}
+
+	if !dryRun {
+		s.jobs[job.JobReference.JobId] = job
+	}
+
+	return job
+}
+
+func (s *fakeServer) job(jobID string) (*bqv2.Job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[jobID]
+	return job, ok
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, reason, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]interface{}{
+			"code":    code,
+			"message": message,
+			"errors": []map[string]interface{}{
+				{"reason": reason, "message": message},
+			},
+		},
+	})
+}
@@ -0,0 +1,120 @@
+package bigquery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+)
+
+// Operation names passed to interceptors on QueryRequest
+const (
+	OperationQuery        = "Query"
+	OperationExecute      = "Execute"
+	OperationExecuteAsync = "ExecuteAsync"
+)
+
+var (
+	// ErrPanicRecovered is wrapped by errors returned from InterceptorRecover
+	ErrPanicRecovered = errors.New("panic recovered")
+)
+
+// QueryRequest is query passing through interceptors.
+// Interceptors may rewrite Query and append Options before calling next
+type QueryRequest struct {
+	// Operation is one of OperationQuery, OperationExecute and OperationExecuteAsync
+	Operation string
+	Query     string
+	Options   []QueryOption
+}
+
+func newQueryRequest(operation, query string, queryOpts []QueryOption) *QueryRequest {
+	return &QueryRequest{
+		Operation: operation,
+		Query:     query,
+		Options:   queryOpts,
+	}
+}
+
+// QueryResult is result of query passing through interceptors.
+// Columns and Contents are set only for OperationQuery
+type QueryResult struct {
+	JobID string
+	// JobLocation is needed to look up the job outside the US and EU multi-regions
+	JobLocation string
+	Columns     []string
+	Contents    [][]string
+}
+
+func newQueryResult(job *bigquery.Job) *QueryResult {
+	res := &QueryResult{}
+	if job != nil {
+		res.JobID = job.ID()
+		res.JobLocation = job.Location()
+	}
+	return res
+}
+
+// QueryHandler executes QueryRequest
+type QueryHandler func(ctx context.Context, req *QueryRequest) (*QueryResult, error)
+
+// Interceptor wraps query execution. Call next to continue the chain
+type Interceptor func(ctx context.Context, req *QueryRequest, next QueryHandler) (*QueryResult, error)
+
+// intercept runs handler through the interceptor chain
+func (bq *BigQuery) intercept(ctx context.Context, req *QueryRequest, handler QueryHandler) (*QueryResult, error) {
+	h := handler
+	for i := len(bq.interceptors) - 1; i >= 0; i-- {
+		interceptor, next := bq.interceptors[i], h
+		h = func(ctx context.Context, req *QueryRequest) (*QueryResult, error) {
+			return interceptor(ctx, req, next)
+		}
+	}
+
+	res, err := h(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		res = &QueryResult{}
+	}
+
+	return res, nil
+}
+
+// InterceptorDefaultLabels returns Interceptor adding job labels.
+// Labels specified by QueryOptionLabels on each call take precedence
+func InterceptorDefaultLabels(labels map[string]string) Interceptor {
+	return func(ctx context.Context, req *QueryRequest, next QueryHandler) (*QueryResult, error) {
+		req.Options = append([]QueryOption{QueryOptionLabels(labels)}, req.Options...)
+		return next(ctx, req)
+	}
+}
+
+// InterceptorTimeout returns Interceptor limiting each call to timeout.
+// For ExecuteAsync the timeout covers job submission only
+func InterceptorTimeout(timeout time.Duration) Interceptor {
+	return func(ctx context.Context, req *QueryRequest, next QueryHandler) (*QueryResult, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		return next(ctx, req)
+	}
+}
+
+// InterceptorRecover returns Interceptor converting panic in later interceptors and the query into error wrapping ErrPanicRecovered
+func InterceptorRecover() Interceptor {
+	return func(ctx context.Context, req *QueryRequest, next QueryHandler) (res *QueryResult, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				res = nil
+				err = fmt.Errorf("%w: %v\n%s", ErrPanicRecovered, r, debug.Stack())
+			}
+		}()
+
+		return next(ctx, req)
+	}
+}
@@ -0,0 +1,154 @@
+package bigquery_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rssh-jp/data-access-library/gcp/bigquery"
+)
+
+func TestInterceptor(t *testing.T) {
+	const query = "select id, name from `test_dataset.test_table`"
+
+	t.Run("Order", func(t *testing.T) {
+		srv := newFakeServer(t)
+
+		var calls []string
+		record := func(name string) bigquery.Interceptor {
+			return func(ctx context.Context, req *bigquery.QueryRequest, next bigquery.QueryHandler) (*bigquery.QueryResult, error) {
+				calls = append(calls, name+":"+req.Operation)
+				return next(ctx, req)
+			}
+		}
+
+		b := srv.newBigQuery(t, bigquery.OptionInterceptors(record("outer"), record("inner")))
+
+		_, _, err := b.Query(context.Background(), query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = b.Execute(context.Background(), query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = b.ExecuteAsync(context.Background(), query)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expect := []string{
+			"outer:Query", "inner:Query",
+			"outer:Execute", "inner:Execute",
+			"outer:ExecuteAsync", "inner:ExecuteAsync",
+		}
+		if !reflect.DeepEqual(expect, calls) {
+			t.Errorf("Could not match calls.\nexpect: %v\nactual: %v", expect, calls)
+		}
+	})
+	t.Run("Rewrite query", func(t *testing.T) {
+		srv := newFakeServer(t)
+
+		b := srv.newBigQuery(t, bigquery.OptionInterceptors(
+			func(ctx context.Context, req *bigquery.QueryRequest, next bigquery.QueryHandler) (*bigquery.QueryResult, error) {
+				req.Query = "-- lint:ok\n" + req.Query
+				return next(ctx, req)
+			},
+		))
+
+		_, contents, err := b.Query(context.Background(), query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(contents) != 2 {
+			t.Errorf("Could not match contents length.\nexpect: %d\nactual: %d", 2, len(contents))
+		}
+
+		if queries := srv.Queries(); len(queries) != 1 || !strings.HasPrefix(queries[0], "-- lint:ok\n") {
+			t.Errorf("Could not rewrite query. actual: %v", queries)
+		}
+	})
+	t.Run("Short circuit", func(t *testing.T) {
+		srv := newFakeServer(t)
+
+		errLint := errors.New("select * is not allowed")
+		b := srv.newBigQuery(t, bigquery.OptionInterceptors(
+			func(ctx context.Context, req *bigquery.QueryRequest, next bigquery.QueryHandler) (*bigquery.QueryResult, error) {
+				if strings.Contains(req.Query, "*") {
+					return nil, errLint
+				}
+				return next(ctx, req)
+			},
+		))
+
+		err := b.Execute(context.Background(), "select * from t")
+		if !errors.Is(err, errLint) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", errLint, err)
+		}
+		if len(srv.Queries()) != 0 {
+			t.Errorf("Bug. Query is rejected. But submitted: %v", srv.Queries())
+		}
+	})
+	t.Run("DefaultLabels", func(t *testing.T) {
+		srv := newFakeServer(t)
+
+		b := srv.newBigQuery(t, bigquery.OptionInterceptors(
+			bigquery.InterceptorDefaultLabels(map[string]string{"team": "data", "env": "dev"}),
+		))
+
+		_, err := b.ExecuteAsync(context.Background(), query, bigquery.QueryOptionLabels(map[string]string{"env": "prod"}))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expect := []map[string]string{{"team": "data", "env": "prod"}}
+		if actual := srv.Labels(); !reflect.DeepEqual(expect, actual) {
+			t.Errorf("Could not match labels.\nexpect: %v\nactual: %v", expect, actual)
+		}
+	})
+	t.Run("Timeout", func(t *testing.T) {
+		srv := newFakeServer(t)
+
+		var deadline time.Time
+		b := srv.newBigQuery(t, bigquery.OptionInterceptors(
+			bigquery.InterceptorTimeout(time.Minute),
+			func(ctx context.Context, req *bigquery.QueryRequest, next bigquery.QueryHandler) (*bigquery.QueryResult, error) {
+				deadline, _ = ctx.Deadline()
+				return next(ctx, req)
+			},
+		))
+
+		err := b.Execute(context.Background(), query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if deadline.IsZero() || time.Until(deadline) > time.Minute {
+			t.Errorf("Could not set deadline. actual: %v", deadline)
+		}
+
+		b = srv.newBigQuery(t, bigquery.OptionInterceptors(bigquery.InterceptorTimeout(time.Nanosecond)))
+
+		_, err = b.ExecuteAsync(context.Background(), query)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", context.DeadlineExceeded, err)
+		}
+	})
+	t.Run("Recover", func(t *testing.T) {
+		srv := newFakeServer(t)
+
+		b := srv.newBigQuery(t, bigquery.OptionInterceptors(
+			bigquery.InterceptorRecover(),
+			func(ctx context.Context, req *bigquery.QueryRequest, next bigquery.QueryHandler) (*bigquery.QueryResult, error) {
+				panic("boom")
+			},
+		))
+
+		_, _, err := b.Query(context.Background(), query)
+		if !errors.Is(err, bigquery.ErrPanicRecovered) {
+			t.Errorf("Could not match error.\nexpect: %v\nactual: %v", bigquery.ErrPanicRecovered, err)
+		}
+	})
+}
@@ -0,0 +1,58 @@
+package bigquery
+
+import (
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// observation is a single instrumented call
+type observation struct {
+	operation  string
+	startTime  time.Time
+	span       trace.Span
+	attrs      []attribute.KeyValue
+	job        *bigquery.Job
+	status     *bigquery.JobStatus
+	statistics *bigquery.JobStatistics
+
+	// query fields are empty for JobStatus
+	query      string
+	parameters []bigquery.QueryParameter
+	dryRun     bool
+}
+
+// setQuery records the query text and its options
+func (o *observation) setQuery(query string, qc *queryConfig) {
+	o.query = query
+	o.parameters = qc.parameters
+	o.dryRun = qc.isDryRun
+}
+
+// setJob records the job and its most recent status
+func (o *observation) setJob(job *bigquery.Job) {
+	if job == nil {
+		return
+	}
+
+	o.job = job
+
+	if status := job.LastStatus(); status != nil {
+		o.setStatus(status)
+	}
+}
+
+// setStatus records the job status and its statistics
+func (o *observation) setStatus(status *bigquery.JobStatus) {
+	if status == nil {
+		return
+	}
+
+	o.status = status
+
+	if status.Statistics != nil {
+		o.statistics = status.Statistics
+	}
+}
@@ -0,0 +1,146 @@
+package bigquery
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/api/option"
+)
+
+type config struct {
+	clientOpts     []option.ClientOption
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	audit          auditConfig
+	interceptors   []Interceptor
+}
+
+func newConfig() *config {
+	return &config{
+		clientOpts:     nil,
+		tracerProvider: nil,
+		meterProvider:  nil,
+		audit:          newAuditConfig(),
+		interceptors:   nil,
+	}
+}
+
+func createConfig(opts ...Option) (*config, error) {
+	c := newConfig()
+
+	for _, opt := range opts {
+		err := opt(c)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// Option is functional option pattern option for NewWithOptions
+type Option func(*config) error
+
+// OptionClientOptions returns Option instance with client options passed through to bigquery.NewClient
+func OptionClientOptions(opts ...option.ClientOption) func(c *config) error {
+	return func(c *config) error {
+		c.clientOpts = append(c.clientOpts, opts...)
+		return nil
+	}
+}
+
+// OptionTracerProvider returns Option instance with OpenTelemetry tracer provider.
+// Spans are not recorded when this option is not specified
+func OptionTracerProvider(tp trace.TracerProvider) func(c *config) error {
+	return func(c *config) error {
+		c.tracerProvider = tp
+		return nil
+	}
+}
+
+// OptionMeterProvider returns Option instance with OpenTelemetry meter provider.
+// Metrics are not recorded when this option is not specified
+func OptionMeterProvider(mp metric.MeterProvider) func(c *config) error {
+	return func(c *config) error {
+		c.meterProvider = mp
+		return nil
+	}
+}
+
+// OptionAuditSinks returns Option instance with audit sinks.
+// Audit records are emitted for Query, Execute and ExecuteAsync when at least one sink is specified
+func OptionAuditSinks(sinks ...AuditSink) func(c *config) error {
+	return func(c *config) error {
+		c.audit.sinks = append(c.audit.sinks, sinks...)
+		return nil
+	}
+}
+
+// OptionAuditPrincipal returns Option instance with function resolving who runs the query.
+// The job owner email is used when this option is not specified
+func OptionAuditPrincipal(principal func(ctx context.Context) string) func(c *config) error {
+	return func(c *config) error {
+		c.audit.principal = principal
+		return nil
+	}
+}
+
+// OptionAuditRedactor returns Option instance with function redacting sql on audit records.
+// RedactSQL is used when this option is not specified
+func OptionAuditRedactor(redact func(sql string) string) func(c *config) error {
+	return func(c *config) error {
+		if redact == nil {
+			return errors.New("redactor is nil")
+		}
+		c.audit.redact = redact
+		return nil
+	}
+}
+
+// OptionAuditParameterValues returns Option instance recording values of query parameters on audit records.
+// Only names and types of parameters are recorded by default because values are not redacted
+func OptionAuditParameterValues() func(c *config) error {
+	return func(c *config) error {
+		c.audit.paramValues = true
+		return nil
+	}
+}
+
+// OptionAuditBufferSize returns Option instance with the number of audit records buffered.
+// Records exceeding the buffer are dropped so that queries never wait for sinks
+func OptionAuditBufferSize(size int) func(c *config) error {
+	return func(c *config) error {
+		if size < 1 {
+			return errors.New("buffer size must be positive")
+		}
+		c.audit.bufferSize = size
+		return nil
+	}
+}
+
+// OptionAuditErrorHandler returns Option instance with function receiving sink errors
+func OptionAuditErrorHandler(handler func(err error)) func(c *config) error {
+	return func(c *config) error {
+		if handler == nil {
+			return errors.New("error handler is nil")
+		}
+		c.audit.errorHandler = handler
+		return nil
+	}
+}
+
+// OptionInterceptors returns Option instance with interceptors applied to Query, Execute and ExecuteAsync.
+// The first interceptor is the outermost
+func OptionInterceptors(interceptors ...Interceptor) func(c *config) error {
+	return func(c *config) error {
+		for _, interceptor := range interceptors {
+			if interceptor == nil {
+				return errors.New("interceptor is nil")
+			}
+		}
+		c.interceptors = append(c.interceptors, interceptors...)
+		return nil
+	}
+}
@@ -13,6 +13,9 @@ type queryConfig struct {
 	writeDisposition  *bigquery.TableWriteDisposition
 	dstTable          *bigquery.Table
 	jobStatistics     *bigquery.JobStatistics
+	queryReport       *QueryReport
+	parameters        []bigquery.QueryParameter
+	labels            map[string]string
 }
 
 func newQueryConfig() *queryConfig {
@@ -23,9 +26,17 @@ func newQueryConfig() *queryConfig {
 		writeDisposition:  nil,
 		dstTable:          nil,
 		jobStatistics:     nil,
+		queryReport:       nil,
+		parameters:        nil,
+		labels:            nil,
 	}
 }
 
+// needsStatistics reports whether statistics references are specified
+func (c *queryConfig) needsStatistics() bool {
+	return c.jobStatistics != nil || c.queryReport != nil
+}
+
 func createQueryConfig(queryOpts ...QueryOption) (*queryConfig, error) {
 	qc := newQueryConfig()
 
@@ -103,7 +114,8 @@ func QueryOptionWriteEmpty() func(c *queryConfig) error {
 	}
 }
 
-// QueryOptionSetJobStatisticsReference returns QueryOption instance with JobStatistics reference
+// QueryOptionSetJobStatisticsReference returns QueryOption instance with JobStatistics reference.
+// The statistics are populated in every mode. For ExecuteAsync they are the ones at job submission
 func QueryOptionSetJobStatisticsReference(js *bigquery.JobStatistics) func(c *queryConfig) error {
 	return func(c *queryConfig) error {
 		c.jobStatistics = js
@@ -111,6 +123,37 @@ func QueryOptionSetJobStatisticsReference(js *bigquery.JobStatistics) func(c *qu
 	}
 }
 
+// QueryOptionSetQueryReportReference returns QueryOption instance with QueryReport reference.
+// The report is populated in every mode. For ExecuteAsync it is the one at job submission, use JobReport afterwards
+func QueryOptionSetQueryReportReference(r *QueryReport) func(c *queryConfig) error {
+	return func(c *queryConfig) error {
+		c.queryReport = r
+		return nil
+	}
+}
+
+// QueryOptionParameters returns QueryOption instance with query parameters
+func QueryOptionParameters(params ...bigquery.QueryParameter) func(c *queryConfig) error {
+	return func(c *queryConfig) error {
+		c.parameters = append(c.parameters, params...)
+		return nil
+	}
+}
+
+// QueryOptionLabels returns QueryOption instance with job labels.
+// Labels are merged when specified multiple times. the later one wins
+func QueryOptionLabels(labels map[string]string) func(c *queryConfig) error {
+	return func(c *queryConfig) error {
+		if c.labels == nil {
+			c.labels = make(map[string]string, len(labels))
+		}
+		for k, v := range labels {
+			c.labels[k] = v
+		}
+		return nil
+	}
+}
+
 // QueryOptionDstTable returns QueryOption instance with destination table
 func QueryOptionDstTable(bq *BigQuery, datasetID, tableID string) func(c *queryConfig) error {
 	return func(c *queryConfig) error {
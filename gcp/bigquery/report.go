@@ -0,0 +1,218 @@
+package bigquery
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+)
+
+// QueryReport is query plan and execution statistics of query job
+type QueryReport struct {
+	JobID     string
+	ProjectID string
+	Location  string
+
+	StatementType string
+	CreationTime  time.Time
+	StartTime     time.Time
+	EndTime       time.Time
+
+	TotalBytesProcessed int64
+	TotalBytesBilled    int64
+	CacheHit            bool
+	SlotMillis          int64
+
+	// ShuffleOutputBytes and ShuffleOutputBytesSpilled are the sum of all stages
+	ShuffleOutputBytes        int64
+	ShuffleOutputBytesSpilled int64
+
+	NumDMLAffectedRows int64
+	DMLStats           *bigquery.DMLStatistics
+
+	// ReferencedTables is fully qualified names of the tables read by the query
+	ReferencedTables []string
+
+	Stages   []*bigquery.ExplainQueryStage
+	Timeline []*bigquery.QueryTimelineSample
+}
+
+// NewQueryReport returns QueryReport instance built from job statistics. job may be nil
+func NewQueryReport(job *bigquery.Job, js *bigquery.JobStatistics) *QueryReport {
+	r := &QueryReport{}
+
+	if job != nil {
+		r.JobID = job.ID()
+		r.ProjectID = job.ProjectID()
+		r.Location = job.Location()
+	}
+
+	if js == nil {
+		return r
+	}
+
+	r.CreationTime = js.CreationTime
+	r.StartTime = js.StartTime
+	r.EndTime = js.EndTime
+	r.TotalBytesProcessed = js.TotalBytesProcessed
+
+	qs, ok := js.Details.(*bigquery.QueryStatistics)
+	if !ok {
+		return r
+	}
+
+	r.StatementType = qs.StatementType
+	r.TotalBytesProcessed = qs.TotalBytesProcessed
+	r.TotalBytesBilled = qs.TotalBytesBilled
+	r.CacheHit = qs.CacheHit
+	r.SlotMillis = qs.SlotMillis
+	r.NumDMLAffectedRows = qs.NumDMLAffectedRows
+	r.DMLStats = qs.DMLStats
+	r.Stages = qs.QueryPlan
+	r.Timeline = qs.Timeline
+
+	for _, stage := range qs.QueryPlan {
+		r.ShuffleOutputBytes += stage.ShuffleOutputBytes
+		r.ShuffleOutputBytesSpilled += stage.ShuffleOutputBytesSpilled
+	}
+
+	for _, table := range qs.ReferencedTables {
+		r.ReferencedTables = append(r.ReferencedTables, table.ProjectID+"."+table.DatasetID+"."+table.TableID)
+	}
+
+	return r
+}
+
+// Duration returns elapsed time from start to end of the job
+func (r *QueryReport) Duration() time.Duration {
+	if r.StartTime.IsZero() || r.EndTime.IsZero() {
+		return 0
+	}
+	return r.EndTime.Sub(r.StartTime)
+}
+
+// String returns human readable multi-line report
+func (r *QueryReport) String() string {
+	var b strings.Builder
+
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+
+	if r.JobID != "" {
+		fmt.Fprintf(w, "Job:\t%s\n", r.jobName())
+	}
+	if r.StatementType != "" {
+		fmt.Fprintf(w, "Statement type:\t%s\n", r.StatementType)
+	}
+	if d := r.Duration(); d > 0 {
+		fmt.Fprintf(w, "Duration:\t%s\n", d)
+	}
+	fmt.Fprintf(w, "Bytes processed:\t%s\n", formatBytes(r.TotalBytesProcessed))
+	fmt.Fprintf(w, "Bytes billed:\t%s\n", formatBytes(r.TotalBytesBilled))
+	fmt.Fprintf(w, "Slot time:\t%s\n", time.Duration(r.SlotMillis)*time.Millisecond)
+	fmt.Fprintf(w, "Shuffle output:\t%s (spilled %s)\n", formatBytes(r.ShuffleOutputBytes), formatBytes(r.ShuffleOutputBytesSpilled))
+	fmt.Fprintf(w, "Cache hit:\t%t\n", r.CacheHit)
+	if r.DMLStats != nil {
+		fmt.Fprintf(w, "DML affected rows:\t%d (inserted %d, updated %d, deleted %d)\n", r.NumDMLAffectedRows, r.DMLStats.InsertedRowCount, r.DMLStats.UpdatedRowCount, r.DMLStats.DeletedRowCount)
+	} else if r.NumDMLAffectedRows > 0 {
+		fmt.Fprintf(w, "DML affected rows:\t%d\n", r.NumDMLAffectedRows)
+	}
+	if len(r.ReferencedTables) > 0 {
+		fmt.Fprintf(w, "Referenced tables:\t%s\n", strings.Join(r.ReferencedTables, ", "))
+	}
+	w.Flush()
+
+	if len(r.Stages) > 0 {
+		b.WriteString("\nQuery plan:\n")
+
+		w = tabwriter.NewWriter(&b, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(w, "ID\tStage\tStatus\tRecords read\tRecords written\tShuffle output\tWait avg\tRead avg\tCompute avg\tWrite avg\t")
+		for _, stage := range r.Stages {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%d\t%s\t%s\t%s\t%s\t%s\t\n",
+				stage.ID,
+				stage.Name,
+				stage.Status,
+				stage.RecordsRead,
+				stage.RecordsWritten,
+				formatBytes(stage.ShuffleOutputBytes),
+				stage.WaitAvg,
+				stage.ReadAvg,
+				stage.ComputeAvg,
+				stage.WriteAvg,
+			)
+		}
+		w.Flush()
+
+		for _, stage := range r.Stages {
+			for _, step := range stage.Steps {
+				fmt.Fprintf(&b, "  %s %s: %s\n", stage.Name, step.Kind, strings.Join(step.Substeps, "; "))
+			}
+		}
+	}
+
+	if len(r.Timeline) > 0 {
+		b.WriteString("\nTimeline:\n")
+
+		w = tabwriter.NewWriter(&b, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(w, "Elapsed\tActive\tPending\tCompleted\tSlot ms\t")
+		for _, sample := range r.Timeline {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t\n",
+				sample.Elapsed,
+				sample.ActiveUnits,
+				sample.PendingUnits,
+				sample.CompletedUnits,
+				sample.SlotMillis,
+			)
+		}
+		w.Flush()
+	}
+
+	return b.String()
+}
+
+// LogValue implements slog.LogValuer with summary of the report
+func (r *QueryReport) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("job", r.jobName()),
+		slog.String("statement_type", r.StatementType),
+		slog.Duration("duration", r.Duration()),
+		slog.Int64("total_bytes_processed", r.TotalBytesProcessed),
+		slog.Int64("total_bytes_billed", r.TotalBytesBilled),
+		slog.Int64("slot_ms", r.SlotMillis),
+		slog.Int64("shuffle_output_bytes", r.ShuffleOutputBytes),
+		slog.Bool("cache_hit", r.CacheHit),
+		slog.Int64("dml_affected_rows", r.NumDMLAffectedRows),
+		slog.Int("stages", len(r.Stages)),
+		slog.Any("referenced_tables", r.ReferencedTables),
+	)
+}
+
+// jobName returns job name in project:location.job_id form same as bq command
+func (r *QueryReport) jobName() string {
+	name := r.JobID
+	if r.Location != "" {
+		name = r.Location + "." + name
+	}
+	if r.ProjectID != "" {
+		name = r.ProjectID + ":" + name
+	}
+	return name
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return strconv.FormatInt(n, 10) + " B"
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit && exp < 5; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,112 @@
+package bigquery_test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	bq "cloud.google.com/go/bigquery"
+
+	"github.com/rssh-jp/data-access-library/gcp/bigquery"
+)
+
+func TestQueryReport(t *testing.T) {
+	const query = "select id, name from `test_dataset.test_table`"
+
+	t.Run("JobStatistics", func(t *testing.T) {
+		srv := newFakeServer(t)
+		b := srv.newBigQuery(t)
+
+		js := new(bq.JobStatistics)
+		err := b.Execute(context.Background(), query, bigquery.QueryOptionSetJobStatisticsReference(js))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		qs, ok := js.Details.(*bq.QueryStatistics)
+		if !ok {
+			t.Fatalf("Could not populate query statistics. actual: %#v", js.Details)
+		}
+		if qs.SlotMillis != 42 {
+			t.Errorf("Could not match slot ms.\nexpect: %d\nactual: %d", 42, qs.SlotMillis)
+		}
+	})
+	t.Run("Query", func(t *testing.T) {
+		srv := newFakeServer(t)
+		b := srv.newBigQuery(t)
+
+		var report bigquery.QueryReport
+		_, _, err := b.Query(context.Background(), query, bigquery.QueryOptionSetQueryReportReference(&report))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if report.JobID != "job_1" {
+			t.Errorf("Could not match job id.\nexpect: %s\nactual: %s", "job_1", report.JobID)
+		}
+		if report.Duration() != 2*time.Second {
+			t.Errorf("Could not match duration.\nexpect: %v\nactual: %v", 2*time.Second, report.Duration())
+		}
+		if report.ShuffleOutputBytes != 80 {
+			t.Errorf("Could not match shuffle output bytes.\nexpect: %d\nactual: %d", 80, report.ShuffleOutputBytes)
+		}
+		if len(report.Stages) != 2 || len(report.Timeline) != 2 {
+			t.Errorf("Could not match stages and timeline. actual: %d, %d", len(report.Stages), len(report.Timeline))
+		}
+
+		expectTables := []string{fakeProjectID + ".test_dataset.test_table"}
+		if !reflect.DeepEqual(expectTables, report.ReferencedTables) {
+			t.Errorf("Could not match referenced tables.\nexpect: %v\nactual: %v", expectTables, report.ReferencedTables)
+		}
+
+		s := report.String()
+		for _, expect := range []string{
+			"fake-project:US.job_1",
+			"Bytes billed:       10.0 MiB",
+			"S00: Input",
+			"S00: Input READ: $1:id, $2:name; FROM test_dataset.test_table",
+			"Timeline:",
+		} {
+			if !strings.Contains(s, expect) {
+				t.Errorf("Could not found %q on report.\n%s", expect, s)
+			}
+		}
+	})
+	t.Run("Dry run", func(t *testing.T) {
+		srv := newFakeServer(t)
+		b := srv.newBigQuery(t)
+
+		var report bigquery.QueryReport
+		_, err := b.ExecuteAsync(context.Background(), query, bigquery.QueryOptionSetQueryReportReference(&report), bigquery.QueryOptionIsDryRun())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if report.TotalBytesProcessed != 60 {
+			t.Errorf("Could not match bytes processed.\nexpect: %d\nactual: %d", 60, report.TotalBytesProcessed)
+		}
+		if len(report.Stages) != 0 {
+			t.Errorf("Bug. Dry run has no query plan. actual: %d", len(report.Stages))
+		}
+	})
+	t.Run("JobReport", func(t *testing.T) {
+		srv := newFakeServer(t)
+		b := srv.newBigQuery(t)
+
+		jobID, err := b.ExecuteAsync(context.Background(), query)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		report, err := b.JobReport(context.Background(), jobID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if report.JobID != jobID || report.SlotMillis != 42 {
+			t.Errorf("Could not match report. actual: %+v", report)
+		}
+	})
+}
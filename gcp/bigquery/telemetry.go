@@ -0,0 +1,197 @@
+package bigquery
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/trace"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+)
+
+const instrumentationName = "github.com/rssh-jp/data-access-library/gcp/bigquery"
+
+// Attribute keys recorded on spans and metrics
+const (
+	AttributeOperation      = attribute.Key("bigquery.operation")
+	AttributeJobID          = attribute.Key("bigquery.job.id")
+	AttributeJobLocation    = attribute.Key("bigquery.job.location")
+	AttributeJobState       = attribute.Key("bigquery.job.state")
+	AttributeDryRun         = attribute.Key("bigquery.dry_run")
+	AttributeStatementType  = attribute.Key("bigquery.statement_type")
+	AttributeBytesProcessed = attribute.Key("bigquery.bytes_processed")
+	AttributeBytesBilled    = attribute.Key("bigquery.bytes_billed")
+	AttributeCacheHit       = attribute.Key("bigquery.cache_hit")
+	AttributeSlotMillis     = attribute.Key("bigquery.slot_ms")
+	AttributeStatus         = attribute.Key("bigquery.status")
+)
+
+type telemetry struct {
+	// enabled reports whether a provider was configured.
+	// Final job statistics cost an extra request, so they are fetched only when enabled
+	enabled bool
+
+	tracer         trace.Tracer
+	duration       metric.Float64Histogram
+	bytesProcessed metric.Int64Histogram
+	bytesBilled    metric.Int64Histogram
+	slotMillis     metric.Int64Histogram
+}
+
+func newTelemetry(tp trace.TracerProvider, mp metric.MeterProvider) (*telemetry, error) {
+	t := &telemetry{
+		enabled: tp != nil || mp != nil,
+	}
+
+	if tp == nil {
+		tp = tracenoop.NewTracerProvider()
+	}
+	if mp == nil {
+		mp = metricnoop.NewMeterProvider()
+	}
+
+	t.tracer = tp.Tracer(instrumentationName)
+
+	meter := mp.Meter(instrumentationName)
+
+	var err error
+
+	t.duration, err = meter.Float64Histogram(
+		"bigquery.client.duration",
+		metric.WithDescription("Duration of BigQuery wrapper calls"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	t.bytesProcessed, err = meter.Int64Histogram(
+		"bigquery.client.bytes_processed",
+		metric.WithDescription("Total bytes processed by query jobs"),
+		metric.WithUnit("By"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	t.bytesBilled, err = meter.Int64Histogram(
+		"bigquery.client.bytes_billed",
+		metric.WithDescription("Total bytes billed by query jobs"),
+		metric.WithUnit("By"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	t.slotMillis, err = meter.Int64Histogram(
+		"bigquery.client.slot_ms",
+		metric.WithDescription("Slot milliseconds consumed by query jobs"),
+		metric.WithUnit("ms"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (t *telemetry) start(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, *observation) {
+	attrs = append([]attribute.KeyValue{AttributeOperation.String(operation)}, attrs...)
+
+	ctx, span := t.tracer.Start(ctx, "bigquery."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+
+	return ctx, &observation{
+		operation: operation,
+		startTime: time.Now(),
+		span:      span,
+		attrs:     attrs,
+	}
+}
+
+func (t *telemetry) end(ctx context.Context, o *observation, err error) {
+	attrs := o.attrs
+
+	if o.job != nil {
+		attrs = append(attrs, AttributeJobID.String(o.job.ID()))
+		if o.job.Location() != "" {
+			attrs = append(attrs, AttributeJobLocation.String(o.job.Location()))
+		}
+	}
+
+	if o.status != nil {
+		attrs = append(attrs, AttributeJobState.String(jobStateString(o.status.State)))
+	}
+
+	var qs *bigquery.QueryStatistics
+	if o.statistics != nil {
+		qs, _ = o.statistics.Details.(*bigquery.QueryStatistics)
+	}
+
+	if qs != nil && qs.StatementType != "" {
+		attrs = append(attrs, AttributeStatementType.String(qs.StatementType))
+	}
+
+	o.span.SetAttributes(attrs...)
+
+	if qs != nil {
+		o.span.SetAttributes(
+			AttributeBytesProcessed.Int64(qs.TotalBytesProcessed),
+			AttributeBytesBilled.Int64(qs.TotalBytesBilled),
+			AttributeCacheHit.Bool(qs.CacheHit),
+			AttributeSlotMillis.Int64(qs.SlotMillis),
+		)
+	} else if o.statistics != nil {
+		o.span.SetAttributes(AttributeBytesProcessed.Int64(o.statistics.TotalBytesProcessed))
+	}
+
+	status := "ok"
+	if err != nil {
+		status = "error"
+		o.span.RecordError(err)
+		o.span.SetStatus(codes.Error, err.Error())
+	}
+
+	o.span.End()
+
+	// Keep metric cardinality low: job id and location are span only attributes
+	metricAttrs := []attribute.KeyValue{
+		AttributeOperation.String(o.operation),
+		AttributeStatus.String(status),
+	}
+	for _, attr := range o.attrs {
+		if attr.Key == AttributeDryRun {
+			metricAttrs = append(metricAttrs, attr)
+		}
+	}
+	if qs != nil && qs.StatementType != "" {
+		metricAttrs = append(metricAttrs, AttributeStatementType.String(qs.StatementType))
+	}
+	set := metric.WithAttributes(metricAttrs...)
+
+	t.duration.Record(ctx, time.Since(o.startTime).Seconds(), set)
+
+	if qs != nil {
+		t.bytesProcessed.Record(ctx, qs.TotalBytesProcessed, set)
+		t.bytesBilled.Record(ctx, qs.TotalBytesBilled, set)
+		t.slotMillis.Record(ctx, qs.SlotMillis, set)
+	}
+}
+
+func jobStateString(state bigquery.State) string {
+	switch state {
+	case bigquery.Pending:
+		return "PENDING"
+	case bigquery.Running:
+		return "RUNNING"
+	case bigquery.Done:
+		return "DONE"
+	}
+	return "UNSPECIFIED"
+}
@@ -0,0 +1,248 @@
+package bigquery_test
+
+import (
+	"context"
+	"testing"
+
+	bq "cloud.google.com/go/bigquery"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/rssh-jp/data-access-library/gcp/bigquery"
+)
+
+type telemetryRecorder struct {
+	spans  *tracetest.SpanRecorder
+	reader *sdkmetric.ManualReader
+}
+
+func newTelemetryRecorder() (*telemetryRecorder, []bigquery.Option) {
+	r := &telemetryRecorder{
+		spans:  tracetest.NewSpanRecorder(),
+		reader: sdkmetric.NewManualReader(),
+	}
+
+	opts := []bigquery.Option{
+		bigquery.OptionTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(r.spans))),
+		bigquery.OptionMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(r.reader))),
+	}
+
+	return r, opts
+}
+
+// span returns the last ended span with the name
+func (r *telemetryRecorder) span(t *testing.T, name string) sdktrace.ReadOnlySpan {
+	t.Helper()
+
+	spans := r.spans.Ended()
+	for i := len(spans) - 1; i >= 0; i-- {
+		if spans[i].Name() == name {
+			return spans[i]
+		}
+	}
+
+	t.Fatalf("Could not found span %q", name)
+	return nil
+}
+
+// histogram returns the data points of the histogram with the name
+func (r *telemetryRecorder) histogram(t *testing.T, name string) []metricdata.HistogramDataPoint[int64] {
+	t.Helper()
+
+	var rm metricdata.ResourceMetrics
+	if err := r.reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != name {
+				continue
+			}
+			if h, ok := m.Data.(metricdata.Histogram[int64]); ok {
+				return h.DataPoints
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *telemetryRecorder) durationCount(t *testing.T) uint64 {
+	t.Helper()
+
+	var rm metricdata.ResourceMetrics
+	if err := r.reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+
+	var count uint64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if h, ok := m.Data.(metricdata.Histogram[float64]); ok && m.Name == "bigquery.client.duration" {
+				for _, dp := range h.DataPoints {
+					count += dp.Count
+				}
+			}
+		}
+	}
+
+	return count
+}
+
+func spanAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	ret := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		ret[kv.Key] = kv.Value
+	}
+	return ret
+}
+
+func TestTelemetry(t *testing.T) {
+	const query = "select id, name from `test_dataset.test_table`"
+
+	t.Run("Query", func(t *testing.T) {
+		srv := newFakeServer(t)
+		rec, opts := newTelemetryRecorder()
+		b := srv.newBigQuery(t, opts...)
+
+		_, contents, err := b.Query(context.Background(), query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(contents) != 2 {
+			t.Errorf("Could not match contents length.\nexpect: %d\nactual: %d", 2, len(contents))
+		}
+
+		attrs := spanAttributes(rec.span(t, "bigquery.Query"))
+
+		expects := map[attribute.Key]attribute.Value{
+			bigquery.AttributeJobID:          attribute.StringValue("job_1"),
+			bigquery.AttributeJobLocation:    attribute.StringValue(fakeLocation),
+			bigquery.AttributeStatementType:  attribute.StringValue("SELECT"),
+			bigquery.AttributeBytesProcessed: attribute.Int64Value(60),
+			bigquery.AttributeBytesBilled:    attribute.Int64Value(10485760),
+			bigquery.AttributeCacheHit:       attribute.BoolValue(false),
+			bigquery.AttributeSlotMillis:     attribute.Int64Value(42),
+		}
+		for key, expect := range expects {
+			if attrs[key] != expect {
+				t.Errorf("Could not match attribute %s.\nexpect: %v\nactual: %v", key, expect.Emit(), attrs[key].Emit())
+			}
+		}
+
+		dps := rec.histogram(t, "bigquery.client.bytes_billed")
+		if len(dps) != 1 || dps[0].Sum != 10485760 {
+			t.Errorf("Could not match bytes_billed histogram. actual: %+v", dps)
+		}
+
+		if count := rec.durationCount(t); count != 1 {
+			t.Errorf("Could not match duration count.\nexpect: %d\nactual: %d", 1, count)
+		}
+	})
+	t.Run("Execute", func(t *testing.T) {
+		srv := newFakeServer(t)
+		rec, opts := newTelemetryRecorder()
+		b := srv.newBigQuery(t, opts...)
+
+		err := b.Execute(context.Background(), query)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		attrs := spanAttributes(rec.span(t, "bigquery.Execute"))
+		if attrs[bigquery.AttributeSlotMillis] != attribute.Int64Value(42) {
+			t.Errorf("Could not match slot ms. actual: %v", attrs[bigquery.AttributeSlotMillis].Emit())
+		}
+	})
+	t.Run("ExecuteAsync", func(t *testing.T) {
+		srv := newFakeServer(t)
+		rec, opts := newTelemetryRecorder()
+		b := srv.newBigQuery(t, opts...)
+
+		jobID, err := b.ExecuteAsync(context.Background(), query)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		attrs := spanAttributes(rec.span(t, "bigquery.ExecuteAsync"))
+		if attrs[bigquery.AttributeJobID] != attribute.StringValue(jobID) {
+			t.Errorf("Could not match job id.\nexpect: %s\nactual: %v", jobID, attrs[bigquery.AttributeJobID].Emit())
+		}
+	})
+	t.Run("JobStatus", func(t *testing.T) {
+		srv := newFakeServer(t)
+		rec, opts := newTelemetryRecorder()
+		b := srv.newBigQuery(t, opts...)
+
+		jobID, err := b.ExecuteAsync(context.Background(), query)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		status, err := b.JobStatus(context.Background(), jobID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.State != bq.Done {
+			t.Errorf("Could not match state.\nexpect: %v\nactual: %v", bq.Done, status.State)
+		}
+
+		attrs := spanAttributes(rec.span(t, "bigquery.JobStatus"))
+		if attrs[bigquery.AttributeJobState] != attribute.StringValue("DONE") {
+			t.Errorf("Could not match job state. actual: %v", attrs[bigquery.AttributeJobState].Emit())
+		}
+	})
+	t.Run("Dry run", func(t *testing.T) {
+		srv := newFakeServer(t)
+		rec, opts := newTelemetryRecorder()
+		b := srv.newBigQuery(t, opts...)
+
+		js := new(bq.JobStatistics)
+		_, _, err := b.Query(context.Background(), query, bigquery.QueryOptionSetJobStatisticsReference(js), bigquery.QueryOptionIsDryRun())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		attrs := spanAttributes(rec.span(t, "bigquery.Query"))
+		if attrs[bigquery.AttributeDryRun] != attribute.BoolValue(true) {
+			t.Errorf("Could not match dry run. actual: %v", attrs[bigquery.AttributeDryRun].Emit())
+		}
+		if attrs[bigquery.AttributeBytesProcessed] != attribute.Int64Value(60) {
+			t.Errorf("Could not match bytes processed. actual: %v", attrs[bigquery.AttributeBytesProcessed].Emit())
+		}
+	})
+	t.Run("Error", func(t *testing.T) {
+		srv := newFakeServer(t)
+		rec, opts := newTelemetryRecorder()
+		b := srv.newBigQuery(t, opts...)
+
+		_, _, err := b.Query(context.Background(), fakeErrorQuery)
+		if err == nil {
+			t.Fatal("Bug. Query is invalid. But returns not error")
+		}
+
+		span := rec.span(t, "bigquery.Query")
+		if span.Status().Code != codes.Error {
+			t.Errorf("Could not match span status.\nexpect: %v\nactual: %v", codes.Error, span.Status().Code)
+		}
+
+		attrs := spanAttributes(span)
+		if attrs[bigquery.AttributeStatus].Type() != attribute.INVALID {
+			t.Errorf("Bug. status attribute is metric only. actual: %v", attrs[bigquery.AttributeStatus].Emit())
+		}
+	})
+	t.Run("No-op default", func(t *testing.T) {
+		srv := newFakeServer(t)
+		b := srv.newBigQuery(t)
+
+		_, _, err := b.Query(context.Background(), query)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+}